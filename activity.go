@@ -0,0 +1,88 @@
+package a25
+
+import (
+	"time"
+
+	"github.com/lordtatty/a25/events"
+	"github.com/lordtatty/a25/feed"
+	"github.com/lordtatty/a25/memory"
+	"github.com/lordtatty/a25/social"
+)
+
+// LogEvents records the agent's activity to the sink: every memory added,
+// reaction decided, plan change and LLM call made by the agent or its modules.
+func (a *Agent) LogEvents(sink events.Sink) {
+	a.Events = sink
+	client := &events.Client{Client: a.Client, Sink: sink, AgentID: a.ID, Agent: a.Name}
+	a.Client = client
+	a.Memory.Client = client
+	a.Modules.Planner.Client = client
+	a.Modules.React.Client = client
+	a.Modules.Reflector.Client = client
+	a.Modules.Dialogue.Client = client
+	a.Modules.Relationships.Client = client
+	a.onMemoryAdded(func(m memory.MemoryObject) {
+		a.emit(events.MemoryAdded, map[string]any{
+			"description": m.Description,
+			"importance":  m.Importance,
+			"kind":        m.Kind,
+		})
+	})
+}
+
+// StreamMemories publishes each memory the agent forms to the hub, where it is
+// served on the agent's name.
+func (a *Agent) StreamMemories(h *feed.Hub) {
+	a.onMemoryAdded(func(m memory.MemoryObject) {
+		h.Publish(a.Name, m)
+	})
+}
+
+// onMemoryAdded chains fn onto the memory stream's OnMemoryAdded hook.
+func (a *Agent) onMemoryAdded(fn func(memory.MemoryObject)) {
+	prev := a.Memory.Hooks.OnMemoryAdded
+	a.Memory.Hooks.OnMemoryAdded = func(m memory.MemoryObject) {
+		if prev != nil {
+			prev(m)
+		}
+		fn(m)
+	}
+}
+
+// JoinGraph registers the agent in a shared interaction graph.
+func (a *Agent) JoinGraph(g *social.Graph) {
+	a.Social = g
+	g.AddAgent(a.Name)
+}
+
+// interact records an interaction with another agent in the social graph.
+func (a *Agent) interact(to, kind string) {
+	if a.Social == nil {
+		return
+	}
+	a.Social.Record(a.Name, to, kind, a.Clock().Now())
+}
+
+// observed records the agent observing any known agents named in the observation.
+func (a *Agent) observed(observation string, t time.Time) {
+	if a.Social == nil {
+		return
+	}
+	for _, n := range a.Social.Mentions(observation) {
+		a.Social.Record(a.Name, n, social.Observed, t)
+	}
+}
+
+// emit records an event if the agent has an event sink.
+func (a *Agent) emit(typ string, data map[string]any) {
+	if a.Events == nil {
+		return
+	}
+	a.Events.Emit(events.Event{
+		Time:    a.Clock().Now(),
+		AgentID: a.ID,
+		Agent:   a.Name,
+		Type:    typ,
+		Data:    data,
+	})
+}
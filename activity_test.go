@@ -0,0 +1,31 @@
+package a25
+
+import (
+	"testing"
+
+	"github.com/lordtatty/a25/events"
+	"github.com/lordtatty/a25/memory"
+)
+
+// recorder is an events.Sink keeping every event.
+type recorder []events.Event
+
+func (r *recorder) Emit(e events.Event) { *r = append(*r, e) }
+
+func TestLogEventsKeepsMemoryHooks(t *testing.T) {
+	a := NewAgent("Klaus", "curious", "a student", nil)
+	var hooked []string
+	a.Memory.Hooks.OnMemoryAdded = func(m memory.MemoryObject) {
+		hooked = append(hooked, m.Description)
+	}
+	var log recorder
+	a.LogEvents(&log)
+	a.Memory.Hooks.OnMemoryAdded(memory.MemoryObject{Description: "Klaus read a book."})
+
+	if len(hooked) != 1 {
+		t.Errorf("the existing hook ran %d times, want once", len(hooked))
+	}
+	if len(log) != 1 || log[0].Type != events.MemoryAdded {
+		t.Errorf("logged %v, want one %s event", log, events.MemoryAdded)
+	}
+}
@@ -0,0 +1,138 @@
+package a25
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/lordtatty/a25/save"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Actor is anything that can be hosted in a simulation. Agent implements it with
+// the full cognitive architecture; simpler scripted characters or experimental
+// architectures can implement it too and run side by side with agents.
+type Actor interface {
+	// Step advances the actor to the given time.
+	Step(now time.Time) error
+	// Perceive delivers an observation to the actor.
+	Perceive(observation string, now time.Time) error
+	// Interview asks the actor a question and returns its answer.
+	Interview(question string) (string, error)
+	// Save writes the actor's state to w.
+	Save(w io.Writer) error
+}
+
+var (
+	_ Actor = (*Agent)(nil)
+	_ Actor = (*Scripted)(nil)
+)
+
+// Step advances the agent to the given time, running any triggers that have come
+// due, reflecting if enough has happened and letting an exhausted agent decide
+// whether to end its day.
+func (a *Agent) Step(now time.Time) error {
+	if err := a.Tick(); err != nil {
+		return fmt.Errorf("failed to run triggers: %w", err)
+	}
+	if _, err := a.MaybeReflect(); err != nil {
+		return err
+	}
+	if _, err := a.CheckFatigue(now); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Perceive processes an observation and decides whether to react to it.
+func (a *Agent) Perceive(observation string, now time.Time) error {
+	return a.PerceiveAndReact(observation, now)
+}
+
+// Interview asks the agent a question, answered in character from its memories.
+func (a *Agent) Interview(question string) (string, error) {
+	return a.InterviewContext(context.Background(), question)
+}
+
+// InterviewContext is Interview under the given context.
+func (a *Agent) InterviewContext(ctx context.Context, question string) (string, error) {
+	retrieved, err := a.Memory.RetrieveRerankedContext(ctx, question)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve memories: %w", err)
+	}
+	sysPrompt := "You are the agent described below, being interviewed. Answer the question in the first person, in character, using only what the agent knows from their memories."
+	summary, err := a.GenerateSummary()
+	if err != nil {
+		return "", err
+	}
+	usrPrompt := fmt.Sprintf(`%s
+Relevant Memories:
+%s
+Question: %s`, summary, memoryList(retrieved), question)
+
+	resp, err := a.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: a.model(),
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: sysPrompt},
+			{Role: "user", Content: usrPrompt},
+		},
+		Temperature: a.temperature(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
+
+// scriptedKind identifies saved scripted actors in the save format.
+const scriptedKind = "scripted"
+
+// Scripted is a simple Actor that answers from a fixed script, for characters
+// that don't need memory, planning or an LLM.
+type Scripted struct {
+	Name     string
+	Answers  map[string]string // Interview answers keyed by question.
+	Default  string            // Answer to questions not in Answers.
+	Observed []string          // Observations perceived so far.
+
+	// OnStep, if set, is called on every step.
+	OnStep func(now time.Time) error `json:"-"`
+}
+
+// Step calls OnStep, if set.
+func (s *Scripted) Step(now time.Time) error {
+	if s.OnStep == nil {
+		return nil
+	}
+	return s.OnStep(now)
+}
+
+// Perceive records the observation.
+func (s *Scripted) Perceive(observation string, now time.Time) error {
+	s.Observed = append(s.Observed, observation)
+	return nil
+}
+
+// Interview returns the scripted answer to the question.
+func (s *Scripted) Interview(question string) (string, error) {
+	if answer, ok := s.Answers[question]; ok {
+		return answer, nil
+	}
+	return s.Default, nil
+}
+
+// Save writes the scripted actor's state to w.
+func (s *Scripted) Save(w io.Writer) error {
+	return save.Write(w, scriptedKind, s)
+}
+
+// LoadScripted restores a scripted actor saved with Save.
+func LoadScripted(r io.Reader) (*Scripted, error) {
+	s := &Scripted{}
+	if err := save.Read(r, scriptedKind, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -2,13 +2,23 @@ package a25
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/lordtatty/a25/clock"
+	"github.com/lordtatty/a25/dialogue"
+	"github.com/lordtatty/a25/events"
+	"github.com/lordtatty/a25/llm"
 	"github.com/lordtatty/a25/memory"
 	"github.com/lordtatty/a25/plan"
 	"github.com/lordtatty/a25/react"
 	"github.com/lordtatty/a25/reflect"
+	"github.com/lordtatty/a25/relationship"
+	"github.com/lordtatty/a25/social"
 	openai "github.com/sashabaranov/go-openai"
 )
 
@@ -16,24 +26,76 @@ type Modules struct {
 	Planner   *plan.Planner
 	React     *react.Reactor
 	Reflector *reflect.Reflector
+	Dialogue  *dialogue.Speaker
+	// Relationships summarises the agent's relationships with other agents;
+	// see SummarizeRelationship.
+	Relationships *relationship.Summarizer
 }
 
 // Agent represents an individual with memories and traits.
 type Agent struct {
+	ID          string
 	Name        string
 	Traits      string
 	Description string
 	Memory      memory.MemoryStream
 	Client      OpenAIClient
 	CurrentPlan plan.Plan
+	Constraints plan.Constraints // Commitments the agent's plans, and changes to them, must respect.
+	Goals       []Goal
+	Skills      []Skill
 	Status      AgentStatus
 	Modules     Modules
+	Events      events.Sink   // Receives the agent's activity; see LogEvents.
+	Social      *social.Graph // Records the agent's interactions with others, if set.
+	Model       string        // Chat model for the agent's own prompts; defaults to GPT-4o mini.
+	Temperature *float32      // Sampling temperature, set with llm.Temp; defaults to 1.
+	// ReflectionThreshold, if positive, makes the agent reflect on its own once
+	// the importance of its memories since the last reflection sums past it.
+	// The generative agents paper uses 150.
+	ReflectionThreshold float64
+	// ReactionHandlers override how the agent carries out each kind of
+	// reaction in PerceiveAndReact.
+	ReactionHandlers map[react.Outcome]ReactionHandler
+	// ReactionMemories is the number of memories most relevant to an
+	// observation shown to the Reactor, so reactions reflect the agent's
+	// history. It defaults to 5; a negative number shows none.
+	ReactionMemories int
+	// PerceptionCooldown, if set, skips observations the same as or nearly
+	// identical to one perceived within this long, so a scene perceived every
+	// tick is only reacted to once. Observations count as the same when their
+	// words overlap by PerceptionSimilarity, which defaults to 0.9.
+	PerceptionCooldown   time.Duration
+	PerceptionSimilarity float64
+	// RelevanceThreshold, if set, skips the Reactor for observations whose
+	// embedding similarity to the agent's current task and most pressing
+	// goals is below it, so irrelevant observations are remembered without a
+	// call to the language model.
+	RelevanceThreshold float64
+	// EmotionRules appraise each observation without calling the language
+	// model, alongside the Reactor's own emotional reactions. Emotions fade to
+	// half their intensity every EmotionHalfLife, which defaults to two hours.
+	EmotionRules    []EmotionRule
+	EmotionHalfLife time.Duration
+
+	// Conversation is the conversation the agent is in, if any; see
+	// StartConversation.
+	Conversation *dialogue.Conversation
+
+	clock          clock.Clock // Set with SetClock so memories and reflections share it.
+	perceived      []perception
+	triggers       []*trigger
+	lastReflection time.Time
+	retrospective  string               // The last day's retrospective; see EndOfDay.
+	references     map[string][]float32 // Embeddings of the task and goals observations are compared against.
 }
 
 // AgentStatus represents the agent's current state.
 type AgentStatus struct {
 	CurrentTask     string
 	CurrentLocation string
+	Energy          float64
+	Emotion         Emotion // How the agent last felt; see Mood.
 }
 
 type OpenAIClient interface {
@@ -44,93 +106,503 @@ type OpenAIClient interface {
 // NewAgent creates a new agent instance.
 func NewAgent(name, traits, description string, client OpenAIClient) *Agent {
 	m := Modules{
-		Planner:   &plan.Planner{Client: client},
-		React:     &react.Reactor{Client: client},
-		Reflector: &reflect.Reflector{Client: client},
+		Planner:       &plan.Planner{Client: client},
+		React:         &react.Reactor{Client: client},
+		Reflector:     &reflect.Reflector{Client: client},
+		Dialogue:      &dialogue.Speaker{Client: client},
+		Relationships: &relationship.Summarizer{Client: client},
 	}
-	mem := memory.MemoryStream{Client: client}
 	return &Agent{
+		ID:          uuid.NewString(),
 		Name:        name,
 		Traits:      traits,
 		Description: description,
-		Memory:      mem,
+		Memory:      memory.MemoryStream{Client: client},
 		Client:      client,
 		CurrentPlan: plan.Plan{},
+		Status:      AgentStatus{Energy: MaxEnergy},
 		Modules:     m,
 	}
 }
 
-// AddMemory adds a memory to the agent's memory stream.
-func (a *Agent) AddMemory(description string, importance float64) {
-	a.Memory.AddMemory(description)
+// SetClock sets the clock the agent, its memory stream and its reflector tell
+// the time by. Use a clock.Zoned to fix the simulation's time zone.
+func (a *Agent) SetClock(c clock.Clock) {
+	a.clock = c
+	a.Memory.Clock = c
+	if a.Modules.Reflector != nil {
+		a.Modules.Reflector.Clock = c
+	}
+}
+
+// Clock returns the clock the agent tells the time by, the system clock unless
+// set with SetClock.
+func (a *Agent) Clock() clock.Clock {
+	if a.clock != nil {
+		return a.clock
+	}
+	return clock.Real{}
+}
+
+// local returns t in the time zone of the agent's clock, so plans and prompts
+// use it whatever zone callers pass times in.
+func (a *Agent) local(t time.Time) time.Time {
+	return t.In(a.Clock().Now().Location())
+}
+
+// model returns the chat model, defaulting to GPT-4o mini.
+func (a *Agent) model() string {
+	return llm.Model(a.Model)
+}
+
+// temperature returns the sampling temperature, defaulting to 1.
+func (a *Agent) temperature() float32 {
+	return llm.Temperature(a.Temperature)
+}
+
+// AddMemory adds a memory to the agent's memory stream with the given
+// importance from 1 to 10. An importance of 0 has the language model rate it.
+func (a *Agent) AddMemory(description string, importance float64) error {
+	if err := a.Memory.Add(memory.MemoryObject{Description: description, Importance: importance}); err != nil {
+		return fmt.Errorf("failed to add memory: %w", err)
+	}
+	return nil
+}
+
+// AddAll adds many memories at once, such as when seeding an agent, embedding
+// them concurrently in batches rather than one at a time.
+func (a *Agent) AddAll(memories []memory.MemoryObject) error {
+	if err := a.Memory.AddAll(memories); err != nil {
+		return fmt.Errorf("failed to add memories: %w", err)
+	}
+	return nil
+}
+
+// Reflect allows the agent to generate reflections, returning those added to
+// their memory.
+func (a *Agent) Reflect() ([]memory.MemoryObject, error) {
+	return a.ReflectContext(context.Background())
 }
 
-// Reflect allows the agent to generate reflections.
-func (a *Agent) Reflect() error {
+// ReflectContext is Reflect under the given context.
+func (a *Agent) ReflectContext(ctx context.Context) ([]memory.MemoryObject, error) {
 	m := a.Memory.GetRecentMemories(100)
-	return a.Modules.Reflector.Reflect(m, &a.Memory)
+	reflections, err := a.Modules.Reflector.ReflectContext(ctx, m, &a.Memory)
+	if err != nil {
+		return reflections, err
+	}
+	a.lastReflection = a.Clock().Now()
+	return reflections, nil
+}
+
+// ReflectOn has the agent reflect on a topic over a period, such as their
+// relationship with Maria over the past week, returning the reflections added.
+func (a *Agent) ReflectOn(topic string, window reflect.TimeRange) ([]memory.MemoryObject, error) {
+	return a.Modules.Reflector.ReflectOn(topic, window, &a.Memory)
+}
+
+// MaybeReflect reflects if the importance of the memories added since the last
+// reflection has passed the ReflectionThreshold, reporting whether it did.
+func (a *Agent) MaybeReflect() (bool, error) {
+	if a.ReflectionThreshold <= 0 || a.Memory.ImportanceSince(a.lastReflection) < a.ReflectionThreshold {
+		return false, nil
+	}
+	if _, err := a.Reflect(); err != nil {
+		return false, fmt.Errorf("failed to reflect: %w", err)
+	}
+	return true, nil
 }
 
 // PlanDay generates a high-level plan for the agent's day.
 func (a *Agent) PlanDay(currentTime time.Time) error {
+	return a.PlanDayContext(context.Background(), currentTime)
+}
+
+// PlanDayContext is PlanDay under the given context, so a server or simulation
+// can bound or cancel planning.
+func (a *Agent) PlanDayContext(ctx context.Context, currentTime time.Time) error {
+	currentTime = a.local(currentTime)
 	summary, err := a.GenerateSummary()
 	if err != nil {
 		return fmt.Errorf("failed to generate agent summary: %w", err)
 	}
-	newActions, err := a.Modules.Planner.PlanDay(currentTime, summary)
+	newActions, err := a.Modules.Planner.PlanDayContext(ctx, currentTime, summary, a.Constraints)
 	if err != nil {
 		return fmt.Errorf("current plan failed to plan: %w", err)
 	}
-	a.CurrentPlan.SetActions(newActions)
+	a.ApplySkills(newActions)
+	if err := a.CurrentPlan.SetActions(newActions); err != nil {
+		return err
+	}
+	// The retrospective has informed this plan and isn't carried past it.
+	a.retrospective = ""
+	a.emit(events.PlanChanged, map[string]any{"reason": "planned day", "actions": len(newActions)})
 	// Add the plan to the memory stream.
-	a.Memory.AddMemory("Generated plan for the day.")
+	if err := a.Memory.AddContext(ctx, memory.MemoryObject{Description: "Generated plan for the day.", Kind: memory.Plan, Source: a.Name}); err != nil {
+		return fmt.Errorf("failed to remember plan: %w", err)
+	}
+	if !plan.HasRest(newActions) {
+		if err := a.Memory.AddMemoryContext(ctx, fmt.Sprintf("%s's plan for the day leaves no time to rest.", a.Name)); err != nil {
+			return fmt.Errorf("failed to remember plan: %w", err)
+		}
+	}
 	return nil
 }
 
 // GenerateSummary creates a summary of the agent's state.
 func (a *Agent) GenerateSummary() (string, error) {
 	// You can customize this method to generate a summary based on the agent's traits, recent memories, etc.
-	return fmt.Sprintf("Name: %s\nTraits: %s\nDescription: %s", a.Name, a.Traits, a.Description), nil
+	summary := fmt.Sprintf("Name: %s\nTraits: %s\nDescription: %s\nEnergy: %.0f/%.0f", a.Name, a.Traits, a.Description, a.Status.Energy, MaxEnergy)
+	if len(a.Skills) > 0 {
+		summary += "\nSkills: " + a.skillSummary()
+	}
+	if len(a.Goals) > 0 {
+		summary += "\nGoals:\n" + a.goalSummary()
+	}
+	if a.retrospective != "" {
+		summary += "\nYesterday: " + a.retrospective
+	}
+	summary += a.moodSummary()
+	return summary, nil
 }
 
-// PerceiveAndReact processes observations and decides whether to react.
+// PerceiveAndReact processes an observation and decides how to react: by
+// ignoring it, adjusting the plan, starting a conversation or updating how the
+// agent feels. Each outcome is carried out by its ReactionHandler.
 func (a *Agent) PerceiveAndReact(observation string, currentTime time.Time) error {
+	return a.PerceiveAndReactContext(context.Background(), observation, currentTime)
+}
+
+// PerceiveAndReactContext is PerceiveAndReact under the given context.
+func (a *Agent) PerceiveAndReactContext(ctx context.Context, observation string, currentTime time.Time) error {
+	currentTime = a.local(currentTime)
+	if a.perceivedRecently(observation, currentTime) {
+		return nil
+	}
+	if err := a.perceiveAndReact(ctx, observation, currentTime); err != nil {
+		return err
+	}
+	// Only observations processed in full count towards the cooldown, so a
+	// failed one can be retried.
+	a.recordPerceptions([]string{observation}, currentTime)
+	return nil
+}
+
+// perceiveAndReact implements PerceiveAndReactContext.
+func (a *Agent) perceiveAndReact(ctx context.Context, observation string, currentTime time.Time) error {
+	relevant, embeddings, err := a.relevant(ctx, []string{observation})
+	if err != nil {
+		return err
+	}
+	var summary string
+	if relevant[0] {
+		// Recall what the agent knows before the observation joins their memories.
+		if summary, err = a.groundedContext(ctx, observation); err != nil {
+			return err
+		}
+	}
 	// Add the observation to memory.
-	a.Memory.AddMemory(observation) // Adjust importance as needed.
-	context := fmt.Sprintf("Agent: %s\nTraits: %s\nDescription: %s\nCurrent Task: %s", a.Name, a.Traits, a.Description, a.Status.CurrentTask)
-	shouldReact, reactReason, err := a.Modules.React.ToObservation(observation, context, currentTime)
+	observed := memory.MemoryObject{Description: observation, Kind: memory.Observation, Source: a.Name}
+	if embeddings != nil {
+		observed.Embedding = embeddings[0]
+	}
+	if err := a.Memory.AddContext(ctx, observed); err != nil {
+		return fmt.Errorf("failed to remember observation: %w", err)
+	}
+	a.observed(observation, currentTime)
+	a.appraise(observation)
+	if !relevant[0] {
+		a.emit(events.ReactionDecided, map[string]any{"observation": observation, "react": false, "outcome": react.Ignore.String(), "reason": "not relevant"})
+		return nil
+	}
+	reaction, err := a.Modules.React.ClassifyContext(ctx, observation, summary, currentTime)
 	if err != nil {
 		return fmt.Errorf("failed to perceive and react: %w", err)
 	}
+	shouldReact := reaction.Outcome != react.Ignore
+	a.emit(events.ReactionDecided, map[string]any{"observation": observation, "react": shouldReact, "outcome": reaction.Outcome.String(), "reason": reaction.Reason})
 	if !shouldReact {
-		a.Memory.AddMemory(fmt.Sprintf("%s decided not to react to: '%s'", a.Name, observation))
+		return a.Memory.AddMemoryContext(ctx, fmt.Sprintf("%s decided not to react to: '%s'", a.Name, observation))
+	}
+	if err := a.handle(ctx, observation, reaction, currentTime); err != nil {
+		return err
+	}
+	// Add reaction to memory.
+	return a.Memory.AddMemoryContext(ctx, fmt.Sprintf("%s decided to react to: '%s', because: %s", a.Name, observation, reaction.Reason))
+}
+
+// PerceiveAll processes all of a tick's observations with a single call to the
+// Reactor, recording each decision and applying any proposed schedule edits.
+func (a *Agent) PerceiveAll(observations []string, currentTime time.Time) error {
+	return a.PerceiveAllContext(context.Background(), observations, currentTime)
+}
+
+// PerceiveAllContext is PerceiveAll under the given context.
+func (a *Agent) PerceiveAllContext(ctx context.Context, observations []string, currentTime time.Time) error {
+	currentTime = a.local(currentTime)
+	var fresh []string
+	for _, o := range observations {
+		if !a.perceivedRecently(o, currentTime) && !slices.ContainsFunc(fresh, func(f string) bool { return a.samePerception(o, f) }) {
+			fresh = append(fresh, o)
+		}
+	}
+	if len(fresh) == 0 {
 		return nil
 	}
-	// Update the plan based on the reaction.
-	err = a.UpdatePlan(reactReason, currentTime)
+	if err := a.perceiveAll(ctx, fresh, currentTime); err != nil {
+		return err
+	}
+	a.recordPerceptions(fresh, currentTime)
+	return nil
+}
+
+// perceiveAll implements PerceiveAllContext for observations not perceived
+// recently.
+func (a *Agent) perceiveAll(ctx context.Context, observations []string, currentTime time.Time) error {
+	relevant, embeddings, err := a.relevant(ctx, observations)
 	if err != nil {
-		return fmt.Errorf("failed to update plan: %w", err)
+		return err
+	}
+	var candidates []string
+	for i, o := range observations {
+		if relevant[i] {
+			candidates = append(candidates, o)
+		} else {
+			a.emit(events.ReactionDecided, map[string]any{"observation": o, "react": false, "outcome": react.Ignore.String(), "reason": "not relevant"})
+		}
+	}
+	var summary string
+	if len(candidates) > 0 {
+		if summary, err = a.groundedContext(ctx, strings.Join(candidates, "\n")); err != nil {
+			return err
+		}
+	}
+	memories := make([]memory.MemoryObject, len(observations))
+	for i, o := range observations {
+		memories[i] = memory.MemoryObject{Description: o, Kind: memory.Observation, Source: a.Name}
+		if embeddings != nil {
+			memories[i].Embedding = embeddings[i]
+		}
+	}
+	if err := a.Memory.AddAllContext(ctx, memories); err != nil {
+		return fmt.Errorf("failed to remember observations: %w", err)
+	}
+	for _, o := range observations {
+		a.observed(o, currentTime)
+		a.appraise(o)
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	decisions, edits, err := a.Modules.React.ToObservationsContext(ctx, candidates, summary, a.planExcerpt(5), currentTime)
+	if err != nil {
+		return fmt.Errorf("failed to perceive and react: %w", err)
+	}
+	for _, d := range decisions {
+		a.emit(events.ReactionDecided, map[string]any{"observation": d.Observation, "react": d.React, "reason": d.Reason})
+		decision := fmt.Sprintf("%s decided to react to: '%s', because: %s", a.Name, d.Observation, d.Reason)
+		if !d.React {
+			decision = fmt.Sprintf("%s decided not to react to: '%s'", a.Name, d.Observation)
+		}
+		if err := a.Memory.AddMemoryContext(ctx, decision); err != nil {
+			return fmt.Errorf("failed to remember reaction: %w", err)
+		}
+	}
+	old := slices.Clone(a.CurrentPlan.Actions())
+	for _, e := range edits {
+		added, err := a.addAction(plan.Action{
+			Description: e.Description,
+			Location:    e.Location,
+			StartTime:   e.StartTime,
+			Duration:    e.Duration,
+			Priority:    reactionPriority,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply schedule edit: %w", err)
+		}
+		if !added {
+			continue
+		}
+		a.emit(events.PlanChanged, map[string]any{"reason": "schedule edit", "action": e.Description})
+	}
+	if len(edits) > 0 {
+		a.CurrentPlan.Rebalance()
+		return a.recordPlanChange(old, "schedule edits")
 	}
-	// Add reaction to memory.
-	a.Memory.AddMemory(fmt.Sprintf("%s decided to react to: '%s', because: %s", a.Name, observation, reactReason))
 	return nil
 }
 
-// UpdatePlan modifies the agent's plan based on the reaction.
+// addAction adds the action to the plan, cut back to fit around the agent's
+// Constraints, reporting false if no part of it fits.
+func (a *Agent) addAction(act plan.Action) (bool, error) {
+	act, ok := a.Constraints.Fit(act)
+	if !ok {
+		return false, nil
+	}
+	return true, a.CurrentPlan.AddAction(act)
+}
+
+// promptMemories is the number of retrieved memories listed in prompts.
+const promptMemories = 10
+
+// memoryList lists the first promptMemories of the retrieved memories, one
+// per line, for a prompt.
+func memoryList(retrieved []memory.RetrievedMemory) string {
+	var lines []string
+	for _, mem := range retrieved[:min(promptMemories, len(retrieved))] {
+		lines = append(lines, "- "+mem.Memory.Description)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// reactionPriority is the priority of work inserted in reaction to an
+// observation, which takes precedence over routinely planned actions.
+const reactionPriority = 1
+
+// planExcerpt lists up to n upcoming actions of the current plan.
+func (a *Agent) planExcerpt(n int) string {
+	var lines []string
+	for i, act := range a.CurrentPlan.Actions() {
+		if i == n {
+			break
+		}
+		lines = append(lines, fmt.Sprintf("- %s: %s", act.StartTime.Format("3:04 PM"), act.Description))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// reactionContext summarises the agent's current state for the Reactor.
+func (a *Agent) reactionContext() string {
+	context := fmt.Sprintf("Agent: %s\nTraits: %s\nDescription: %s\nCurrent Task: %s\nEnergy: %.0f/%.0f", a.Name, a.Traits, a.Description, a.Status.CurrentTask, a.Status.Energy, MaxEnergy)
+	context += a.moodSummary()
+	if a.Exhausted() {
+		context += "\nThe agent is exhausted and may want to cut the day short."
+	}
+	return context
+}
+
+// defaultReactionMemories is the number of memories shown to the Reactor by default.
+const defaultReactionMemories = 5
+
+// groundedContext is the reaction context followed by the agent's memories
+// most relevant to the observation.
+func (a *Agent) groundedContext(ctx context.Context, observation string) (string, error) {
+	summary := a.reactionContext()
+	k := a.ReactionMemories
+	if k < 0 || a.Memory.Len() == 0 {
+		return summary, nil
+	}
+	if k == 0 {
+		k = defaultReactionMemories
+	}
+	retrieved, err := a.Memory.RetrieveMemoriesContext(ctx, observation)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve memories: %w", err)
+	}
+	if len(retrieved) == 0 {
+		return summary, nil
+	}
+	var lines []string
+	for _, r := range retrieved[:min(k, len(retrieved))] {
+		lines = append(lines, "- "+r.Memory.Description)
+	}
+	return summary + "\nRelevant Memories:\n" + strings.Join(lines, "\n"), nil
+}
+
+// UpdatePlan has the planner revise the rest of the agent's day based on the
+// reaction, keeping the actions already done or under way.
 func (a *Agent) UpdatePlan(reaction string, currentTime time.Time) error {
-	// You can implement logic to adjust the plan.
-	// For simplicity, let's prepend a new action.
-	newAction := plan.Action{
-		Description: reaction,
-		StartTime:   currentTime,
-		// Set Duration and Location as needed.
-	}
-	a.CurrentPlan.AddAction(newAction)
+	return a.UpdatePlanContext(context.Background(), reaction, currentTime)
+}
+
+// UpdatePlanContext is UpdatePlan under the given context.
+func (a *Agent) UpdatePlanContext(ctx context.Context, reaction string, currentTime time.Time) error {
+	return a.updatePlan(ctx, reaction, nil, currentTime)
+}
+
+// UpdatePlanWithAction is UpdatePlan for a reaction that calls for a
+// particular action, which the agent starts at currentTime before the rest of
+// the day is replanned around it.
+func (a *Agent) UpdatePlanWithAction(ctx context.Context, reaction string, action plan.Action, currentTime time.Time) error {
+	return a.updatePlan(ctx, reaction, &action, currentTime)
+}
+
+// updatePlan revises the plan around the reaction, starting with the action
+// if there is one.
+func (a *Agent) updatePlan(ctx context.Context, reaction string, action *plan.Action, currentTime time.Time) error {
+	currentTime = a.local(currentTime)
+	current := a.CurrentPlan.Actions()
+	var revised []plan.Action
+	var err error
+	if action != nil {
+		revised, err = a.Modules.Planner.ReviseWithAction(ctx, current, reaction, *action, currentTime, a.Constraints)
+	} else {
+		revised, err = a.Modules.Planner.ReviseContext(ctx, current, reaction, currentTime, a.Constraints)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to revise plan: %w", err)
+	}
+	// Skills only shorten the newly planned actions; kept ones already reflect them.
+	kept := make(map[string]bool, len(current))
+	for _, act := range current {
+		kept[act.ID] = true
+	}
+	for i := range revised {
+		if !kept[revised[i].ID] {
+			a.ApplySkills(revised[i : i+1])
+		}
+	}
+	revised = a.Constraints.Enforce(revised, currentTime)
+	if err := a.CurrentPlan.SetActions(revised); err != nil {
+		return err
+	}
+	a.emit(events.PlanChanged, map[string]any{"reason": "reaction", "action": reaction})
+	return a.recordPlanChange(current, "reacting: "+reaction)
+}
+
+// recordPlanChange remembers how the plan has changed from old, if it has.
+func (a *Agent) recordPlanChange(old []plan.Action, reason string) error {
+	d := plan.Diff(old, a.CurrentPlan.Actions())
+	if d.Empty() {
+		return nil
+	}
+	err := a.Memory.Add(memory.MemoryObject{
+		Description: fmt.Sprintf("%s's plan changed (%s):\n%s", a.Name, reason, d),
+		Kind:        memory.Plan,
+		Source:      a.Name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remember plan change: %w", err)
+	}
 	return nil
 }
 
-func (a *Agent) SelectTask() {
-	a.CurrentPlan.NextAction()
-	a.Status.CurrentTask = a.CurrentPlan.NextAction().Description
-	a.Memory.AddMemory("Started Task: " + a.Status.CurrentTask)
+// SelectTask completes the action in progress, if any, updating the progress of
+// the goal it worked towards, and starts the next pending action of the plan.
+// The next action is started even if assessing the goal or remembering the
+// task fails, and those errors are returned afterwards.
+func (a *Agent) SelectTask() error {
+	return a.SelectTaskContext(context.Background())
+}
+
+// SelectTaskContext is SelectTask under the given context.
+func (a *Agent) SelectTaskContext(ctx context.Context) error {
+	var goalErr error
+	if cur := a.CurrentPlan.Current(); cur != nil {
+		a.CurrentPlan.Complete(cur.ID)
+		goalErr = a.advanceGoal(ctx, *cur)
+	}
+	next := a.CurrentPlan.NextAction()
+	if next == nil {
+		a.Status.CurrentTask = ""
+		return goalErr
+	}
+	a.CurrentPlan.Start(next.ID)
+	a.Status.CurrentTask = next.Description
+	err := a.PracticeContext(ctx, *next)
+	a.spendEnergy(*next)
+	if memErr := a.Memory.AddMemoryContext(ctx, "Started Task: "+a.Status.CurrentTask); memErr != nil {
+		err = errors.Join(err, fmt.Errorf("failed to remember task: %w", memErr))
+	}
+	return errors.Join(goalErr, err)
 }
@@ -0,0 +1,105 @@
+package a25
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lordtatty/a25/memory"
+	"github.com/lordtatty/a25/plan"
+)
+
+var errStore = errors.New("store unavailable")
+
+// failingStore is a memory.Store that fails to store anything.
+type failingStore struct{}
+
+func (failingStore) Load() ([]memory.MemoryObject, error) { return nil, nil }
+func (failingStore) Put(memory.MemoryObject) error        { return errStore }
+func (failingStore) Delete(string) error                  { return nil }
+
+func TestMemoryErrorsAreReturned(t *testing.T) {
+	now := time.Date(2024, 2, 14, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		reply string
+		run   func(a *Agent) error
+	}{
+		{"perceive", "Klaus is at the library.", func(a *Agent) error {
+			return a.PerceiveAndReact("Maria is painting.", now)
+		}},
+		{"select task", "", func(a *Agent) error {
+			if err := a.CurrentPlan.AddAction(plan.Action{Description: "Read", StartTime: now, Duration: time.Hour}); err != nil {
+				return err
+			}
+			return a.SelectTask()
+		}},
+		{"vote", "Pizza\nIt is quick.", func(a *Agent) error {
+			_, err := a.CastVote("What's for lunch?", []string{"Pizza", "Salad"})
+			return err
+		}},
+		{"assess goals", "40", func(a *Agent) error {
+			a.AddGoal("Finish the thesis", now.Add(48*time.Hour))
+			return a.AssessGoals()
+		}},
+		{"host event", "", func(a *Agent) error {
+			_, err := NewEvent(a, "Valentine's Day party", "Hobbs Cafe", now.Add(8*time.Hour), 2*time.Hour)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		a := newTestAgent("Klaus", &scriptedClient{replies: []string{tt.reply}})
+		a.Memory.Store = failingStore{}
+		if err := tt.run(a); !errors.Is(err, errStore) {
+			t.Errorf("%s: error = %v, want the store error", tt.name, err)
+		}
+	}
+}
+
+func TestContextVariantsStopWhenCancelled(t *testing.T) {
+	now := time.Date(2024, 2, 14, 9, 0, 0, 0, time.UTC)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		name string
+		run  func(a *Agent) error
+	}{
+		{"vote", func(a *Agent) error {
+			_, err := VoteContext(ctx, []*Agent{a}, "What's for lunch?", []string{"Pizza", "Salad"})
+			return err
+		}},
+		{"assess goals", func(a *Agent) error {
+			a.AddGoal("Finish the thesis", now.Add(48*time.Hour))
+			return a.AssessGoalsContext(ctx)
+		}},
+		{"invite", func(a *Agent) error {
+			e := &Event{Host: "Isabella", Description: "Valentine's Day party", StartTime: now.Add(8 * time.Hour), Duration: time.Hour}
+			_, err := e.InviteContext(ctx, a)
+			return err
+		}},
+		{"select task", func(a *Agent) error {
+			if err := a.CurrentPlan.AddAction(plan.Action{Description: "Read", StartTime: now, Duration: time.Hour}); err != nil {
+				return err
+			}
+			return a.SelectTaskContext(ctx)
+		}},
+		{"end of day", func(a *Agent) error {
+			if err := a.CurrentPlan.AddAction(plan.Action{Description: "Read", StartTime: now, Duration: time.Hour}); err != nil {
+				return err
+			}
+			_, err := a.EndOfDayContext(ctx, now)
+			return err
+		}},
+		{"interview", func(a *Agent) error {
+			_, err := a.InterviewContext(ctx, "How was your day?")
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		a := newTestAgent("Klaus", &scriptedClient{replies: []string{"Yes"}})
+		if err := tt.run(a); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: error = %v, want context.Canceled", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock provides the current time, allowing agents to run on simulated time.
+type Clock interface {
+	Now() time.Time
+}
+
+// Real is a Clock backed by the system clock.
+type Real struct{}
+
+// Now returns the current system time.
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// Manual is a Clock that only moves when it is set or advanced, for driving
+// simulations step by step.
+type Manual struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+// NewManual creates a manual clock starting at the given time.
+func NewManual(t time.Time) *Manual {
+	return &Manual{t: t}
+}
+
+// Now returns the clock's current time.
+func (m *Manual) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.t
+}
+
+// Set moves the clock to the given time.
+func (m *Manual) Set(t time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.t = t
+}
+
+// Advance moves the clock forward by the given duration.
+func (m *Manual) Advance(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.t = m.t.Add(d)
+}
+
+// Zoned is a Clock reporting another clock's time in a fixed location, so a
+// simulation keeps the same time zone wherever it runs, such as on servers
+// set to UTC. A nil Clock is the system clock and a nil Location is UTC.
+type Zoned struct {
+	Clock    Clock
+	Location *time.Location
+}
+
+// Now returns the underlying clock's time in the location.
+func (z Zoned) Now() time.Time {
+	c := z.Clock
+	if c == nil {
+		c = Real{}
+	}
+	loc := z.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	return c.Now().In(loc)
+}
+
+// Simulated reports whether the clock tells simulated rather than system time,
+// looking through Zoned. A nil clock is the system clock.
+func Simulated(c Clock) bool {
+	switch c := c.(type) {
+	case nil, Real, *Real:
+		return false
+	case Zoned:
+		return Simulated(c.Clock)
+	case *Zoned:
+		return Simulated(c.Clock)
+	}
+	return true
+}
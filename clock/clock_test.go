@@ -0,0 +1,35 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestZonedDefaults(t *testing.T) {
+	start := time.Date(2024, 2, 14, 9, 0, 0, 0, time.FixedZone("EST", -5*3600))
+	if got := (Zoned{Clock: NewManual(start)}).Now(); got.Location() != time.UTC || !got.Equal(start) {
+		t.Errorf("Now() = %v, want %v in UTC", got, start)
+	}
+	if got := (Zoned{}).Now(); got.IsZero() {
+		t.Error("Now() with no clock is zero, want the system time")
+	}
+}
+
+func TestSimulated(t *testing.T) {
+	tests := []struct {
+		name  string
+		clock Clock
+		want  bool
+	}{
+		{"nil", nil, false},
+		{"real", Real{}, false},
+		{"manual", NewManual(time.Time{}), true},
+		{"zoned real", Zoned{Clock: Real{}, Location: time.UTC}, false},
+		{"zoned manual", Zoned{Clock: NewManual(time.Time{})}, true},
+	}
+	for _, tt := range tests {
+		if got := Simulated(tt.clock); got != tt.want {
+			t.Errorf("Simulated(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// Package cloud stores objects such as agent snapshots in S3-compatible object
+// storage: Amazon S3, or Google Cloud Storage through its XML API with HMAC keys.
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by Get when the object does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// Bucket is a bucket in S3-compatible storage. Requests are signed with AWS
+// Signature Version 4.
+type Bucket struct {
+	Endpoint   string // e.g. https://s3.eu-west-2.amazonaws.com.
+	Region     string
+	Name       string
+	AccessKey  string
+	SecretKey  string
+	HTTPClient *http.Client // Defaults to http.DefaultClient.
+}
+
+// NewS3Bucket returns the Amazon S3 bucket in the region.
+func NewS3Bucket(region, name, accessKey, secretKey string) *Bucket {
+	return &Bucket{
+		Endpoint:  "https://s3." + region + ".amazonaws.com",
+		Region:    region,
+		Name:      name,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+	}
+}
+
+// NewGCSBucket returns the Google Cloud Storage bucket, accessed with an HMAC
+// key for a service account.
+func NewGCSBucket(name, accessKey, secretKey string) *Bucket {
+	return &Bucket{
+		Endpoint:  "https://storage.googleapis.com",
+		Region:    "auto",
+		Name:      name,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+	}
+}
+
+// Put writes data to the object at key, replacing any there before.
+func (b *Bucket) Put(key string, data []byte) error {
+	resp, err := b.do(http.MethodPut, key, data)
+	if err != nil {
+		return fmt.Errorf("failed to put %s: %w", key, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get reads the object at key.
+func (b *Bucket) Get(key string) ([]byte, error) {
+	resp, err := b.do(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// do sends a signed request for the object at key, returning an error for
+// unsuccessful responses.
+func (b *Bucket) do(method, key string, body []byte) (*http.Response, error) {
+	path := "/" + b.Name + "/" + escapePath(key)
+	req, err := http.NewRequestWithContext(context.Background(), method, strings.TrimSuffix(b.Endpoint, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	b.sign(req, path, body, time.Now().UTC())
+	client := b.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("storage returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return resp, nil
+}
+
+// sign adds an AWS Signature Version 4 authorization header to the request.
+func (b *Bucket) sign(req *http.Request, path string, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payload := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payload[:]))
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": req.Header.Get("X-Amz-Content-Sha256"),
+		"x-amz-date":           amzDate,
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method, path, "", canonicalHeaders.String(), signedHeaders, hex.EncodeToString(payload[:]),
+	}, "\n")
+	scope := date + "/" + b.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + b.SecretKey)
+	for _, part := range []string{date, b.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		b.AccessKey, scope, signedHeaders, signature))
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data under key.
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// escapePath percent-encodes an object key as Signature Version 4 requires:
+// every byte except unreserved characters and the slashes between segments.
+func escapePath(key string) string {
+	var sb strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~/", c) >= 0 {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,158 @@
+package compress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lordtatty/a25/llm"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+type OpenAIClient interface {
+	CreateChatCompletion(context.Context, openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error)
+	CreateEmbeddings(context.Context, openai.EmbeddingRequestConverter) (*openai.EmbeddingResponse, error)
+}
+
+// Compressor shrinks an oversized prompt section, such as a memory list, to fit
+// within a character limit. The query describes what the section will be used
+// for, so the compressor can keep what matters.
+type Compressor interface {
+	Compress(ctx context.Context, section, query string, limit int) (string, error)
+}
+
+// Fit returns the section unchanged if it is within the limit, and compressed
+// otherwise. A nil compressor or a non-positive limit disables compression.
+func Fit(ctx context.Context, c Compressor, section, query string, limit int) (string, error) {
+	if c == nil || limit <= 0 || len(section) <= limit {
+		return section, nil
+	}
+	return c.Compress(ctx, section, query, limit)
+}
+
+// Summarizer compresses a section by asking the language model to summarise it.
+type Summarizer struct {
+	Client      OpenAIClient
+	Model       string   // Chat model; defaults to GPT-4o mini.
+	Temperature *float32 // Sampling temperature, set with llm.Temp; defaults to 1.
+}
+
+// model returns the chat model, defaulting to GPT-4o mini.
+func (s *Summarizer) model() string {
+	return llm.Model(s.Model)
+}
+
+// temperature returns the sampling temperature, defaulting to 1.
+func (s *Summarizer) temperature() float32 {
+	return llm.Temperature(s.Temperature)
+}
+
+// Compress summarises the section to roughly the given number of characters.
+func (s *Summarizer) Compress(ctx context.Context, section, query string, limit int) (string, error) {
+	sysPrompt := fmt.Sprintf("Summarize the following statements in no more than %d characters, keeping names, places and details that are relevant.  Output the summary only.", limit)
+	if query != "" {
+		sysPrompt += fmt.Sprintf("  Focus on what is relevant to: %s", query)
+	}
+	resp, err := s.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: s.model(),
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: sysPrompt},
+			{Role: "user", Content: section},
+		},
+		Temperature: s.temperature(),
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", errors.New("no summary in response")
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
+
+// Selector compresses a section with one item per line by keeping the lines
+// most similar to the query, in their original order, until the limit is reached.
+type Selector struct {
+	Client OpenAIClient
+	Model  openai.EmbeddingModel // Defaults to text-embedding-3-small.
+}
+
+// model returns the embedding model, defaulting to text-embedding-3-small.
+func (s *Selector) model() openai.EmbeddingModel {
+	if s.Model != "" {
+		return s.Model
+	}
+	return openai.SmallEmbedding3
+}
+
+// Compress selects the lines of the section most relevant to the query.
+func (s *Selector) Compress(ctx context.Context, section, query string, limit int) (string, error) {
+	lines := strings.Split(strings.TrimSpace(section), "\n")
+	if query == "" {
+		return truncate(lines, limit), nil
+	}
+	inputs := append([]string{query}, lines...)
+	resp, err := s.Client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Input: inputs,
+		Model: s.model(),
+	})
+	if err != nil {
+		return "", err
+	}
+	embeddings := make([][]float32, len(inputs))
+	for _, d := range resp.Data {
+		if d.Index >= 0 && d.Index < len(inputs) {
+			embeddings[d.Index] = d.Embedding
+		}
+	}
+	for i, e := range embeddings {
+		if e == nil {
+			return "", fmt.Errorf("no embedding for input %d of %d in response", i, len(inputs))
+		}
+	}
+	q := embeddings[0]
+	type scored struct {
+		idx   int
+		score float32
+	}
+	var ranked []scored
+	for i := range lines {
+		var d float32
+		for j, x := range embeddings[i+1] {
+			d += x * q[j]
+		}
+		ranked = append(ranked, scored{i, d})
+	}
+	sort.Slice(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+
+	keep := make([]bool, len(lines))
+	size := 0
+	for _, r := range ranked {
+		if size+len(lines[r.idx])+1 > limit {
+			continue
+		}
+		keep[r.idx] = true
+		size += len(lines[r.idx]) + 1
+	}
+	var out []string
+	for i, l := range lines {
+		if keep[i] {
+			out = append(out, l)
+		}
+	}
+	return strings.Join(out, "\n"), nil
+}
+
+// truncate keeps the leading lines that fit within the limit.
+func truncate(lines []string, limit int) string {
+	size := 0
+	for i, l := range lines {
+		size += len(l) + 1
+		if size > limit {
+			return strings.Join(lines[:i], "\n")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
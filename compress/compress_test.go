@@ -0,0 +1,73 @@
+package compress
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// fakeClient embeds each text as a one-hot vector of its first letter and
+// returns the embeddings in reverse order, dropping the last drop of them.
+type fakeClient struct {
+	drop int
+	chat openai.ChatCompletionRequest
+}
+
+func (c *fakeClient) CreateChatCompletion(_ context.Context, req openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error) {
+	c.chat = req
+	return &openai.ChatCompletionResponse{Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: " summary "}}}}, nil
+}
+
+func (c *fakeClient) CreateEmbeddings(_ context.Context, conv openai.EmbeddingRequestConverter) (*openai.EmbeddingResponse, error) {
+	texts := conv.Convert().Input.([]string)
+	resp := &openai.EmbeddingResponse{}
+	for i := len(texts) - 1; i >= c.drop; i-- {
+		e := make([]float32, 26)
+		e[(texts[i][0]|0x20)-'a'] = 1
+		resp.Data = append(resp.Data, openai.Embedding{Index: i, Embedding: e})
+	}
+	return resp, nil
+}
+
+func TestSelector(t *testing.T) {
+	section := "apples are red\nbananas are yellow\navocados are green\ncherries are red"
+	tests := []struct {
+		name    string
+		drop    int
+		query   string
+		limit   int
+		want    string
+		wantErr bool
+	}{
+		{"most similar lines in order", 0, "a fruit", 40, "apples are red\navocados are green", false},
+		{"no query truncates", 0, "", 40, "apples are red\nbananas are yellow", false},
+		{"short response", 1, "a fruit", 40, "", true},
+	}
+	for _, tt := range tests {
+		s := &Selector{Client: &fakeClient{drop: tt.drop}}
+		got, err := s.Compress(context.Background(), section, tt.query, tt.limit)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s: Compress = %q, %v; want %q, error %t", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestSummarizer(t *testing.T) {
+	client := &fakeClient{}
+	s := &Summarizer{Client: client, Model: "small-model"}
+	got, err := Fit(context.Background(), s, strings.Repeat("x", 20), "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "summary" {
+		t.Errorf("Fit = %q, want the trimmed summary", got)
+	}
+	if client.chat.Model != "small-model" || client.chat.Temperature != 1 {
+		t.Errorf("requested %q at %v, want small-model at 1", client.chat.Model, client.chat.Temperature)
+	}
+	if got, _ := Fit(context.Background(), s, "short", "", 10); got != "short" {
+		t.Errorf("Fit compressed a section within the limit: %q", got)
+	}
+}
@@ -0,0 +1,280 @@
+package a25
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lordtatty/a25/llm"
+	"github.com/lordtatty/a25/memory"
+	"github.com/lordtatty/a25/ratelimit"
+	openai "github.com/sashabaranov/go-openai"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds the settings for agents and their modules. It can be loaded from
+// a YAML file, overridden by environment variables, or built as a struct.
+type Config struct {
+	Default   ModelConfig     `yaml:"default"`
+	Planner   ModelConfig     `yaml:"planner"`
+	Reactor   ModelConfig     `yaml:"reactor"`
+	Reflector ModelConfig     `yaml:"reflector"`
+	Memory    ModelConfig     `yaml:"memory"`
+	Dialogue  ModelConfig     `yaml:"dialogue"`
+	Embedding EmbeddingConfig `yaml:"embedding"`
+
+	Relationships ModelConfig `yaml:"relationships"`
+	Redactor      ModelConfig `yaml:"redactor"`
+
+	Retrieval   RetrievalConfig   `yaml:"retrieval"`
+	Budget      BudgetConfig      `yaml:"budget"`
+	Persistence PersistenceConfig `yaml:"persistence"`
+	Logging     LoggingConfig     `yaml:"logging"`
+	Redaction   RedactionConfig   `yaml:"redaction"`
+
+	limiterOnce sync.Once
+	limiter     *ratelimit.Limiter
+}
+
+// ModelConfig selects the chat model and temperature for a module. Unset
+// fields fall back to the default model config; a temperature of 0 is set.
+type ModelConfig struct {
+	Model       string   `yaml:"model"`
+	Temperature *float32 `yaml:"temperature"`
+}
+
+// EmbeddingConfig selects the OpenAI embedding model for memories.
+type EmbeddingConfig struct {
+	Model      string `yaml:"model"`
+	Dimensions int    `yaml:"dimensions"`
+}
+
+// RetrievalConfig controls memory retrieval scoring.
+type RetrievalConfig struct {
+	Relevance   float32 `yaml:"relevance"`
+	Recency     float32 `yaml:"recency"`
+	Importance  float32 `yaml:"importance"`
+	Parallelism int     `yaml:"parallelism"`
+	Cache       bool    `yaml:"cache"`
+	TimeScale   float64 `yaml:"time_scale"` // Simulated hours per system clock hour.
+}
+
+// BudgetConfig limits LLM usage across every agent created from the config.
+type BudgetConfig struct {
+	RequestsPerMinute int `yaml:"requests_per_minute"`
+	TokensPerMinute   int `yaml:"tokens_per_minute"`
+}
+
+// PersistenceConfig sets where agents are saved.
+type PersistenceConfig struct {
+	Dir string `yaml:"dir"`
+}
+
+// LoggingConfig controls logging of LLM calls.
+type LoggingConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// RedactionConfig masks personal information in memories before they are
+// embedded or stored.
+type RedactionConfig struct {
+	Enabled bool     `yaml:"enabled"`
+	Names   []string `yaml:"names"` // Names to mask along with emails and phone numbers.
+	LLM     bool     `yaml:"llm"`   // Also ask the Redactor model to mask what patterns miss.
+}
+
+// LoadConfig reads a YAML config file, then applies environment overrides. An
+// empty path loads the config from the environment alone.
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{}
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config: %w", err)
+		}
+		if err := yaml.Unmarshal(b, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config: %w", err)
+		}
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// applyEnv overrides config values from A25_* environment variables.
+func (c *Config) applyEnv() error {
+	if v := os.Getenv("A25_MODEL"); v != "" {
+		c.Default.Model = v
+	}
+	if v := os.Getenv("A25_TEMPERATURE"); v != "" {
+		t, err := strconv.ParseFloat(v, 32)
+		if err != nil {
+			return fmt.Errorf("invalid A25_TEMPERATURE: %w", err)
+		}
+		c.Default.Temperature = llm.Temp(float32(t))
+	}
+	if v := os.Getenv("A25_REQUESTS_PER_MINUTE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid A25_REQUESTS_PER_MINUTE: %w", err)
+		}
+		c.Budget.RequestsPerMinute = n
+	}
+	if v := os.Getenv("A25_TOKENS_PER_MINUTE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid A25_TOKENS_PER_MINUTE: %w", err)
+		}
+		c.Budget.TokensPerMinute = n
+	}
+	if v := os.Getenv("A25_PERSISTENCE_DIR"); v != "" {
+		c.Persistence.Dir = v
+	}
+	if v := os.Getenv("A25_LOGGING"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid A25_LOGGING: %w", err)
+		}
+		c.Logging.Enabled = b
+	}
+	return nil
+}
+
+// resolve fills unset fields of a module's model config from the default.
+func (c *Config) resolve(m ModelConfig) ModelConfig {
+	if m.Model == "" {
+		m.Model = c.Default.Model
+	}
+	if m.Temperature == nil {
+		m.Temperature = c.Default.Temperature
+	}
+	return m
+}
+
+// NewAgentFromConfig creates an agent configured by cfg. If the config has a
+// persistence directory holding a save for the agent, the agent is restored
+// from it. Agents created from the same config share its LLM budget.
+func NewAgentFromConfig(cfg *Config, name, traits, description string, client OpenAIClient) (*Agent, error) {
+	if cfg.Logging.Enabled {
+		client = &loggingClient{Client: client, Agent: name}
+	}
+	if l := cfg.budgetLimiter(); l != nil {
+		client = l.Wrap(client, name)
+	}
+
+	a, err := cfg.load(name, client)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		a = NewAgent(name, traits, description, client)
+	}
+
+	agent := cfg.resolve(ModelConfig{})
+	a.Model, a.Temperature = agent.Model, agent.Temperature
+	planner := cfg.resolve(cfg.Planner)
+	a.Modules.Planner.Model, a.Modules.Planner.Temperature = planner.Model, planner.Temperature
+	reactor := cfg.resolve(cfg.Reactor)
+	a.Modules.React.Model, a.Modules.React.Temperature = reactor.Model, reactor.Temperature
+	reflector := cfg.resolve(cfg.Reflector)
+	a.Modules.Reflector.Model, a.Modules.Reflector.Temperature = reflector.Model, reflector.Temperature
+	mem := cfg.resolve(cfg.Memory)
+	a.Memory.Model, a.Memory.Temperature = mem.Model, mem.Temperature
+	dialogue := cfg.resolve(cfg.Dialogue)
+	a.Modules.Dialogue.Model, a.Modules.Dialogue.Temperature = dialogue.Model, dialogue.Temperature
+	relationships := cfg.resolve(cfg.Relationships)
+	a.Modules.Relationships.Model, a.Modules.Relationships.Temperature = relationships.Model, relationships.Temperature
+	a.Memory.EmbeddingModel = openai.EmbeddingModel(cfg.Embedding.Model)
+	a.Memory.EmbeddingDimensions = cfg.Embedding.Dimensions
+
+	a.Memory.Weights = memory.Weights{
+		Relevance:  cfg.Retrieval.Relevance,
+		Recency:    cfg.Retrieval.Recency,
+		Importance: cfg.Retrieval.Importance,
+	}
+	if cfg.Redaction.Enabled {
+		redactors := memory.Redactors{&memory.PatternRedactor{Names: cfg.Redaction.Names}}
+		if cfg.Redaction.LLM {
+			redactor := cfg.resolve(cfg.Redactor)
+			redactors = append(redactors, &memory.LLMRedactor{Client: client, Model: redactor.Model, Temperature: redactor.Temperature})
+		}
+		a.Memory.Redactor = redactors
+	}
+	a.Memory.Parallelism = cfg.Retrieval.Parallelism
+	a.Memory.CacheRetrievals = cfg.Retrieval.Cache
+	a.Memory.TimeScale = cfg.Retrieval.TimeScale
+	return a, nil
+}
+
+// budgetLimiter returns the limiter shared by agents created from the config,
+// or nil if the config sets no budget.
+func (c *Config) budgetLimiter() *ratelimit.Limiter {
+	c.limiterOnce.Do(func() {
+		if c.Budget.RequestsPerMinute > 0 || c.Budget.TokensPerMinute > 0 {
+			c.limiter = &ratelimit.Limiter{
+				RequestsPerMinute: c.Budget.RequestsPerMinute,
+				TokensPerMinute:   c.Budget.TokensPerMinute,
+			}
+		}
+	})
+	return c.limiter
+}
+
+// SaveAgent saves the agent to the config's persistence directory.
+func (c *Config) SaveAgent(a *Agent) error {
+	if c.Persistence.Dir == "" {
+		return errors.New("no persistence directory configured")
+	}
+	path, err := c.savePath(a.Name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.Persistence.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create persistence directory: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create save file: %w", err)
+	}
+	if err := a.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// load restores a saved agent from the persistence directory, returning nil if
+// there is no save for it.
+func (c *Config) load(name string, client OpenAIClient) (*Agent, error) {
+	if c.Persistence.Dir == "" {
+		return nil, nil
+	}
+	path, err := c.savePath(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open save file: %w", err)
+	}
+	defer f.Close()
+	return LoadAgent(f, client)
+}
+
+// savePath returns the file an agent is saved to. Names that could escape the
+// persistence directory are rejected.
+func (c *Config) savePath(name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid agent name for a save file: %q", name)
+	}
+	return filepath.Join(c.Persistence.Dir, name+".json"), nil
+}
@@ -0,0 +1,109 @@
+package a25
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/lordtatty/a25/llm"
+	"github.com/lordtatty/a25/memory"
+	"github.com/lordtatty/a25/ratelimit"
+)
+
+func TestNewAgentFromConfigModels(t *testing.T) {
+	cfg := &Config{
+		Default:  ModelConfig{Model: "default-model", Temperature: llm.Temp(0)},
+		Dialogue: ModelConfig{Model: "dialogue-model"},
+		Relationships: ModelConfig{
+			Model:       "relationship-model",
+			Temperature: llm.Temp(0.5),
+		},
+	}
+	a, err := NewAgentFromConfig(cfg, "Maria", "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		module      string
+		model       string
+		temperature *float32
+		want        string
+		wantTemp    float32
+	}{
+		{"planner", a.Modules.Planner.Model, a.Modules.Planner.Temperature, "default-model", 0},
+		{"dialogue", a.Modules.Dialogue.Model, a.Modules.Dialogue.Temperature, "dialogue-model", 0},
+		{"relationships", a.Modules.Relationships.Model, a.Modules.Relationships.Temperature, "relationship-model", 0.5},
+	}
+	for _, tt := range tests {
+		if tt.model != tt.want || tt.temperature == nil || *tt.temperature != tt.wantTemp {
+			t.Errorf("%s: model %q, temperature %v; want %q, %v", tt.module, tt.model, tt.temperature, tt.want, tt.wantTemp)
+		}
+	}
+}
+
+func TestConfigSharesOneLimiter(t *testing.T) {
+	cfg := &Config{Budget: BudgetConfig{RequestsPerMinute: 60}}
+	limiters := make(chan *ratelimit.Limiter, 8)
+	var wg sync.WaitGroup
+	for i := 0; i < cap(limiters); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limiters <- cfg.budgetLimiter()
+		}()
+	}
+	wg.Wait()
+	close(limiters)
+	first := <-limiters
+	for l := range limiters {
+		if l != first {
+			t.Fatal("agents created from one config got different limiters")
+		}
+	}
+	if (&Config{}).budgetLimiter() != nil {
+		t.Error("a config without a budget has a limiter")
+	}
+}
+
+func TestSavePath(t *testing.T) {
+	cfg := &Config{Persistence: PersistenceConfig{Dir: "saves"}}
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"Maria", filepath.Join("saves", "Maria.json"), false},
+		{"Klaus Mueller", filepath.Join("saves", "Klaus Mueller.json"), false},
+		{"", "", true},
+		{"..", "", true},
+		{"../escape", "", true},
+		{"nested/name", "", true},
+		{`back\slash`, "", true},
+	}
+	for _, tt := range tests {
+		got, err := cfg.savePath(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("savePath(%q) = %q, %v; want %q, error %t", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNewAgentFromConfigRedaction(t *testing.T) {
+	cfg := &Config{
+		Default:   ModelConfig{Model: "default-model"},
+		Redactor:  ModelConfig{Temperature: llm.Temp(0)},
+		Redaction: RedactionConfig{Enabled: true, Names: []string{"Klaus"}, LLM: true},
+	}
+	a, err := NewAgentFromConfig(cfg, "Maria", "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	redactors, ok := a.Memory.Redactor.(memory.Redactors)
+	if !ok || len(redactors) != 2 {
+		t.Fatalf("Redactor = %#v, want patterns then the model", a.Memory.Redactor)
+	}
+	r, ok := redactors[1].(*memory.LLMRedactor)
+	if !ok || r.Model != "default-model" || r.Temperature == nil || *r.Temperature != 0 {
+		t.Errorf("LLM redactor = %#v, want default-model at temperature 0", redactors[1])
+	}
+}
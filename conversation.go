@@ -0,0 +1,151 @@
+package a25
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lordtatty/a25/dialogue"
+	"github.com/lordtatty/a25/events"
+	"github.com/lordtatty/a25/memory"
+	"github.com/lordtatty/a25/relationship"
+	"github.com/lordtatty/a25/social"
+)
+
+// relationshipMemories is the number of memories of the other agent drawn on
+// in conversation.
+const relationshipMemories = 5
+
+// StartConversation has the agent open a conversation with another agent,
+// drawing on what they remember of them. The conversation becomes the agent's
+// current one and is returned to be continued with Reply.
+func (a *Agent) StartConversation(ctx context.Context, with, reason string) (*dialogue.Conversation, error) {
+	memories, err := a.memoriesOf(ctx, with)
+	if err != nil {
+		return nil, err
+	}
+	c, err := a.Modules.Dialogue.Start(ctx, a.Name, with, reason, a.reactionContext(), memories)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start conversation: %w", err)
+	}
+	a.Conversation = c
+	return c, a.said(ctx, c)
+}
+
+// Reply has the agent take their next turn in the conversation.
+func (a *Agent) Reply(ctx context.Context, c *dialogue.Conversation) (dialogue.Turn, error) {
+	memories, err := a.memoriesOf(ctx, c.Other(a.Name))
+	if err != nil {
+		return dialogue.Turn{}, err
+	}
+	t, err := a.Modules.Dialogue.Reply(ctx, c, a.Name, a.reactionContext(), memories)
+	if err != nil {
+		return dialogue.Turn{}, fmt.Errorf("failed to reply: %w", err)
+	}
+	a.Conversation = c
+	return t, a.said(ctx, c)
+}
+
+// Hear has the agent decide, with the Reactor, how to respond to the other
+// participant's latest turn in the conversation: replying, replying and ending
+// the conversation, or saying nothing, which ends it. It reports whether the
+// agent replied.
+func (a *Agent) Hear(ctx context.Context, c *dialogue.Conversation) (bool, error) {
+	if c.Ended {
+		return false, fmt.Errorf("conversation has ended")
+	}
+	if len(c.Turns) == 0 || c.Turns[len(c.Turns)-1].Speaker == a.Name {
+		return false, fmt.Errorf("no turn for %s to hear", a.Name)
+	}
+	heard := c.Turns[len(c.Turns)-1]
+	if err := a.Memory.AddContext(ctx, memory.MemoryObject{
+		Description: fmt.Sprintf("%s said to %s: %q", heard.Speaker, a.Name, heard.Text),
+		Kind:        memory.Observation,
+		Source:      heard.Speaker,
+	}); err != nil {
+		return false, fmt.Errorf("failed to remember utterance: %w", err)
+	}
+	memories, err := a.memoriesOf(ctx, heard.Speaker)
+	if err != nil {
+		return false, err
+	}
+	summary := a.reactionContext()
+	if len(memories) > 0 {
+		summary += fmt.Sprintf("\nWhat %s remembers about %s:\n- %s", a.Name, heard.Speaker, strings.Join(memories, "\n- "))
+	}
+	before := dialogue.Conversation{Turns: c.Turns[:len(c.Turns)-1]}
+	r, err := a.Modules.React.ToUtteranceContext(ctx, heard.Speaker, heard.Text, before.Transcript(), summary, a.local(a.Clock().Now()))
+	if err != nil {
+		return false, fmt.Errorf("failed to decide response: %w", err)
+	}
+	if !r.Respond {
+		c.Ended = true
+		a.Conversation = nil
+		return false, a.Memory.AddMemoryContext(ctx, fmt.Sprintf("%s did not reply to %s.", a.Name, heard.Speaker))
+	}
+	c.Say(a.Name, r.Text, r.End)
+	a.Conversation = c
+	return true, a.said(ctx, c)
+}
+
+// Converse continues the conversation between the two agents, each hearing
+// and responding to the other in turn, until one of them ends it or maxTurns
+// more turns have been taken.
+func Converse(ctx context.Context, c *dialogue.Conversation, a, b *Agent, maxTurns int) error {
+	for i := 0; i < maxTurns && !c.Ended; i++ {
+		listener := a
+		if len(c.Turns) > 0 && c.Turns[len(c.Turns)-1].Speaker == a.Name {
+			listener = b
+		}
+		if _, err := listener.Hear(ctx, c); err != nil {
+			return err
+		}
+	}
+	if c.Ended {
+		for _, agent := range []*Agent{a, b} {
+			if agent.Conversation == c {
+				agent.Conversation = nil
+			}
+		}
+	}
+	return nil
+}
+
+// memoriesOf retrieves the agent's memories most relevant to the other agent,
+// led by their summary of the relationship if they have one.
+func (a *Agent) memoriesOf(ctx context.Context, other string) ([]string, error) {
+	if a.Memory.Len() == 0 {
+		return nil, nil
+	}
+	question := relationship.Question(a.Name, other)
+	retrieved, err := a.Memory.RetrieveMemoriesContext(ctx, question)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve memories: %w", err)
+	}
+	var memories []string
+	summary, found := a.relationshipSummary(question)
+	for _, r := range retrieved {
+		if len(memories) == relationshipMemories {
+			break
+		}
+		if r.Memory.ID != summary.ID {
+			memories = append(memories, r.Memory.Description)
+		}
+	}
+	if found {
+		memories = append([]string{summary.Description}, memories...)
+	}
+	return memories, nil
+}
+
+// said records the agent's latest turn in the conversation.
+func (a *Agent) said(ctx context.Context, c *dialogue.Conversation) error {
+	t := c.Turns[len(c.Turns)-1]
+	other := c.Other(a.Name)
+	a.interact(other, social.Talked)
+	a.emit(events.Utterance, map[string]any{"to": other, "text": t.Text, "ended": c.Ended})
+	if c.Ended {
+		a.Conversation = nil
+	}
+	return a.Memory.AddMemoryContext(ctx, fmt.Sprintf("%s said to %s: %q", a.Name, other, t.Text))
+}
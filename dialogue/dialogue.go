@@ -0,0 +1,128 @@
+package dialogue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/lordtatty/a25/llm"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+type OpenAIClient interface {
+	CreateChatCompletion(context.Context, openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error)
+}
+
+// Speaker generates what an agent says in conversation.
+type Speaker struct {
+	Client      OpenAIClient
+	Model       string   // Chat model; defaults to GPT-4o mini.
+	Temperature *float32 // Sampling temperature, set with llm.Temp; defaults to 1.
+}
+
+// model returns the chat model, defaulting to GPT-4o mini.
+func (s *Speaker) model() string {
+	return llm.Model(s.Model)
+}
+
+// temperature returns the sampling temperature, defaulting to 1.
+func (s *Speaker) temperature() float32 {
+	return llm.Temperature(s.Temperature)
+}
+
+// Turn is one utterance in a conversation.
+type Turn struct {
+	Speaker string `json:"speaker"`
+	Text    string `json:"text"`
+}
+
+// Conversation is a dialogue between two agents, continued turn by turn
+// until one of them ends it.
+type Conversation struct {
+	Initiator string `json:"initiator"`
+	Partner   string `json:"partner"`
+	Reason    string `json:"reason"` // Why the initiator started it.
+	Turns     []Turn `json:"turns"`
+	Ended     bool   `json:"ended"`
+}
+
+// Other returns the participant other than the speaker.
+func (c *Conversation) Other(speaker string) string {
+	if speaker == c.Initiator {
+		return c.Partner
+	}
+	return c.Initiator
+}
+
+// Transcript returns the conversation so far, one line per turn.
+func (c *Conversation) Transcript() string {
+	var lines []string
+	for _, t := range c.Turns {
+		lines = append(lines, t.Speaker+": "+t.Text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Say adds the speaker's utterance to the conversation, ending it if end is
+// set, and returns the turn.
+func (c *Conversation) Say(speaker, text string, end bool) Turn {
+	t := Turn{Speaker: speaker, Text: text}
+	c.Turns = append(c.Turns, t)
+	c.Ended = end
+	return t
+}
+
+// Start begins a conversation with the speaker's opening utterance to the
+// partner, conditioned on the speaker's context and memories of the partner.
+func (s *Speaker) Start(ctx context.Context, speaker, partner, reason, agentContext string, memories []string) (*Conversation, error) {
+	c := &Conversation{Initiator: speaker, Partner: partner, Reason: reason}
+	if _, err := s.Reply(ctx, c, speaker, agentContext, memories); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reply adds the speaker's next utterance to the conversation and returns it.
+// The speaker may end the conversation, after which no more turns are taken.
+func (s *Speaker) Reply(ctx context.Context, c *Conversation, speaker, agentContext string, memories []string) (Turn, error) {
+	if c.Ended {
+		return Turn{}, fmt.Errorf("conversation has ended")
+	}
+	other := c.Other(speaker)
+	sysPrompt := fmt.Sprintf(`You are %s, talking with %s. Say what %s says next, in character, in one to three sentences.
+Respond in JSON with the following format:
+{"utterance": "what %s says", "end": false}
+Set "end" to true if this utterance ends the conversation.`, speaker, other, speaker, speaker)
+
+	usrPrompt := fmt.Sprintf("Agent Context:\n%s\n", agentContext)
+	if len(memories) > 0 {
+		usrPrompt += fmt.Sprintf("What %s remembers about %s:\n- %s\n", speaker, other, strings.Join(memories, "\n- "))
+	}
+	if len(c.Turns) == 0 {
+		usrPrompt += fmt.Sprintf("%s is starting the conversation because: %s", speaker, c.Reason)
+	} else {
+		usrPrompt += "Conversation so far:\n" + c.Transcript()
+	}
+
+	resp, err := s.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: s.model(),
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: sysPrompt},
+			{Role: "user", Content: usrPrompt},
+		},
+		ResponseFormat: &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject},
+		Temperature:    s.temperature(),
+	})
+	if err != nil {
+		return Turn{}, err
+	}
+	var out struct {
+		Utterance string `json:"utterance"`
+		End       bool   `json:"end"`
+	}
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &out); err != nil {
+		return Turn{}, fmt.Errorf("failed to parse utterance: %w", err)
+	}
+	return c.Say(speaker, strings.TrimSpace(out.Utterance), out.End), nil
+}
@@ -0,0 +1,113 @@
+package a25
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// defaultEmotionHalfLife is how long an emotion takes to fade to half its
+// intensity by default.
+const defaultEmotionHalfLife = 2 * time.Hour
+
+// minIntensity is the intensity below which an emotion has faded away.
+const minIntensity = 0.1
+
+// Emotion is how the agent feels: a feeling, such as "anxious", with an
+// intensity from 0 to 1 that fades over time.
+type Emotion struct {
+	Feeling   string    `json:"feeling,omitempty"`
+	Intensity float64   `json:"intensity,omitempty"`
+	Since     time.Time `json:"since,omitempty"` // When the intensity was last set.
+}
+
+// EmotionRule is a cheap alternative to the language model for appraising
+// observations: an observation mentioning any of the words makes the agent
+// feel the feeling at the given intensity.
+type EmotionRule struct {
+	Words     []string
+	Feeling   string
+	Intensity float64
+}
+
+// at returns the emotion as it is at now, having faded with the half-life, or
+// the zero Emotion once it has faded away.
+func (e Emotion) at(now time.Time, halfLife time.Duration) Emotion {
+	if e.Feeling == "" {
+		return Emotion{}
+	}
+	if elapsed := now.Sub(e.Since); elapsed > 0 {
+		e.Intensity *= math.Pow(0.5, float64(elapsed)/float64(halfLife))
+		e.Since = now
+	}
+	if e.Intensity < minIntensity {
+		return Emotion{}
+	}
+	return e
+}
+
+// describe returns the feeling qualified by its intensity, e.g. "very anxious".
+func (e Emotion) describe() string {
+	switch {
+	case e.Intensity >= 0.7:
+		return "very " + e.Feeling
+	case e.Intensity >= 0.4:
+		return e.Feeling
+	default:
+		return "slightly " + e.Feeling
+	}
+}
+
+// emotionHalfLife returns the agent's emotion half-life.
+func (a *Agent) emotionHalfLife() time.Duration {
+	if a.EmotionHalfLife > 0 {
+		return a.EmotionHalfLife
+	}
+	return defaultEmotionHalfLife
+}
+
+// Mood returns how the agent feels now, with their last emotion faded.
+func (a *Agent) Mood() Emotion {
+	return a.Status.Emotion.at(a.Clock().Now(), a.emotionHalfLife())
+}
+
+// Feel updates how the agent feels. The same feeling again intensifies it;
+// a different one replaces it if at least as intense as the current mood.
+// An intensity of 0 counts as 0.5.
+func (a *Agent) Feel(feeling string, intensity float64) {
+	if intensity <= 0 {
+		intensity = 0.5
+	}
+	mood := a.Mood()
+	now := a.Clock().Now()
+	switch {
+	case strings.EqualFold(mood.Feeling, feeling):
+		a.Status.Emotion = Emotion{Feeling: mood.Feeling, Intensity: min(1, mood.Intensity+intensity), Since: now}
+	case intensity >= mood.Intensity:
+		a.Status.Emotion = Emotion{Feeling: feeling, Intensity: min(1, intensity), Since: now}
+	}
+}
+
+// appraise applies the first EmotionRule matching the observation, if any.
+func (a *Agent) appraise(observation string) {
+	words := wordSet(observation)
+	for _, r := range a.EmotionRules {
+		for _, w := range r.Words {
+			if words[strings.ToLower(w)] {
+				a.Feel(r.Feeling, r.Intensity)
+				return
+			}
+		}
+	}
+}
+
+// moodSummary describes the agent's mood for prompts, or is empty if they
+// feel nothing in particular.
+func (a *Agent) moodSummary() string {
+	mood := a.Mood()
+	if mood.Feeling == "" {
+		return ""
+	}
+	return fmt.Sprintf("\nFeeling: %s", mood.describe())
+}
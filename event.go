@@ -0,0 +1,220 @@
+package a25
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/lordtatty/a25/events"
+	"github.com/lordtatty/a25/plan"
+	"github.com/lordtatty/a25/social"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Event represents a social event hosted by an agent, such as a party.
+type Event struct {
+	ID          string
+	Host        string
+	Description string
+	Location    string
+	StartTime   time.Time
+	Duration    time.Duration
+	Invitees    []string
+	Accepted    []string
+	Declined    []string
+}
+
+// NewEvent creates an event hosted by the given agent. The host remembers
+// planning it and the event is added to the host's plan, failing if the
+// plan's validation rejects it.
+func NewEvent(host *Agent, description, location string, startTime time.Time, duration time.Duration) (*Event, error) {
+	return NewEventContext(context.Background(), host, description, location, startTime, duration)
+}
+
+// NewEventContext is NewEvent under the given context.
+func NewEventContext(ctx context.Context, host *Agent, description, location string, startTime time.Time, duration time.Duration) (*Event, error) {
+	e := &Event{
+		ID:          uuid.NewString(),
+		Host:        host.Name,
+		Description: description,
+		Location:    location,
+		StartTime:   startTime,
+		Duration:    duration,
+	}
+	if err := host.CurrentPlan.AddAction(e.action()); err != nil {
+		return nil, fmt.Errorf("failed to add event to plan: %w", err)
+	}
+	if err := host.Memory.AddMemoryContext(ctx, fmt.Sprintf("%s is planning to host %s", host.Name, e.summary())); err != nil {
+		return nil, fmt.Errorf("failed to remember event: %w", err)
+	}
+	e.Accepted = append(e.Accepted, host.Name)
+	return e, nil
+}
+
+// Invite delivers an invitation from the host to the agent, who decides whether
+// to attend. Accepting agents have the event inserted into their plan.
+func (e *Event) Invite(a *Agent) (bool, error) {
+	return e.InviteContext(context.Background(), a)
+}
+
+// InviteContext is Invite under the given context.
+func (e *Event) InviteContext(ctx context.Context, a *Agent) (bool, error) {
+	return e.TellContext(ctx, e.Host, a)
+}
+
+// Tell passes word of the event from one agent to another, letting invitations
+// propagate through conversation. The recipient decides whether to attend.
+func (e *Event) Tell(from string, to *Agent) (bool, error) {
+	return e.TellContext(context.Background(), from, to)
+}
+
+// TellContext is Tell under the given context.
+func (e *Event) TellContext(ctx context.Context, from string, to *Agent) (bool, error) {
+	if !slices.Contains(e.Invitees, to.Name) {
+		e.Invitees = append(e.Invitees, to.Name)
+	}
+	invitation := fmt.Sprintf("%s invited %s to %s", from, to.Name, e.summary())
+	if err := to.Memory.AddMemoryContext(ctx, invitation); err != nil {
+		return false, fmt.Errorf("failed to remember invitation: %w", err)
+	}
+	to.appraise(invitation)
+	if to.Social != nil {
+		to.Social.Record(from, to.Name, social.Invited, to.Clock().Now())
+	}
+	if slices.Contains(e.Accepted, to.Name) {
+		return true, nil
+	}
+
+	accept, reason, err := to.decideRSVP(ctx, e)
+	if err != nil {
+		return false, fmt.Errorf("failed to decide rsvp: %w", err)
+	}
+	if !accept {
+		if !slices.Contains(e.Declined, to.Name) {
+			e.Declined = append(e.Declined, to.Name)
+		}
+		return false, to.Memory.AddMemoryContext(ctx, fmt.Sprintf("%s declined the invitation to %s's event because: %s", to.Name, e.Host, reason))
+	}
+	if err := to.CurrentPlan.AddAction(e.action()); err != nil {
+		return false, fmt.Errorf("failed to add event to plan: %w", err)
+	}
+	e.Declined = slices.DeleteFunc(e.Declined, func(n string) bool { return n == to.Name })
+	e.Accepted = append(e.Accepted, to.Name)
+	to.emit(events.PlanChanged, map[string]any{"reason": "accepted invitation", "action": e.Description})
+	return true, to.Memory.AddMemoryContext(ctx, fmt.Sprintf("%s accepted the invitation to %s's event because: %s", to.Name, e.Host, reason))
+}
+
+// Attend records attendance observations for every agent present at the event
+// who had accepted the invitation.
+func (e *Event) Attend(agents []*Agent) error {
+	return e.AttendContext(context.Background(), agents)
+}
+
+// AttendContext is Attend under the given context.
+func (e *Event) AttendContext(ctx context.Context, agents []*Agent) error {
+	var attendees []string
+	for _, a := range agents {
+		if slices.Contains(e.Accepted, a.Name) {
+			attendees = append(attendees, a.Name)
+		}
+	}
+	for _, a := range agents {
+		if !slices.Contains(e.Accepted, a.Name) {
+			continue
+		}
+		var others []string
+		for _, n := range attendees {
+			if n != a.Name {
+				others = append(others, n)
+			}
+		}
+		obs := fmt.Sprintf("%s attended %s", a.Name, e.summary())
+		for _, n := range others {
+			a.interact(n, social.Observed)
+		}
+		if len(others) > 0 {
+			obs += " along with " + strings.Join(others, ", ")
+		}
+		if err := a.Memory.AddMemoryContext(ctx, obs); err != nil {
+			return fmt.Errorf("%s failed to remember attending: %w", a.Name, err)
+		}
+	}
+	return nil
+}
+
+// summary describes the event in a sentence suitable for memories.
+func (e *Event) summary() string {
+	return fmt.Sprintf("%s at %s on %s", e.Description, e.Location, e.StartTime.Format("January 2 at 3:04 PM"))
+}
+
+// action converts the event into a plan action.
+func (e *Event) action() plan.Action {
+	return plan.Action{
+		Description: e.Description,
+		Location:    e.Location,
+		StartTime:   e.StartTime,
+		Duration:    e.Duration,
+	}
+}
+
+// decideRSVP asks the model whether the agent will attend the event.
+func (a *Agent) decideRSVP(ctx context.Context, e *Event) (bool, string, error) {
+	retrieved, err := a.Memory.RetrieveMemoriesContext(ctx, e.Description)
+	if err != nil {
+		return false, "", err
+	}
+	var planTexts []string
+	for _, act := range a.CurrentPlan.Actions() {
+		planTexts = append(planTexts, fmt.Sprintf("- %s: %s", act.StartTime.Format("3:04 PM"), act.Description))
+	}
+
+	sysPrompt := `Based on the agent's context, memories and plan, decide whether the agent will attend the event they were invited to.
+Respond with 'Yes' or 'No' followed by a brief explanation.`
+	usrPrompt := fmt.Sprintf(`Agent: %s
+Traits: %s
+Description: %s
+Relevant Memories:
+%s
+Current Plan:
+%s
+Invitation: %s is hosting %s`, a.Name, a.Traits, a.Description, memoryList(retrieved), strings.Join(planTexts, "\n"), e.Host, e.summary())
+
+	resp, err := a.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: a.model(),
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: sysPrompt},
+			{Role: "user", Content: usrPrompt},
+		},
+		Temperature: a.temperature(),
+	})
+	if err != nil {
+		return false, "", err
+	}
+
+	return parseRSVP(resp.Choices[0].Message.Content)
+}
+
+// parseRSVP reads whether the agent will attend, and why, from the model's
+// response, which must start with the word yes or no.
+func parseRSVP(response string) (bool, string, error) {
+	notWord := func(r rune) bool { return !unicode.IsLetter(r) }
+	text := strings.TrimLeftFunc(response, notWord)
+	word, reason := text, ""
+	if i := strings.IndexFunc(text, notWord); i >= 0 {
+		word, reason = text[:i], text[i:]
+	}
+	reason = strings.TrimSpace(strings.TrimLeftFunc(reason, func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSpace(r)
+	}))
+	switch {
+	case strings.EqualFold(word, "yes"):
+		return true, reason, nil
+	case strings.EqualFold(word, "no"):
+		return false, reason, nil
+	}
+	return false, "", fmt.Errorf("unexpected rsvp response: %q", strings.TrimSpace(response))
+}
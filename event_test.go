@@ -0,0 +1,102 @@
+package a25
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/lordtatty/a25/memory"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// scriptedClient answers chat completions with its replies in turn, repeating
+// the last, and embeds each text as a one-hot vector of its first letter. It
+// fails once its context is cancelled.
+type scriptedClient struct {
+	replies []string
+	chats   int
+}
+
+func (c *scriptedClient) CreateChatCompletion(ctx context.Context, _ openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	reply := c.replies[min(c.chats, len(c.replies)-1)]
+	c.chats++
+	return &openai.ChatCompletionResponse{Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: reply}}}}, nil
+}
+
+func (c *scriptedClient) CreateEmbeddings(ctx context.Context, conv openai.EmbeddingRequestConverter) (*openai.EmbeddingResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	resp := &openai.EmbeddingResponse{}
+	for i, text := range conv.Convert().Input.([]string) {
+		e := make([]float32, 26)
+		e[(text[0]|0x20)%26] = 1
+		resp.Data = append(resp.Data, openai.Embedding{Index: i, Embedding: e})
+	}
+	return resp, nil
+}
+
+// newTestAgent creates an agent whose memories are rated without the model.
+func newTestAgent(name string, client OpenAIClient) *Agent {
+	a := NewAgent(name, "friendly", "a resident of Smallville", client)
+	a.Memory.Rater = memory.StaticRater(3)
+	return a
+}
+
+func TestParseRSVP(t *testing.T) {
+	tests := []struct {
+		response string
+		attend   bool
+		reason   string
+		wantErr  bool
+	}{
+		{"Yes, I love parties.", true, "I love parties.", false},
+		{"no. I am busy", false, "I am busy", false},
+		{"**YES** - sounds fun", true, "sounds fun", false},
+		{"No", false, "", false},
+		{"Not really, I am tired.", false, "", true},
+		{"Yesterday I said I would go.", false, "", true},
+		{"Nope", false, "", true},
+		{"Maybe", false, "", true},
+	}
+	for _, tt := range tests {
+		attend, reason, err := parseRSVP(tt.response)
+		if (err != nil) != tt.wantErr || attend != tt.attend || reason != tt.reason {
+			t.Errorf("parseRSVP(%q) = %t, %q, %v; want %t, %q, error %t", tt.response, attend, reason, err, tt.attend, tt.reason, tt.wantErr)
+		}
+	}
+}
+
+func TestTellRecordsEachAgentOnce(t *testing.T) {
+	start := time.Date(2024, 2, 14, 17, 0, 0, 0, time.UTC)
+	host := newTestAgent("Isabella", &scriptedClient{replies: []string{"Yes"}})
+	e, err := NewEvent(host, "Valentine's Day party", "Hobbs Cafe", start, 2*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	guest := newTestAgent("Klaus", &scriptedClient{replies: []string{"No, I have to study.", "No, still studying.", "Yes, I finished early.", "No"}})
+	want := []struct {
+		accepted []string
+		declined []string
+	}{
+		{[]string{"Isabella"}, []string{"Klaus"}},
+		{[]string{"Isabella"}, []string{"Klaus"}},
+		{[]string{"Isabella", "Klaus"}, nil},
+		{[]string{"Isabella", "Klaus"}, nil},
+	}
+	for i, w := range want {
+		if _, err := e.Tell(host.Name, guest); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(e.Accepted, w.accepted) || !slices.Equal(e.Declined, w.declined) {
+			t.Errorf("after telling %d times: accepted %q, declined %q; want %q, %q", i+1, e.Accepted, e.Declined, w.accepted, w.declined)
+		}
+	}
+	if !slices.Equal(e.Invitees, []string{"Klaus"}) {
+		t.Errorf("Invitees = %q, want Klaus once", e.Invitees)
+	}
+}
@@ -0,0 +1,124 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Event types recorded in the activity log.
+const (
+	MemoryAdded     = "memory_added"
+	ReactionDecided = "reaction_decided"
+	PlanChanged     = "plan_changed"
+	LLMCall         = "llm_call"
+	Utterance       = "utterance"
+)
+
+// Event is a single entry in an agent's activity log.
+type Event struct {
+	Time    time.Time      `json:"time"`
+	AgentID string         `json:"agent_id"`
+	Agent   string         `json:"agent"`
+	Type    string         `json:"type"`
+	Data    map[string]any `json:"data,omitempty"`
+}
+
+// Sink receives events as they happen.
+type Sink interface {
+	Emit(Event)
+}
+
+// JSONL is a Sink that appends each event to a writer as a line of JSON. It is
+// safe for concurrent use, so one log can be shared by many agents.
+type JSONL struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewJSONL creates a JSONL sink writing to w.
+func NewJSONL(w io.Writer) *JSONL {
+	return &JSONL{enc: json.NewEncoder(w)}
+}
+
+// Emit writes the event. After a write fails, further events are dropped.
+func (j *JSONL) Emit(e Event) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.err != nil {
+		return
+	}
+	j.err = j.enc.Encode(e)
+}
+
+// Err returns the first error encountered while writing events.
+func (j *JSONL) Err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.err
+}
+
+type OpenAIClient interface {
+	CreateChatCompletion(context.Context, openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error)
+	CreateEmbeddings(context.Context, openai.EmbeddingRequestConverter) (*openai.EmbeddingResponse, error)
+}
+
+// Client is an OpenAIClient that emits a summary of every call to a Sink.
+type Client struct {
+	Client  OpenAIClient
+	Sink    Sink
+	AgentID string
+	Agent   string
+}
+
+// CreateChatCompletion creates a chat completion and records the call.
+func (c *Client) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error) {
+	start := time.Now()
+	resp, err := c.Client.CreateChatCompletion(ctx, req)
+	data := map[string]any{
+		"kind":     "chat",
+		"model":    req.Model,
+		"duration": time.Since(start).String(),
+	}
+	if err != nil {
+		data["error"] = err.Error()
+	} else {
+		data["tokens"] = resp.Usage.TotalTokens
+	}
+	c.emit(start, data)
+	return resp, err
+}
+
+// CreateEmbeddings creates embeddings and records the call.
+func (c *Client) CreateEmbeddings(ctx context.Context, conv openai.EmbeddingRequestConverter) (*openai.EmbeddingResponse, error) {
+	start := time.Now()
+	resp, err := c.Client.CreateEmbeddings(ctx, conv)
+	data := map[string]any{
+		"kind":     "embedding",
+		"model":    string(conv.Convert().Model),
+		"duration": time.Since(start).String(),
+	}
+	if err != nil {
+		data["error"] = err.Error()
+	} else {
+		data["tokens"] = resp.Usage.TotalTokens
+	}
+	c.emit(start, data)
+	return resp, err
+}
+
+// emit records an LLM call event.
+func (c *Client) emit(t time.Time, data map[string]any) {
+	c.Sink.Emit(Event{
+		Time:    t,
+		AgentID: c.AgentID,
+		Agent:   c.Agent,
+		Type:    LLMCall,
+		Data:    data,
+	})
+}
@@ -10,6 +10,7 @@ import (
 	openai "github.com/sashabaranov/go-openai"
 
 	"github.com/lordtatty/a25"
+	"github.com/lordtatty/a25/memory"
 )
 
 func main() {
@@ -37,24 +38,34 @@ func main() {
 	)
 
 	// Add some initial memories.
-	agent.AddMemory("Klaus Mueller is reading a book on gentrification.", 7.0)
-	agent.AddMemory("Klaus Mueller is conversing with a librarian about his research project.", 6.5)
-	agent.AddMemory("Klaus Mueller had lunch at the campus cafe.", 2.0)
-	agent.AddMemory("Klaus Mueller attended a lecture on urban development.", 8.0)
-	agent.AddMemory("Klaus Mueller met with Maria Lopez to discuss research.", 7.5)
+	seed := []memory.MemoryObject{
+		{Description: "Klaus Mueller is reading a book on gentrification.", Importance: 7.0},
+		{Description: "Klaus Mueller is conversing with a librarian about his research project.", Importance: 6.5},
+		{Description: "Klaus Mueller had lunch at the campus cafe.", Importance: 2.0},
+		{Description: "Klaus Mueller attended a lecture on urban development.", Importance: 8.0},
+		{Description: "Klaus Mueller met with Maria Lopez to discuss research.", Importance: 7.5},
+	}
+	if err := agent.AddAll(seed); err != nil {
+		fmt.Println("Error adding memories:", err)
+		return
+	}
 
 	// ===== EXISTING FEATURE DEMONSTRATION =====
 	// Agent reflects on recent experiences.
 	fmt.Println("Agent is reflecting on recent experiences...")
-	err := agent.Reflect()
+	reflections, err := agent.Reflect()
 	if err != nil {
 		fmt.Println("Error during reflection:", err)
 		return
 	}
+	fmt.Println("Agent concluded:")
+	for _, r := range reflections {
+		fmt.Printf("- %s (Importance: %.1f, from %d memories)\n", r.Description, r.Importance, len(r.Evidence))
+	}
 
 	// Print out the agent's memories after reflection.
 	fmt.Println("\nAgent's memories after reflection:")
-	for _, mem := range agent.Memory.Memories {
+	for _, mem := range agent.Memory.Memories() {
 		fmt.Printf("- %s (Importance: %.1f)\n", mem.Description, mem.Importance)
 	}
 
@@ -70,12 +81,13 @@ func main() {
 
 	// Print the agent's planned actions.
 	fmt.Println("\nAgent's planned actions for the day:")
-	for _, action := range agent.CurrentPlan.Actions() {
-		fmt.Printf("- Action: %s | Location: %s | StartTime: %s\n", action.Description, action.Location, action.StartTime.Format(time.RFC822))
-	}
+	fmt.Print(agent.CurrentPlan.RenderMarkdown())
 
 	// Select Task
-	agent.SelectTask()
+	if err := agent.SelectTask(); err != nil {
+		fmt.Println("Error selecting task:", err)
+		return
+	}
 
 	// Simulate agent perceiving a new observation.
 	observation := "Klaus sees a protest happening outside the university."
@@ -106,7 +118,7 @@ func main() {
 
 	// Print out the agent's memories after updates (including perception and action execution).
 	fmt.Println("\nAgent's memories after updates:")
-	for _, mem := range agent.Memory.Memories {
+	for _, mem := range agent.Memory.Memories() {
 		fmt.Printf("- %s (Importance: %.1f)\n", mem.Description, mem.Importance)
 	}
 }
@@ -0,0 +1,81 @@
+// main.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	oailog "github.com/lordtatty/openai-log"
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/lordtatty/a25"
+)
+
+// This example recreates the Valentine's Day party scenario from the paper: one
+// agent hosts a party and word of it spreads from agent to agent.
+func main() {
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if apiKey == "" {
+		fmt.Println("Please set the OPENAI_API_KEY environment variable.")
+		return
+	}
+
+	client := &oailog.AI{
+		Client:        openai.NewClient(apiKey),
+		DefaultModel:  openai.GPT4oMini,
+		EnableLogging: true,
+	}
+	defer client.Usage.PrintUsage()
+
+	isabella := a25.NewAgent(
+		"Isabella Rodriguez",
+		"friendly, outgoing, hospitable",
+		"Isabella Rodriguez runs Hobbs Cafe and loves to make people feel welcome.",
+		client,
+	)
+	maria := a25.NewAgent(
+		"Maria Lopez",
+		"energetic, enthusiastic, inquisitive",
+		"Maria Lopez is a student who often spends time at Hobbs Cafe. She has a secret crush on Klaus Mueller.",
+		client,
+	)
+	klaus := a25.NewAgent(
+		"Klaus Mueller",
+		"dedicated, curious, analytical",
+		"Klaus Mueller is a college student studying urban planning.",
+		client,
+	)
+
+	year := time.Now().Year()
+	start := time.Date(year, time.February, 14, 17, 0, 0, 0, time.Local)
+	party, err := a25.NewEvent(isabella, "Valentine's Day party", "Hobbs Cafe", start, 2*time.Hour)
+	if err != nil {
+		fmt.Println("Error creating event:", err)
+		return
+	}
+
+	// Isabella invites Maria, who passes the invitation on to Klaus.
+	if _, err := party.Invite(maria); err != nil {
+		fmt.Println("Error during invitation:", err)
+		return
+	}
+	if _, err := party.Tell(maria.Name, klaus); err != nil {
+		fmt.Println("Error during invitation:", err)
+		return
+	}
+	fmt.Printf("Accepted: %v\nDeclined: %v\n", party.Accepted, party.Declined)
+
+	agents := []*a25.Agent{isabella, maria, klaus}
+	if err := party.Attend(agents); err != nil {
+		fmt.Println("Error during the party:", err)
+		return
+	}
+
+	for _, a := range agents {
+		fmt.Printf("\n%s's memories:\n", a.Name)
+		for _, mem := range a.Memory.Memories() {
+			fmt.Printf("- %s (Importance: %.1f)\n", mem.Description, mem.Importance)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package a25
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lordtatty/a25/events"
+	"github.com/lordtatty/a25/plan"
+)
+
+const (
+	// MaxEnergy is the energy of a fully rested agent.
+	MaxEnergy = 100.0
+	// ExhaustionThreshold is the energy below which an agent considers ending its day.
+	ExhaustionThreshold = 20.0
+
+	drainPerHour   = 8.0
+	recoverPerHour = 20.0
+)
+
+// spendEnergy drains energy for the time spent on an action, or restores it if
+// the action is restful.
+func (a *Agent) spendEnergy(action plan.Action) {
+	hours := action.Duration.Hours()
+	if action.IsRest() {
+		a.Status.Energy = min(a.Status.Energy+hours*recoverPerHour, MaxEnergy)
+		return
+	}
+	a.Status.Energy = max(a.Status.Energy-hours*drainPerHour, 0)
+}
+
+// Exhausted reports whether the agent's energy has fallen below the exhaustion threshold.
+func (a *Agent) Exhausted() bool {
+	return a.Status.Energy < ExhaustionThreshold
+}
+
+// CheckFatigue lets an exhausted agent decide whether to cut the day short. If
+// it does, the rest of the plan is replaced with rest until midnight.
+func (a *Agent) CheckFatigue(currentTime time.Time) (bool, error) {
+	currentTime = a.local(currentTime)
+	if !a.Exhausted() {
+		return false, nil
+	}
+	observation := fmt.Sprintf("%s feels exhausted, with only %.0f of %.0f energy left.", a.Name, a.Status.Energy, MaxEnergy)
+	shouldReact, reason, err := a.Modules.React.ToObservation(observation, a.reactionContext(), currentTime)
+	if err != nil {
+		return false, fmt.Errorf("failed to decide on fatigue: %w", err)
+	}
+	if !shouldReact {
+		return false, a.Memory.AddMemory(fmt.Sprintf("%s decided to keep going despite being exhausted.", a.Name))
+	}
+	a.CurrentPlan.Truncate(currentTime)
+	y, m, d := currentTime.Date()
+	_, err = a.addAction(plan.Action{
+		Description: "Rest for the remainder of the day",
+		StartTime:   currentTime,
+		Duration:    time.Date(y, m, d+1, 0, 0, 0, 0, currentTime.Location()).Sub(currentTime),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to plan rest: %w", err)
+	}
+	a.emit(events.PlanChanged, map[string]any{"reason": "exhausted", "action": "rest"})
+	return true, a.Memory.AddMemory(fmt.Sprintf("%s decided to cut the day short because: %s", a.Name, reason))
+}
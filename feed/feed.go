@@ -0,0 +1,126 @@
+// Package feed streams agents' new memories to HTTP clients as server-sent
+// events, so observability UIs and game HUDs can show what an agent just
+// thought as it happens.
+package feed
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lordtatty/a25/memory"
+)
+
+// bufferSize is the number of memories queued per subscriber. Memories sent to
+// a subscriber whose queue is full are dropped so a slow client never stalls
+// the simulation.
+const bufferSize = 64
+
+// Memory is the JSON payload sent for each new memory.
+type Memory struct {
+	ID           string    `json:"id"`
+	Agent        string    `json:"agent"`
+	Description  string    `json:"description"`
+	Kind         string    `json:"kind,omitempty"`
+	Source       string    `json:"source,omitempty"`
+	Importance   float64   `json:"importance"`
+	CreationTime time.Time `json:"creation_time"`
+}
+
+// Hub fans new memories out to the clients subscribed to each agent. It is an
+// http.Handler meant to be mounted on a pattern with an {agent} wildcard:
+//
+//	mux.Handle("GET /agents/{agent}/memories", hub)
+type Hub struct {
+	mu   sync.Mutex
+	subs map[string]map[chan Memory]struct{}
+}
+
+// NewHub creates an empty hub.
+func NewHub() *Hub {
+	return &Hub{subs: make(map[string]map[chan Memory]struct{})}
+}
+
+// Publish sends a memory to every client subscribed to the agent.
+func (h *Hub) Publish(agent string, m memory.MemoryObject) {
+	msg := Memory{
+		ID:           m.ID,
+		Agent:        agent,
+		Description:  m.Description,
+		Kind:         m.Kind,
+		Source:       m.Source,
+		Importance:   m.Importance,
+		CreationTime: m.CreationTime,
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs[agent] {
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+}
+
+// subscribe registers a new subscriber for the agent.
+func (h *Hub) subscribe(agent string) chan Memory {
+	ch := make(chan Memory, bufferSize)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs[agent] == nil {
+		h.subs[agent] = make(map[chan Memory]struct{})
+	}
+	h.subs[agent][ch] = struct{}{}
+	return ch
+}
+
+// unsubscribe removes a subscriber.
+func (h *Hub) unsubscribe(agent string, ch chan Memory) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subs[agent], ch)
+	if len(h.subs[agent]) == 0 {
+		delete(h.subs, agent)
+	}
+}
+
+// ServeHTTP streams the memories of the agent named by the {agent} path value
+// until the client disconnects.
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	agent := r.PathValue("agent")
+	if agent == "" {
+		http.Error(w, "missing agent", http.StatusBadRequest)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	ch := h.subscribe(agent)
+	defer h.unsubscribe(agent, ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case m := <-ch:
+			data, err := json.Marshal(m)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: memory\ndata: %s\n\n", m.ID, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -6,4 +6,21 @@ require (
 	github.com/google/uuid v1.6.0
 	github.com/lordtatty/openai-log v0.0.0-20241014165047-31649d706d39
 	github.com/sashabaranov/go-openai v1.32.1
+	go.etcd.io/bbolt v1.3.11
+	go.mongodb.org/mongo-driver/v2 v2.0.0
+	gonum.org/v1/gonum v0.15.1
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/golang/snappy v0.0.4 // indirect
+	github.com/klauspost/compress v1.16.7 // indirect
+	github.com/xdg-go/pbkdf2 v1.0.0 // indirect
+	github.com/xdg-go/scram v1.1.2 // indirect
+	github.com/xdg-go/stringprep v1.0.4 // indirect
+	github.com/youmark/pkcs8 v0.0.0-20240726163527-a2c0da244d78 // indirect
+	golang.org/x/crypto v0.29.0 // indirect
+	golang.org/x/sync v0.9.0 // indirect
+	golang.org/x/sys v0.27.0 // indirect
+	golang.org/x/text v0.20.0 // indirect
 )
@@ -0,0 +1,150 @@
+package a25
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lordtatty/a25/plan"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Goal is a long-horizon objective the agent is working towards.
+type Goal struct {
+	ID          string
+	Description string
+	Deadline    time.Time
+	Progress    float64 // Fraction complete, between 0 and 1.
+}
+
+// GoalStatus pairs a goal with the agent pursuing it.
+type GoalStatus struct {
+	Agent string
+	Goal  Goal
+}
+
+// AddGoal adds a new goal for the agent.
+func (a *Agent) AddGoal(description string, deadline time.Time) Goal {
+	g := Goal{
+		ID:          uuid.NewString(),
+		Description: description,
+		Deadline:    deadline,
+	}
+	a.Goals = append(a.Goals, g)
+	return g
+}
+
+// AssessGoals evaluates progress on each goal from the agent's recent memories,
+// updates the goal's progress and records the assessment as a memory.
+func (a *Agent) AssessGoals() error {
+	return a.AssessGoalsContext(context.Background())
+}
+
+// AssessGoalsContext is AssessGoals under the given context.
+func (a *Agent) AssessGoalsContext(ctx context.Context) error {
+	var memoryTexts []string
+	for _, mem := range a.Memory.GetRecentMemories(30) {
+		memoryTexts = append(memoryTexts, "- "+mem.Description)
+	}
+	for i := range a.Goals {
+		g := &a.Goals[i]
+		progress, err := a.assessGoal(ctx, *g, memoryTexts)
+		if err != nil {
+			return fmt.Errorf("failed to assess goal '%s': %w", g.Description, err)
+		}
+		g.Progress = progress
+		if err := a.Memory.AddMemoryContext(ctx, fmt.Sprintf("%s assessed progress on the goal '%s': %.0f%% complete.", a.Name, g.Description, progress*100)); err != nil {
+			return fmt.Errorf("failed to remember goal assessment: %w", err)
+		}
+	}
+	return nil
+}
+
+// assessGoal asks the model how far the agent has progressed towards a goal.
+func (a *Agent) assessGoal(ctx context.Context, g Goal, memoryTexts []string) (float64, error) {
+	sysPrompt := "Based on the agent's recent memories, estimate how much progress the agent has made towards the goal as a percentage from 0 to 100.  Output a single number only, e.g., 40.  Include no other comment or opinion."
+	usrPrompt := fmt.Sprintf(`Agent: %s
+Goal: %s
+Current Progress: %.0f%%
+Recent Memories:
+%s`, a.Name, g.Description, g.Progress*100, strings.Join(memoryTexts, "\n"))
+
+	resp, err := a.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: a.model(),
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: sysPrompt},
+			{Role: "user", Content: usrPrompt},
+		},
+		Temperature: a.temperature(),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	pct, err := strconv.ParseFloat(strings.Trim(strings.TrimSpace(resp.Choices[0].Message.Content), "%"), 64)
+	if err != nil {
+		return 0, err
+	}
+	return min(max(pct/100, 0), 1), nil
+}
+
+// goalSummary lists the agent's goals with their IDs, deadlines and progress
+// for planning prompts.
+func (a *Agent) goalSummary() string {
+	var lines []string
+	for _, g := range a.Goals {
+		line := fmt.Sprintf("- %s (ID %s, %.0f%% complete", g.Description, g.ID, g.Progress*100)
+		if !g.Deadline.IsZero() {
+			line += ", due " + g.Deadline.Format("January 2, 2006")
+		}
+		lines = append(lines, line+")")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// goalFor returns the goal an action works towards, matched by ID or, failing
+// that, description, or nil if it works towards none.
+func (a *Agent) goalFor(action plan.Action) *Goal {
+	if action.Goal == "" {
+		return nil
+	}
+	for i := range a.Goals {
+		if a.Goals[i].ID == action.Goal || strings.EqualFold(a.Goals[i].Description, action.Goal) {
+			return &a.Goals[i]
+		}
+	}
+	return nil
+}
+
+// advanceGoal reassesses the goal a completed action worked towards, if any.
+func (a *Agent) advanceGoal(ctx context.Context, action plan.Action) error {
+	g := a.goalFor(action)
+	if g == nil {
+		return nil
+	}
+	var memoryTexts []string
+	for _, mem := range a.Memory.GetRecentMemories(30) {
+		memoryTexts = append(memoryTexts, "- "+mem.Description)
+	}
+	memoryTexts = append(memoryTexts, fmt.Sprintf("- %s completed: %s", a.Name, action.Description))
+	progress, err := a.assessGoal(ctx, *g, memoryTexts)
+	if err != nil {
+		return fmt.Errorf("failed to assess goal '%s': %w", g.Description, err)
+	}
+	g.Progress = progress
+	return nil
+}
+
+// GoalReport returns the status of every goal held by the given agents.
+func GoalReport(agents []*Agent) []GoalStatus {
+	var report []GoalStatus
+	for _, a := range agents {
+		for _, g := range a.Goals {
+			report = append(report, GoalStatus{Agent: a.Name, Goal: g})
+		}
+	}
+	return report
+}
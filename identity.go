@@ -0,0 +1,83 @@
+package a25
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lordtatty/a25/memory"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// maxIdentityReflections is the most reflections drawn on when refreshing the
+// agent's identity.
+const maxIdentityReflections = 30
+
+// RefreshIdentity rewrites the agent's Traits and Description from their
+// reflections, deepest and most recent first, so the persona evolves with
+// experience rather than staying as it was at construction. Without
+// reflections the identity is left as it is.
+func (a *Agent) RefreshIdentity(ctx context.Context) error {
+	var reflections []memory.MemoryObject
+	for _, m := range a.Memory.GetMemoriesBetween(time.Time{}, time.Time{}) {
+		if m.Kind == memory.Reflection {
+			reflections = append(reflections, m)
+		}
+	}
+	if len(reflections) == 0 {
+		return nil
+	}
+	sort.SliceStable(reflections, func(i, j int) bool {
+		if reflections[i].Depth != reflections[j].Depth {
+			return reflections[i].Depth > reflections[j].Depth
+		}
+		return reflections[i].CreationTime.After(reflections[j].CreationTime)
+	})
+	var lines []string
+	for _, m := range reflections[:min(maxIdentityReflections, len(reflections))] {
+		lines = append(lines, "- "+m.Description)
+	}
+
+	sysPrompt := `Update the agent's identity in light of their reflections on their experience. Keep what still holds, and change what their experience has changed.
+Respond in JSON with the following format:
+{"traits": "a few comma-separated personality traits", "description": "a short third-person description of who the agent is now"}`
+	usrPrompt := fmt.Sprintf("Name: %s\nTraits: %s\nDescription: %s\nReflections:\n%s", a.Name, a.Traits, a.Description, strings.Join(lines, "\n"))
+
+	resp, err := a.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: a.model(),
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: sysPrompt},
+			{Role: "user", Content: usrPrompt},
+		},
+		ResponseFormat: &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject},
+		Temperature:    a.temperature(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to refresh identity: %w", err)
+	}
+	var out struct {
+		Traits      string `json:"traits"`
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &out); err != nil {
+		return fmt.Errorf("failed to parse identity: %w", err)
+	}
+	if t := strings.TrimSpace(out.Traits); t != "" {
+		a.Traits = t
+	}
+	if d := strings.TrimSpace(out.Description); d != "" {
+		a.Description = d
+	}
+	return nil
+}
+
+// RefreshIdentityEvery schedules RefreshIdentity to run at the given interval
+// as one of the agent's triggers.
+func (a *Agent) RefreshIdentityEvery(d time.Duration) error {
+	return a.Every(d, func(time.Time) error {
+		return a.RefreshIdentity(context.Background())
+	})
+}
@@ -0,0 +1,171 @@
+// Package kv persists agents' memories and plans in an embedded bbolt
+// database, for single-binary deployments without an external database.
+package kv
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/lordtatty/a25/memory"
+	"github.com/lordtatty/a25/plan"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Each agent has a bucket of its own holding a bucket of memories keyed by ID
+// and its plan under a single key.
+var (
+	agentsBucket   = []byte("agents")
+	memoriesBucket = []byte("memories")
+	planKey        = []byte("plan")
+)
+
+// DB is an embedded database of agent state.
+type DB struct {
+	db *bolt.DB
+}
+
+// Open opens or creates the database at path.
+func Open(path string) (*DB, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return &DB{db: db}, nil
+}
+
+// Close closes the database.
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// Memories returns a memory.Store for the agent's memories.
+func (d *DB) Memories(agent string) *MemoryStore {
+	return &MemoryStore{db: d.db, agent: []byte(agent)}
+}
+
+// Namespace returns the agent's memory store, making DB a memory.SharedStore.
+func (d *DB) Namespace(agent string) memory.Store {
+	return d.Memories(agent)
+}
+
+// SavePlan stores the agent's plan, replacing any saved before.
+func (d *DB) SavePlan(agent string, actions []plan.Action) error {
+	data, err := json.Marshal(actions)
+	if err != nil {
+		return err
+	}
+	return d.db.Update(func(tx *bolt.Tx) error {
+		b, err := agentBucket(tx, []byte(agent))
+		if err != nil {
+			return err
+		}
+		return b.Put(planKey, data)
+	})
+}
+
+// LoadPlan returns the agent's saved plan, or nil if none was saved.
+func (d *DB) LoadPlan(agent string) ([]plan.Action, error) {
+	var actions []plan.Action
+	err := d.db.View(func(tx *bolt.Tx) error {
+		b := readAgentBucket(tx, []byte(agent))
+		if b == nil {
+			return nil
+		}
+		data := b.Get(planKey)
+		if data == nil {
+			return nil
+		}
+		return json.Unmarshal(data, &actions)
+	})
+	return actions, err
+}
+
+// MemoryStore is a memory.Store keeping one agent's memories in the database.
+type MemoryStore struct {
+	db    *bolt.DB
+	agent []byte
+}
+
+// Load returns the agent's memories in creation order.
+func (s *MemoryStore) Load() ([]memory.MemoryObject, error) {
+	var memories []memory.MemoryObject
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := readAgentBucket(tx, s.agent)
+		if b == nil {
+			return nil
+		}
+		mb := b.Bucket(memoriesBucket)
+		if mb == nil {
+			return nil
+		}
+		return mb.ForEach(func(k, v []byte) error {
+			var m memory.MemoryObject
+			if err := json.Unmarshal(v, &m); err != nil {
+				return fmt.Errorf("memory %s: %w", k, err)
+			}
+			memories = append(memories, m)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(memories, func(a, b memory.MemoryObject) int {
+		return a.CreationTime.Compare(b.CreationTime)
+	})
+	return memories, nil
+}
+
+// Put inserts or replaces a memory.
+func (s *MemoryStore) Put(m memory.MemoryObject) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := agentBucket(tx, s.agent)
+		if err != nil {
+			return err
+		}
+		mb, err := b.CreateBucketIfNotExists(memoriesBucket)
+		if err != nil {
+			return err
+		}
+		return mb.Put([]byte(m.ID), data)
+	})
+}
+
+// Delete removes a memory.
+func (s *MemoryStore) Delete(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := readAgentBucket(tx, s.agent)
+		if b == nil {
+			return nil
+		}
+		mb := b.Bucket(memoriesBucket)
+		if mb == nil {
+			return nil
+		}
+		return mb.Delete([]byte(id))
+	})
+}
+
+// agentBucket returns the agent's bucket, creating it if needed.
+func agentBucket(tx *bolt.Tx, agent []byte) (*bolt.Bucket, error) {
+	agents, err := tx.CreateBucketIfNotExists(agentsBucket)
+	if err != nil {
+		return nil, err
+	}
+	return agents.CreateBucketIfNotExists(agent)
+}
+
+// readAgentBucket returns the agent's bucket, or nil if it does not exist.
+func readAgentBucket(tx *bolt.Tx, agent []byte) *bolt.Bucket {
+	agents := tx.Bucket(agentsBucket)
+	if agents == nil {
+		return nil
+	}
+	return agents.Bucket(agent)
+}
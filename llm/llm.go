@@ -0,0 +1,34 @@
+// Package llm holds the chat completion defaults shared by a25's modules.
+package llm
+
+import (
+	"math"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Model returns the chat model, defaulting to GPT-4o mini.
+func Model(model string) string {
+	if model != "" {
+		return model
+	}
+	return openai.GPT4oMini
+}
+
+// Temperature returns the sampling temperature to request, defaulting to 1
+// when t is nil. go-openai leaves a zero temperature out of requests, which
+// the API reads as 1, so zero is sent as the smallest positive temperature.
+func Temperature(t *float32) float32 {
+	switch {
+	case t == nil:
+		return 1
+	case *t == 0:
+		return math.SmallestNonzeroFloat32
+	}
+	return *t
+}
+
+// Temp returns a pointer to t, for setting a module's Temperature.
+func Temp(t float32) *float32 {
+	return &t
+}
@@ -0,0 +1,23 @@
+package llm
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTemperature(t *testing.T) {
+	tests := []struct {
+		t    *float32
+		want float32
+	}{
+		{nil, 1},
+		{Temp(0), math.SmallestNonzeroFloat32},
+		{Temp(0.7), 0.7},
+		{Temp(1.5), 1.5},
+	}
+	for _, tt := range tests {
+		if got := Temperature(tt.t); got != tt.want {
+			t.Errorf("Temperature(%v) = %v, want %v", tt.t, got, tt.want)
+		}
+	}
+}
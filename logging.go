@@ -0,0 +1,34 @@
+package a25
+
+import (
+	"context"
+	"log"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// loggingClient logs every LLM call an agent makes.
+type loggingClient struct {
+	Client OpenAIClient
+	Agent  string
+}
+
+func (c *loggingClient) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error) {
+	resp, err := c.Client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		log.Printf("a25: %s: chat completion with %s failed: %v", c.Agent, req.Model, err)
+		return nil, err
+	}
+	log.Printf("a25: %s: chat completion with %s used %d tokens", c.Agent, req.Model, resp.Usage.TotalTokens)
+	return resp, nil
+}
+
+func (c *loggingClient) CreateEmbeddings(ctx context.Context, conv openai.EmbeddingRequestConverter) (*openai.EmbeddingResponse, error) {
+	resp, err := c.Client.CreateEmbeddings(ctx, conv)
+	if err != nil {
+		log.Printf("a25: %s: embedding failed: %v", c.Agent, err)
+		return nil, err
+	}
+	log.Printf("a25: %s: embedding with %s used %d tokens", c.Agent, resp.Model, resp.Usage.TotalTokens)
+	return resp, nil
+}
@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/lordtatty/a25/save"
+)
+
+// ErrNotFound is returned when no memory has the given ID.
+var ErrNotFound = errors.New("memory not found")
+
+func init() {
+	save.Register(memoriesKind, 1, AssignIDs)
+}
+
+// Archive hides a memory from retrieval without deleting it. The archived
+// memory is kept as a tombstone, including when the stream is persisted.
+func (ms *MemoryStream) Archive(id string) error {
+	return ms.ArchiveContext(context.Background(), id)
+}
+
+// ArchiveContext is Archive under the given context.
+func (ms *MemoryStream) ArchiveContext(ctx context.Context, id string) error {
+	return ms.setArchived(ctx, id, true)
+}
+
+// Restore makes an archived memory retrievable again.
+func (ms *MemoryStream) Restore(id string) error {
+	return ms.RestoreContext(context.Background(), id)
+}
+
+// RestoreContext is Restore under the given context.
+func (ms *MemoryStream) RestoreContext(ctx context.Context, id string) error {
+	return ms.setArchived(ctx, id, false)
+}
+
+// setArchived sets the archived flag of the memory with the given ID. The
+// change is written to the Store without the lock held, and only then made
+// in the stream.
+func (ms *MemoryStream) setArchived(ctx context.Context, id string, archived bool) error {
+	ms.mu.Lock()
+	i := ms.index(id)
+	if i < 0 {
+		ms.mu.Unlock()
+		return ErrNotFound
+	}
+	m := withArchived(ms.memories[i], archived)
+	ms.mu.Unlock()
+	if err := ms.persist(ctx, m); err != nil {
+		return err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if i = ms.index(id); i < 0 {
+		return ErrNotFound
+	}
+	ms.memories[i].Archived = archived
+	ms.version++
+	ms.indexed(ms.memories[i])
+	return nil
+}
+
+// withArchived returns a copy of the memory with the archived flag set.
+func withArchived(m MemoryObject, archived bool) MemoryObject {
+	m.Archived = archived
+	return m
+}
+
+// index returns the position of the memory with the given ID, or -1. It must be
+// called with the lock held.
+func (ms *MemoryStream) index(id string) int {
+	for i := range ms.memories {
+		if ms.memories[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// AssignIDs is the save format migration from version 1, which gives an ID to
+// every memory in a saved list. Memories had no identity before version 2.
+func AssignIDs(data json.RawMessage) (json.RawMessage, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+	var memories []map[string]any
+	if err := json.Unmarshal(data, &memories); err != nil {
+		return nil, err
+	}
+	for _, m := range memories {
+		if id, _ := m["ID"].(string); id == "" {
+			m["ID"] = uuid.NewString()
+		}
+	}
+	return json.Marshal(memories)
+}
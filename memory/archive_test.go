@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// mapStore is an in-memory Store.
+type mapStore map[string]MemoryObject
+
+func (s mapStore) Load() ([]MemoryObject, error) { return nil, nil }
+func (s mapStore) Put(m MemoryObject) error      { s[m.ID] = m; return nil }
+func (s mapStore) Delete(id string) error        { delete(s, id); return nil }
+
+func TestArchiveContext(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		wantErr      error
+		wantArchived bool
+	}{
+		{"archived", context.Background(), nil, true},
+		{"cancelled", cancelled, context.Canceled, false},
+	}
+	for _, tt := range tests {
+		store := mapStore{}
+		ms := NewStream(nil)
+		ms.Store = store
+		ms.SetMemories([]MemoryObject{{ID: "1", Description: "apple"}})
+		if err := ms.ArchiveContext(tt.ctx, "1"); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+		m, _ := ms.GetMemory("1")
+		if m.Archived != tt.wantArchived || store["1"].Archived != tt.wantArchived {
+			t.Errorf("%s: archived in stream %t, in store %t; want %t", tt.name, m.Archived, store["1"].Archived, tt.wantArchived)
+		}
+	}
+	if err := NewStream(nil).Archive("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Archive of a missing memory: error = %v, want ErrNotFound", err)
+	}
+}
+
+// lockCheckingStore is a mapStore recording whether the stream's lock was held
+// during any write.
+type lockCheckingStore struct {
+	mapStore
+	ms     *MemoryStream
+	locked bool
+}
+
+func (s *lockCheckingStore) Put(m MemoryObject) error {
+	if s.ms.mu.TryLock() {
+		s.ms.mu.Unlock()
+	} else {
+		s.locked = true
+	}
+	return s.mapStore.Put(m)
+}
+
+func TestStoreWritesWithoutLock(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(ms *MemoryStream) error
+	}{
+		{"archive", func(ms *MemoryStream) error { return ms.Archive("1") }},
+		{"restore", func(ms *MemoryStream) error { return ms.Restore("1") }},
+		{"backfill", func(ms *MemoryStream) error {
+			_, err := ms.Backfill(1)
+			return err
+		}},
+		{"migrate", func(ms *MemoryStream) error { return ms.Migrate(wordEmbedder{}, 1, nil) }},
+	}
+	for _, tt := range tests {
+		ms := NewStream(nil)
+		ms.Embedder = wordEmbedder{}
+		store := &lockCheckingStore{mapStore: mapStore{}, ms: ms}
+		ms.Store = store
+		ms.SetMemories([]MemoryObject{{ID: "1", Description: "apple"}, {ID: "2", Description: "banana"}})
+		if err := tt.run(ms); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(store.mapStore) == 0 {
+			t.Errorf("%s: nothing was stored", tt.name)
+		}
+		if store.locked {
+			t.Errorf("%s: wrote to the store with the stream's lock held", tt.name)
+		}
+	}
+}
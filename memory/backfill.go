@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+)
+
+// Backfill embeds, in batches, every memory stored without an embedding, such
+// as memories saved before embeddings were kept or imported without them, and
+// returns the number embedded. Retrieval embeds any such memories on first use
+// as well, but backfilling up front keeps the first queries fast.
+func (ms *MemoryStream) Backfill(batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	return ms.backfill(context.Background(), 0, batchSize)
+}
+
+// backfill embeds the memories stored without an embedding or, if dim is
+// positive, with an embedding of a different length, in requests of up to
+// batchSize memories, or one request if it is not positive. It returns the
+// number embedded. The lock is not held while embedding, so requests don't
+// hold up other use of the stream.
+func (ms *MemoryStream) backfill(ctx context.Context, dim, batchSize int) (int, error) {
+	ms.mu.Lock()
+	missing := ms.unembedded(dim)
+	ms.mu.Unlock()
+	if batchSize <= 0 {
+		batchSize = max(len(missing), 1)
+	}
+	for lo := 0; lo < len(missing); lo += batchSize {
+		if err := ms.embedStored(ctx, missing[lo:min(lo+batchSize, len(missing))]); err != nil {
+			return lo, err
+		}
+	}
+	return len(missing), nil
+}
+
+// unembedded returns the memories without an embedding or, if dim is
+// positive, with an embedding of a different length. It must be called with
+// the lock held.
+func (ms *MemoryStream) unembedded(dim int) []MemoryObject {
+	var missing []MemoryObject
+	for _, m := range ms.memories {
+		if len(m.Embedding) == 0 || (dim > 0 && len(m.Embedding) != dim) {
+			missing = append(missing, m)
+		}
+	}
+	return missing
+}
+
+// embedStored embeds the given stored memories in one request and stores
+// their embeddings, skipping any removed or re-described meanwhile. It must be
+// called without the lock held; the Store is written to after releasing it.
+func (ms *MemoryStream) embedStored(ctx context.Context, memories []MemoryObject) error {
+	if len(memories) == 0 {
+		return nil
+	}
+	texts := make([]string, len(memories))
+	for i, m := range memories {
+		texts[i] = m.Description
+	}
+	embeddings, err := embedContext(ctx, ms.embedder(), texts)
+	if err != nil {
+		return fmt.Errorf("failed to embed memories: %w", err)
+	}
+	if len(embeddings) != len(memories) {
+		return fmt.Errorf("expected %d embeddings but got %d", len(memories), len(embeddings))
+	}
+	for j, e := range embeddings {
+		if len(e) == 0 {
+			return fmt.Errorf("no embedding returned for memory %s", memories[j].ID)
+		}
+	}
+	ms.mu.Lock()
+	var updated []MemoryObject
+	for j, e := range embeddings {
+		i := ms.index(memories[j].ID)
+		if i < 0 || ms.memories[i].Description != memories[j].Description {
+			continue
+		}
+		m := &ms.memories[i]
+		m.Embedding, m.Norm = normalize(e)
+		ms.indexed(*m)
+		updated = append(updated, *m)
+	}
+	ms.version++
+	ms.mu.Unlock()
+	for _, m := range updated {
+		if err := ms.persist(ctx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
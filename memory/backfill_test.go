@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"testing"
+	"time"
+)
+
+// lockCheckingEmbedder embeds like wordEmbedder, recording whether the
+// stream's lock was held during any request.
+type lockCheckingEmbedder struct {
+	ms     *MemoryStream
+	locked bool
+}
+
+func (e *lockCheckingEmbedder) Embed(texts []string) ([][]float32, error) {
+	if e.ms.mu.TryLock() {
+		e.ms.mu.Unlock()
+	} else {
+		e.locked = true
+	}
+	return wordEmbedder{}.Embed(texts)
+}
+
+func TestRetrieveBackfillsWithoutLock(t *testing.T) {
+	ms := NewStream(nil)
+	embedder := &lockCheckingEmbedder{ms: ms}
+	ms.Embedder = embedder
+	now := time.Now()
+	ms.SetMemories([]MemoryObject{
+		{ID: "1", Description: "Maria is at the cafe", Importance: 3, CreationTime: now, LastAccessedTime: now},
+		{ID: "2", Description: "Klaus is reading", Importance: 3, CreationTime: now, LastAccessedTime: now},
+	})
+	retrieved, err := ms.RetrieveMemories("Maria")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if embedder.locked {
+		t.Error("embedded with the stream's lock held")
+	}
+	if len(retrieved) == 0 || retrieved[0].Memory.ID != "1" {
+		t.Errorf("retrieved %v, want memory 1 first", retrieved)
+	}
+	for _, m := range ms.Memories() {
+		if len(m.Embedding) == 0 {
+			t.Errorf("memory %s was not backfilled", m.ID)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// AddAll adds several memories at once, embedding them in batched requests sent
+// concurrently by EmbedWorkers and rating the importance of those without one
+// in a single language model call, rather than one call of each per memory.
+// This suits bulk imports and perception-heavy ticks where an agent takes in
+// many observations together.
+func (ms *MemoryStream) AddAll(memories []MemoryObject) error {
+	return ms.AddAllContext(context.Background(), memories)
+}
+
+// AddAllContext is AddAll under the given context.
+func (ms *MemoryStream) AddAllContext(ctx context.Context, memories []MemoryObject) error {
+	if len(memories) == 0 {
+		return nil
+	}
+	memories = slices.Clone(memories)
+	// Memories given an embedding keep it unless redaction changes them.
+	var texts []string
+	var unembedded []int
+	for i, m := range memories {
+		if err := ms.adding(&m); err != nil {
+			return fmt.Errorf("memory rejected: %w", err)
+		}
+		description := m.Description
+		m, err := ms.redact(ctx, m)
+		if err != nil {
+			return err
+		}
+		if len(m.Embedding) > 0 && m.Description == description {
+			if err := ms.checkDimension(m.Embedding); err != nil {
+				return err
+			}
+			m.Embedding, m.Norm = normalize(m.Embedding)
+		} else {
+			unembedded = append(unembedded, i)
+			texts = append(texts, m.Description)
+		}
+		memories[i] = m
+	}
+	if len(texts) > 0 {
+		embeddings, err := ms.embedAll(ctx, texts)
+		if err != nil {
+			return err
+		}
+		for j, i := range unembedded {
+			memories[i].Embedding, memories[i].Norm = normalize(embeddings[j])
+		}
+	}
+	// Duplicates of earlier memories, stored or in the batch, are merged before
+	// anything is rated, and memories given an importance are not rated.
+	var unique []MemoryObject
+	var unrated []int
+	texts = texts[:0]
+	for _, m := range memories {
+		merged, err := ms.merge(ctx, m)
+		if err != nil {
+			return err
+		}
+		if merged {
+			continue
+		}
+		if i := ms.batchDuplicateOf(m, unique); i >= 0 {
+			unique[i].Recurrences += m.Recurrences + 1
+			continue
+		}
+		if m.Importance == 0 {
+			unrated = append(unrated, len(unique))
+			texts = append(texts, m.Description)
+		}
+		unique = append(unique, m)
+	}
+	if len(texts) > 0 {
+		ratings, err := ms.RateImportancesContext(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("failed to rate importance: %w", err)
+		}
+		for j, i := range unrated {
+			unique[i].Importance = ratings[j]
+		}
+	}
+	for _, m := range unique {
+		if err := ms.insert(ctx, m); err != nil {
+			return err
+		}
+	}
+	return ms.enforceCapacity()
+}
@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// countingEmbedder embeds like wordEmbedder, recording the size of each request.
+type countingEmbedder struct {
+	mu       sync.Mutex
+	requests []int
+}
+
+func (e *countingEmbedder) Embed(texts []string) ([][]float32, error) {
+	e.mu.Lock()
+	e.requests = append(e.requests, len(texts))
+	e.mu.Unlock()
+	return wordEmbedder{}.Embed(texts)
+}
+
+func TestAddAllBatchesEmbeddings(t *testing.T) {
+	given := make([]float32, 26)
+	given[1] = 1
+	tests := []struct {
+		name     string
+		n        int
+		given    int // How many of the memories come with an embedding.
+		requests []int
+	}{
+		{"one request", 40, 0, []int{40}},
+		{"split into batches", 250, 0, []int{50, 100, 100}},
+		{"given embeddings reused", 40, 10, []int{30}},
+		{"all given", 5, 5, nil},
+	}
+	for _, tt := range tests {
+		embedder := &countingEmbedder{}
+		ms := NewStream(nil)
+		ms.Embedder = embedder
+		ms.Rater = StaticRater(3)
+		memories := make([]MemoryObject, tt.n)
+		for i := range memories {
+			memories[i].Description = fmt.Sprintf("memory %d", i)
+			if i < tt.given {
+				memories[i].Embedding = given
+			}
+		}
+		if err := ms.AddAll(memories); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		slices.Sort(embedder.requests)
+		if !slices.Equal(embedder.requests, tt.requests) {
+			t.Errorf("%s: embedding requests of %v texts, want %v", tt.name, embedder.requests, tt.requests)
+		}
+		stored := ms.Memories()
+		if len(stored) != tt.n {
+			t.Fatalf("%s: stored %d memories, want %d", tt.name, len(stored), tt.n)
+		}
+		for i, m := range stored {
+			if got, want := m.Embedding[1] == 1, i < tt.given; got != want {
+				t.Errorf("%s: memory %d has its given embedding: %t, want %t", tt.name, i, got, want)
+			}
+		}
+	}
+}
+
+func TestBatchingEmbedderCoalescesCalls(t *testing.T) {
+	const callers = 8
+	embedder := &countingEmbedder{}
+	b := &BatchingEmbedder{Embedder: embedder, MaxBatch: callers}
+	texts := []string{"apple", "banana", "cherry", "date", "elderberry", "fig", "grape", "honeydew"}
+	got := make([][][]float32, callers)
+	var wg sync.WaitGroup
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e, err := b.Embed(texts[i : i+1])
+			if err != nil {
+				t.Error(err)
+			}
+			got[i] = e
+		}()
+	}
+	wg.Wait()
+	if !slices.Equal(embedder.requests, []int{callers}) {
+		t.Errorf("embedding requests of %v texts, want one of %d", embedder.requests, callers)
+	}
+	for i, e := range got {
+		if len(e) != 1 || e[0][texts[i][0]-'a'] != 1 {
+			t.Errorf("caller %d got %v, not the embedding of %q", i, e, texts[i])
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BatchingEmbedder coalesces concurrent Embed calls, such as many agents adding
+// memories in the same tick, into a single request to the underlying embedder.
+// Calls wait up to Window for others to join before the batch is sent, or
+// until MaxBatch texts are pending.
+type BatchingEmbedder struct {
+	Embedder Embedder
+	MaxBatch int           // Defaults to 100.
+	Window   time.Duration // Defaults to 10ms.
+
+	mu      sync.Mutex
+	pending []*embedCall
+	size    int
+	timer   *time.Timer
+}
+
+// embedCall is an Embed call waiting to be batched.
+type embedCall struct {
+	texts      []string
+	embeddings [][]float32
+	err        error
+	done       chan struct{}
+}
+
+// Embed queues the texts for the next batch and waits for their embeddings.
+func (b *BatchingEmbedder) Embed(texts []string) ([][]float32, error) {
+	return b.EmbedContext(context.Background(), texts)
+}
+
+// EmbedContext is Embed, but stops waiting when the context ends. The texts
+// are still sent with the rest of their batch.
+func (b *BatchingEmbedder) EmbedContext(ctx context.Context, texts []string) ([][]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	call := &embedCall{texts: texts, done: make(chan struct{})}
+	b.mu.Lock()
+	b.pending = append(b.pending, call)
+	b.size += len(texts)
+	if b.size >= b.maxBatch() {
+		batch := b.take()
+		b.mu.Unlock()
+		b.send(batch)
+	} else {
+		if b.timer == nil {
+			b.timer = time.AfterFunc(b.window(), b.flush)
+		}
+		b.mu.Unlock()
+	}
+	select {
+	case <-call.done:
+		return call.embeddings, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// flush sends whatever is pending.
+func (b *BatchingEmbedder) flush() {
+	b.mu.Lock()
+	batch := b.take()
+	b.mu.Unlock()
+	b.send(batch)
+}
+
+// take removes the pending calls. It must be called with the lock held.
+func (b *BatchingEmbedder) take() []*embedCall {
+	batch := b.pending
+	b.pending = nil
+	b.size = 0
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	return batch
+}
+
+// send embeds the texts of every call in one request and hands each call its
+// share of the results.
+func (b *BatchingEmbedder) send(batch []*embedCall) {
+	if len(batch) == 0 {
+		return
+	}
+	var texts []string
+	for _, c := range batch {
+		texts = append(texts, c.texts...)
+	}
+	embeddings, err := b.Embedder.Embed(texts)
+	if err == nil && len(embeddings) != len(texts) {
+		err = fmt.Errorf("expected %d embeddings but got %d", len(texts), len(embeddings))
+	}
+	offset := 0
+	for _, c := range batch {
+		if err != nil {
+			c.err = err
+		} else {
+			c.embeddings = embeddings[offset : offset+len(c.texts)]
+		}
+		offset += len(c.texts)
+		close(c.done)
+	}
+}
+
+// maxBatch returns the batch size that triggers an immediate send.
+func (b *BatchingEmbedder) maxBatch() int {
+	if b.MaxBatch > 0 {
+		return b.MaxBatch
+	}
+	return defaultBatchSize
+}
+
+// window returns how long calls wait for others to join a batch.
+func (b *BatchingEmbedder) window() time.Duration {
+	if b.Window > 0 {
+		return b.Window
+	}
+	return 10 * time.Millisecond
+}
@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"hash/fnv"
+	"slices"
+	"strings"
+)
+
+// cacheKey identifies a retrieval by its query and the state of the stream it
+// ran against: its version and how many times memories had been accessed.
+type cacheKey struct {
+	query    uint64
+	version  uint64
+	accesses uint64
+}
+
+// hashQuery hashes a query after normalising case and whitespace, so trivially
+// different phrasings of the same query share a cache entry.
+func hashQuery(query string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(strings.Join(strings.Fields(strings.ToLower(query)), " ")))
+	return h.Sum64()
+}
+
+// cached returns the cached results for a query, if any.
+func (ms *MemoryStream) cached(query string) ([]RetrievedMemory, bool) {
+	if !ms.CacheRetrievals {
+		return nil, false
+	}
+	r, ok := ms.cache[cacheKey{hashQuery(query), ms.version, ms.accesses}]
+	return slices.Clone(r), ok
+}
+
+// store caches the results for a query against the current stream version.
+func (ms *MemoryStream) store(query string, r []RetrievedMemory) {
+	if !ms.CacheRetrievals {
+		return
+	}
+	if ms.cache == nil {
+		ms.cache = make(map[cacheKey][]RetrievedMemory)
+	}
+	ms.cache[cacheKey{hashQuery(query), ms.version, ms.accesses}] = slices.Clone(r)
+}
+
+// ResetCache clears cached retrieval results. Call it at the start of each
+// simulation tick so recency scores are recomputed.
+func (ms *MemoryStream) ResetCache() {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	clear(ms.cache)
+}
@@ -0,0 +1,36 @@
+package memory
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestResetCacheDuringRetrieval(t *testing.T) {
+	ms := NewStream(nil)
+	ms.Embedder = wordEmbedder{}
+	ms.CacheRetrievals = true
+	ms.SetMemories([]MemoryObject{
+		{ID: "1", Description: "apple"},
+		{ID: "2", Description: "banana"},
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if _, err := ms.RetrieveMemories("apple"); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				ms.ResetCache()
+			}
+		}()
+	}
+	wg.Wait()
+}
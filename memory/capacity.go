@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Evictor frees room in a stream that holds more memories than its Capacity.
+type Evictor interface {
+	// Evict removes at least n memories from the stream, if it can.
+	Evict(ms *MemoryStream, n int) error
+}
+
+// EvictLRU evicts the least recently accessed memories.
+type EvictLRU struct{}
+
+// Evict removes the n memories accessed longest ago.
+func (EvictLRU) Evict(ms *MemoryStream, n int) error {
+	return ms.evictBy(n, func(a, b MemoryObject) int {
+		return a.LastAccessedTime.Compare(b.LastAccessedTime)
+	})
+}
+
+// EvictLeastImportant evicts the least important memories, oldest first.
+type EvictLeastImportant struct{}
+
+// Evict removes the n least important memories.
+func (EvictLeastImportant) Evict(ms *MemoryStream, n int) error {
+	return ms.evictBy(n, func(a, b MemoryObject) int {
+		if a.Importance != b.Importance {
+			if a.Importance < b.Importance {
+				return -1
+			}
+			return 1
+		}
+		return a.LastAccessedTime.Compare(b.LastAccessedTime)
+	})
+}
+
+// ConsolidateThenEvict first consolidates memories older than Age into
+// summaries, then evicts with Then if the stream is still over capacity.
+type ConsolidateThenEvict struct {
+	Age     time.Duration      // Memories older than this are consolidated; defaults to a day.
+	Options ConsolidateOptions // Before is set from Age.
+	Then    Evictor            // Defaults to EvictLRU.
+}
+
+// Evict consolidates old memories and evicts any excess that remains.
+func (c ConsolidateThenEvict) Evict(ms *MemoryStream, n int) error {
+	age := c.Age
+	if age <= 0 {
+		age = 24 * time.Hour
+	}
+	opts := c.Options
+	opts.Before = ms.now().Add(-age)
+	if _, err := ms.Consolidate(opts); err != nil {
+		return err
+	}
+	then := c.Then
+	if then == nil {
+		then = EvictLRU{}
+	}
+	if excess := ms.excess(); excess > 0 {
+		return then.Evict(ms, excess)
+	}
+	return nil
+}
+
+// excess returns how many memories the stream holds beyond its Capacity.
+func (ms *MemoryStream) excess() int {
+	if ms.Capacity <= 0 {
+		return 0
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return max(len(ms.memories)-ms.Capacity, 0)
+}
+
+// enforceCapacity evicts memories while the stream is over its Capacity.
+func (ms *MemoryStream) enforceCapacity() error {
+	excess := ms.excess()
+	if excess == 0 {
+		return nil
+	}
+	evictor := ms.Eviction
+	if evictor == nil {
+		evictor = EvictLRU{}
+	}
+	if err := evictor.Evict(ms, excess); err != nil {
+		return fmt.Errorf("failed to evict memories: %w", err)
+	}
+	return nil
+}
+
+// evictBy removes the first n memories in the order given by cmp.
+func (ms *MemoryStream) evictBy(n int, cmp func(a, b MemoryObject) int) error {
+	ms.mu.Lock()
+	sorted := slices.Clone(ms.memories)
+	slices.SortStableFunc(sorted, cmp)
+	victims := sorted[:min(n, len(sorted))]
+	ids := make(map[string]bool, n)
+	for _, m := range victims {
+		ids[m.ID] = true
+	}
+	err := ms.remove(ids)
+	ms.mu.Unlock()
+	ms.evicted(victims)
+	return err
+}
+
+// remove deletes the memories with the given IDs from the stream, its Index
+// and its Store. It must be called with the lock held.
+func (ms *MemoryStream) remove(ids map[string]bool) error {
+	ms.memories = slices.DeleteFunc(ms.memories, func(m MemoryObject) bool {
+		return ids[m.ID]
+	})
+	ms.version++
+	for id := range ids {
+		ms.unindexed(id)
+		if ms.Store != nil {
+			if err := ms.Store.Delete(id); err != nil {
+				return fmt.Errorf("failed to delete memory: %w", err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// ConsolidateOptions control which memories Consolidate merges.
+type ConsolidateOptions struct {
+	Before time.Time     // Only memories created before this time are consolidated.
+	Window time.Duration // Memories are grouped into windows of this length; defaults to a day.
+	// Similarity, if positive, further splits each window into topics: a memory
+	// joins the first group whose founding memory it is at least this similar to.
+	Similarity float32
+	MinGroup   int // Smallest group worth summarising; defaults to 2.
+}
+
+// Consolidate shrinks the stream by replacing groups of old memories with a
+// single summary written by the language model. Memories are grouped by
+// creation time window and, optionally, by topic. Each summary keeps the
+// highest importance of the memories it replaces. Archived memories and
+// earlier summaries are left alone. It returns the number of memories replaced.
+func (ms *MemoryStream) Consolidate(opts ConsolidateOptions) (int, error) {
+	return ms.ConsolidateContext(context.Background(), opts)
+}
+
+// ConsolidateContext is Consolidate under the given context.
+func (ms *MemoryStream) ConsolidateContext(ctx context.Context, opts ConsolidateOptions) (int, error) {
+	if opts.Window <= 0 {
+		opts.Window = 24 * time.Hour
+	}
+	if opts.MinGroup < 2 {
+		opts.MinGroup = 2
+	}
+
+	ms.mu.Lock()
+	var old []MemoryObject
+	for _, m := range ms.memories {
+		if !m.Archived && m.Kind != Summary && m.CreationTime.Before(opts.Before) {
+			old = append(old, m)
+		}
+	}
+	ms.mu.Unlock()
+
+	replaced := 0
+	for _, group := range groupMemories(old, opts) {
+		if len(group) < opts.MinGroup {
+			continue
+		}
+		summary, err := ms.summarize(ctx, group)
+		if err != nil {
+			return replaced, fmt.Errorf("failed to summarise memories: %w", err)
+		}
+		if err := ms.replace(ctx, group, summary); err != nil {
+			return replaced, err
+		}
+		replaced += len(group)
+	}
+	return replaced, nil
+}
+
+// groupMemories splits memories into time windows and, if a similarity
+// threshold is set, into topics within each window.
+func groupMemories(memories []MemoryObject, opts ConsolidateOptions) [][]MemoryObject {
+	windows := make(map[int64][]MemoryObject)
+	var keys []int64
+	for _, m := range memories {
+		k := m.CreationTime.UnixNano() / int64(opts.Window)
+		if _, ok := windows[k]; !ok {
+			keys = append(keys, k)
+		}
+		windows[k] = append(windows[k], m)
+	}
+	slices.Sort(keys)
+
+	var groups [][]MemoryObject
+	for _, k := range keys {
+		if opts.Similarity <= 0 {
+			groups = append(groups, windows[k])
+			continue
+		}
+		var topics [][]MemoryObject
+	next:
+		for _, m := range windows[k] {
+			for i, t := range topics {
+				if len(t[0].Embedding) == len(m.Embedding) && dot(t[0].Embedding, m.Embedding) >= opts.Similarity {
+					topics[i] = append(t, m)
+					continue next
+				}
+			}
+			topics = append(topics, []MemoryObject{m})
+		}
+		groups = append(groups, topics...)
+	}
+	return groups
+}
+
+// summarize writes a summary memory for a group of memories.
+func (ms *MemoryStream) summarize(ctx context.Context, group []MemoryObject) (MemoryObject, error) {
+	sysPrompt := "Summarise the following memories into a single memory, written in the same voice, that keeps the people, places, events and feelings most worth remembering.  Output the summary only, in one or two sentences."
+	var lines []string
+	summary := MemoryObject{Kind: Summary, CreationTime: group[0].CreationTime}
+	for _, m := range group {
+		lines = append(lines, fmt.Sprintf("- %s: %s", m.CreationTime.Format("January 2 3:04 PM"), m.Description))
+		summary.Importance = max(summary.Importance, m.Importance)
+		if m.CreationTime.After(summary.CreationTime) {
+			summary.CreationTime = m.CreationTime
+		}
+		if m.LastAccessedTime.After(summary.LastAccessedTime) {
+			summary.LastAccessedTime = m.LastAccessedTime
+		}
+	}
+	resp, err := ms.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: ms.model(),
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: sysPrompt},
+			{Role: "user", Content: strings.Join(lines, "\n")},
+		},
+		Temperature: ms.temperature(),
+	})
+	if err != nil {
+		return summary, err
+	}
+	summary.Description = strings.TrimSpace(resp.Choices[0].Message.Content)
+	embedding, err := ms.embed(ctx, summary.Description)
+	if err != nil {
+		return summary, fmt.Errorf("failed to get embedding: %w", err)
+	}
+	summary.Embedding, summary.Norm = normalize(embedding)
+	return summary, nil
+}
+
+// replace removes the group's memories from the stream and adds the summary.
+func (ms *MemoryStream) replace(ctx context.Context, group []MemoryObject, summary MemoryObject) error {
+	if err := ms.insert(ctx, summary); err != nil {
+		return err
+	}
+	ids := make(map[string]bool, len(group))
+	for _, m := range group {
+		ids[m.ID] = true
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.remove(ids)
+}
@@ -0,0 +1,138 @@
+package memory
+
+import (
+	"context"
+	"time"
+)
+
+// defaultDedupWindow is how far back Add looks for duplicates by default.
+const defaultDedupWindow = time.Hour
+
+// dedupWindow returns how far back to look for duplicates.
+func (ms *MemoryStream) dedupWindow() time.Duration {
+	if ms.DedupWindow > 0 {
+		return ms.DedupWindow
+	}
+	return defaultDedupWindow
+}
+
+// duplicateOf returns the position of a recent memory the embedded memory
+// duplicates, or -1. Memories are not always in creation order, as when
+// imported, so all are checked. It must be called with the lock held.
+func (ms *MemoryStream) duplicateOf(m MemoryObject, now time.Time) int {
+	if ms.DedupThreshold <= 0 || len(m.Embedding) == 0 {
+		return -1
+	}
+	since := now.Add(-ms.dedupWindow())
+	for i := len(ms.memories) - 1; i >= 0; i-- {
+		old := ms.memories[i]
+		if !old.CreationTime.Before(since) && ms.similar(old, m) {
+			return i
+		}
+	}
+	return -1
+}
+
+// similar reports whether two unarchived memories of the same kind have
+// embeddings at least DedupThreshold similar.
+func (ms *MemoryStream) similar(a, b MemoryObject) bool {
+	return !a.Archived && !b.Archived && a.Kind == b.Kind && len(a.Embedding) > 0 &&
+		len(a.Embedding) == len(b.Embedding) && dot(a.Embedding, b.Embedding) >= ms.DedupThreshold
+}
+
+// batchDuplicateOf returns the position among the memories of an earlier one
+// in the same batch that the embedded memory duplicates, or -1.
+func (ms *MemoryStream) batchDuplicateOf(m MemoryObject, memories []MemoryObject) int {
+	if ms.DedupThreshold <= 0 {
+		return -1
+	}
+	for i, old := range memories {
+		if !old.CreationTime.IsZero() && !m.CreationTime.IsZero() && m.CreationTime.Sub(old.CreationTime).Abs() > ms.dedupWindow() {
+			continue
+		}
+		if ms.similar(old, m) {
+			return i
+		}
+	}
+	return -1
+}
+
+// merge records that the embedded memory recurred instead of storing it,
+// reporting whether a duplicate was found.
+func (ms *MemoryStream) merge(ctx context.Context, m MemoryObject) (bool, error) {
+	now := ms.now()
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	i := ms.duplicateOf(m, now)
+	if i < 0 {
+		return false, nil
+	}
+	merged := ms.memories[i]
+	merged.Recurrences++
+	merged.LastAccessedTime = now
+	if err := ms.persist(ctx, merged); err != nil {
+		return false, err
+	}
+	ms.memories[i] = merged
+	ms.version++
+	return true, nil
+}
+
+// Deduplicate merges near-duplicate memories already in the stream: a memory at
+// least DedupThreshold similar to an earlier one of the same kind created
+// within DedupWindow before it is removed, and the earlier memory's Recurrences
+// is increased. It returns the number of memories removed.
+func (ms *MemoryStream) Deduplicate() (int, error) {
+	return ms.DeduplicateContext(context.Background())
+}
+
+// DeduplicateContext is Deduplicate under the given context.
+func (ms *MemoryStream) DeduplicateContext(ctx context.Context) (int, error) {
+	if ms.DedupThreshold <= 0 {
+		return 0, nil
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	window := ms.dedupWindow()
+	removed := make(map[string]bool)
+	var changed []int
+	// Memories are not always in creation order, so every pair is checked;
+	// of two created at once, the one stored first is the original.
+	for j := range ms.memories {
+		dup := ms.memories[j]
+		if dup.Archived || len(dup.Embedding) == 0 {
+			continue
+		}
+		for i := range ms.memories {
+			orig := &ms.memories[i]
+			age := dup.CreationTime.Sub(orig.CreationTime)
+			if i == j || age < 0 || (age == 0 && i > j) || age > window || removed[orig.ID] {
+				continue
+			}
+			if ms.similar(*orig, dup) {
+				orig.Recurrences += dup.Recurrences + 1
+				if dup.LastAccessedTime.After(orig.LastAccessedTime) {
+					orig.LastAccessedTime = dup.LastAccessedTime
+				}
+				removed[dup.ID] = true
+				changed = append(changed, i)
+				break
+			}
+		}
+	}
+	if len(removed) == 0 {
+		return 0, nil
+	}
+	for _, i := range changed {
+		if removed[ms.memories[i].ID] {
+			continue
+		}
+		if err := ms.persist(ctx, ms.memories[i]); err != nil {
+			return 0, err
+		}
+	}
+	if err := ms.remove(removed); err != nil {
+		return 0, err
+	}
+	return len(removed), nil
+}
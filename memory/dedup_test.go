@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"testing"
+	"time"
+)
+
+// wordEmbedder embeds each text as a one-hot vector of its first letter, so
+// texts sharing a first letter are duplicates.
+type wordEmbedder struct{}
+
+func (wordEmbedder) Embed(texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, t := range texts {
+		embeddings[i] = make([]float32, 26)
+		embeddings[i][(t[0]|0x20)-'a'] = 1
+	}
+	return embeddings, nil
+}
+
+func TestDuplicateOf(t *testing.T) {
+	now := time.Date(2024, 2, 14, 12, 0, 0, 0, time.UTC)
+	a := []float32{1, 0}
+	b := []float32{0, 1}
+	// The stream is out of creation order, as after an import.
+	memories := []MemoryObject{
+		{ID: "recent", Embedding: a, CreationTime: now.Add(-10 * time.Minute)},
+		{ID: "old", Embedding: b, CreationTime: now.Add(-2 * time.Hour)},
+		{ID: "reflection", Kind: Reflection, Embedding: b, CreationTime: now.Add(-5 * time.Minute)},
+	}
+	tests := []struct {
+		name string
+		m    MemoryObject
+		want int
+	}{
+		{"duplicate behind an older memory", MemoryObject{Embedding: a}, 0},
+		{"outside the window", MemoryObject{Embedding: b}, -1},
+		{"different kind", MemoryObject{Kind: Reflection, Embedding: b}, 2},
+		{"no embedding", MemoryObject{}, -1},
+	}
+	ms := MemoryStream{DedupThreshold: 0.9}
+	ms.SetMemories(memories)
+	for _, tt := range tests {
+		if got := ms.duplicateOf(tt.m, now); got != tt.want {
+			t.Errorf("%s: duplicateOf = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestImportanceSince(t *testing.T) {
+	now := time.Date(2024, 2, 14, 12, 0, 0, 0, time.UTC)
+	var ms MemoryStream
+	ms.SetMemories([]MemoryObject{
+		{Importance: 4, CreationTime: now.Add(-time.Minute)},
+		{Importance: 8, CreationTime: now.Add(-time.Hour)},
+		{Importance: 2, CreationTime: now.Add(-2 * time.Minute)},
+		{Importance: 9, Kind: Reflection, CreationTime: now.Add(-time.Minute)},
+		{Importance: 5, Archived: true, CreationTime: now.Add(-time.Minute)},
+	})
+	if got := ms.ImportanceSince(now.Add(-30 * time.Minute)); got != 6 {
+		t.Errorf("ImportanceSince = %v, want 6", got)
+	}
+}
+
+func TestAddAllMergesDuplicatesInBatch(t *testing.T) {
+	ms := NewStream(nil)
+	ms.Embedder = wordEmbedder{}
+	ms.Rater = StaticRater(3)
+	ms.DedupThreshold = 0.9
+	err := ms.AddAll([]MemoryObject{
+		{Description: "Maria is at the cafe"},
+		{Description: "Klaus is reading"},
+		{Description: "Maria is still at the cafe"},
+		{Description: "Maria is at the cafe again"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	memories := ms.Memories()
+	if len(memories) != 2 {
+		t.Fatalf("stored %d memories, want 2", len(memories))
+	}
+	if memories[0].Recurrences != 2 || memories[1].Recurrences != 0 {
+		t.Errorf("Recurrences = %d, %d; want 2, 0", memories[0].Recurrences, memories[1].Recurrences)
+	}
+}
+
+func TestDeduplicateOutOfOrder(t *testing.T) {
+	now := time.Date(2024, 2, 14, 12, 0, 0, 0, time.UTC)
+	a := []float32{1, 0}
+	ms := MemoryStream{DedupThreshold: 0.9}
+	ms.SetMemories([]MemoryObject{
+		{ID: "later", Embedding: a, CreationTime: now},
+		{ID: "other", Embedding: []float32{0, 1}, CreationTime: now},
+		{ID: "earlier", Embedding: a, CreationTime: now.Add(-10 * time.Minute)},
+	})
+	removed, err := ms.Deduplicate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 {
+		t.Fatalf("removed %d memories, want 1", removed)
+	}
+	m, err := ms.GetMemory("earlier")
+	if err != nil {
+		t.Fatalf("the earlier memory was removed: %v", err)
+	}
+	if m.Recurrences != 1 {
+		t.Errorf("Recurrences = %d, want 1", m.Recurrences)
+	}
+}
@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// defaultBatchSize is the number of texts embedded per request when embedding
+// in bulk.
+const defaultBatchSize = 100
+
+// Embedder turns texts into embedding vectors.
+type Embedder interface {
+	Embed(texts []string) ([][]float32, error)
+}
+
+// ContextEmbedder is an Embedder whose requests can be cancelled with a context.
+type ContextEmbedder interface {
+	Embedder
+	EmbedContext(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// embedContext embeds the texts with e, under ctx if e supports it.
+func embedContext(ctx context.Context, e Embedder, texts []string) ([][]float32, error) {
+	if ce, ok := e.(ContextEmbedder); ok {
+		return ce.EmbedContext(ctx, texts)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return e.Embed(texts)
+}
+
+// OpenAIEmbedder embeds texts with an OpenAI embedding model.
+type OpenAIEmbedder struct {
+	Client     OpenAIClient
+	Model      openai.EmbeddingModel // Defaults to text-embedding-3-small.
+	Dimensions int                   // Shortens embeddings to this length, if set; text-embedding-3 models only.
+}
+
+// Embed retrieves the embedding vectors for the texts in one request.
+func (e *OpenAIEmbedder) Embed(texts []string) ([][]float32, error) {
+	return e.EmbedContext(context.Background(), texts)
+}
+
+// EmbedContext is Embed under the given context.
+func (e *OpenAIEmbedder) EmbedContext(ctx context.Context, texts []string) ([][]float32, error) {
+	model := e.Model
+	if model == "" {
+		model = openai.SmallEmbedding3
+	}
+	resp, err := e.Client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Input:      texts,
+		Model:      model,
+		Dimensions: e.Dimensions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	embeddings := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index >= 0 && d.Index < len(texts) {
+			embeddings[d.Index] = d.Embedding
+		}
+	}
+	return embeddings, nil
+}
+
+// embedder returns the stream's embedder.
+func (ms *MemoryStream) embedder() Embedder {
+	if ms.Embedder != nil {
+		return ms.Embedder
+	}
+	return &OpenAIEmbedder{Client: ms.Client, Model: ms.EmbeddingModel, Dimensions: ms.EmbeddingDimensions}
+}
+
+// embed retrieves the embedding vector for a single text.
+func (ms *MemoryStream) embed(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := embedContext(ctx, ms.embedder(), []string{text})
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) == 0 || len(embeddings[0]) == 0 {
+		return nil, errors.New("no embedding returned")
+	}
+	if err := ms.checkDimension(embeddings[0]); err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// ErrDimensionMismatch is returned when an embedding's length differs from the
+// embeddings already in the stream, such as after switching embedding models
+// without Migrate.
+var ErrDimensionMismatch = errors.New("embedding dimension mismatch")
+
+// Dimension returns the length of the embeddings stored in the stream, or zero
+// if none are.
+func (ms *MemoryStream) Dimension() int {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.dimension()
+}
+
+// dimension returns the length of the most recently stored embedding. It must
+// be called with the lock held.
+func (ms *MemoryStream) dimension() int {
+	for i := len(ms.memories) - 1; i >= 0; i-- {
+		if n := len(ms.memories[i].Embedding); n > 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+// checkDimension refuses an embedding whose length differs from the stream's,
+// unless ReembedOnMismatch is set.
+func (ms *MemoryStream) checkDimension(e []float32) error {
+	if ms.ReembedOnMismatch {
+		return nil
+	}
+	if dim := ms.Dimension(); dim != 0 && len(e) != dim {
+		return fmt.Errorf("%w: got %d, stream has %d", ErrDimensionMismatch, len(e), dim)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lordtatty/a25/ratelimit"
+	"github.com/sashabaranov/go-openai"
+)
+
+// defaultEmbedWorkers is the default number of concurrent embedding requests.
+const defaultEmbedWorkers = 4
+
+// Rate-limited embedding requests are retried up to embedRetries times,
+// backing off exponentially from embedBackoff.
+const (
+	embedRetries = 5
+	embedBackoff = time.Second
+)
+
+// embedWorkers returns the number of concurrent embedding requests, defaulting to 4.
+func (ms *MemoryStream) embedWorkers() int {
+	if ms.EmbedWorkers > 0 {
+		return ms.EmbedWorkers
+	}
+	return defaultEmbedWorkers
+}
+
+// embedAll embeds the texts in batches, sending up to EmbedWorkers batches at
+// once. Batches refused for exceeding the API's rate limit are retried after
+// a backoff. The embeddings are checked against the stream's dimension.
+func (ms *MemoryStream) embedAll(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	batches := make(chan int)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+	}
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr != nil
+	}
+	for w := 0; w < ms.embedWorkers(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for lo := range batches {
+				if failed() {
+					continue
+				}
+				hi := min(lo+defaultBatchSize, len(texts))
+				batch, err := ms.embedRetrying(ctx, texts[lo:hi])
+				if err != nil {
+					fail(fmt.Errorf("failed to get embeddings: %w", err))
+					continue
+				}
+				if len(batch) != hi-lo {
+					fail(fmt.Errorf("expected %d embeddings but got %d", hi-lo, len(batch)))
+					continue
+				}
+				copy(embeddings[lo:hi], batch)
+			}
+		}()
+	}
+	for lo := 0; lo < len(texts); lo += defaultBatchSize {
+		batches <- lo
+	}
+	close(batches)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	for _, e := range embeddings {
+		if err := ms.checkDimension(e); err != nil {
+			return nil, err
+		}
+	}
+	return embeddings, nil
+}
+
+// embedRetrying embeds a batch, waiting on the Limiter before each attempt and
+// retrying with exponential backoff while the API reports the rate limit
+// exceeded, until ctx is done.
+func (ms *MemoryStream) embedRetrying(ctx context.Context, texts []string) ([][]float32, error) {
+	var tokens int
+	for _, t := range texts {
+		tokens += ratelimit.EstimateTokens(t)
+	}
+	backoff := embedBackoff
+	for attempt := 0; ; attempt++ {
+		if ms.Limiter != nil {
+			if err := ms.Limiter.Wait(ctx, ms.LimiterAgent, tokens); err != nil {
+				return nil, err
+			}
+		}
+		embeddings, err := embedContext(ctx, ms.embedder(), texts)
+		if err == nil || attempt == embedRetries || !rateLimited(err) {
+			return embeddings, err
+		}
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+		backoff *= 2
+	}
+}
+
+// rateLimited reports whether err is the API refusing a request for exceeding
+// its rate limit.
+func rateLimited(err error) bool {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatusCode == http.StatusTooManyRequests
+	}
+	var reqErr *openai.RequestError
+	return errors.As(err, &reqErr) && reqErr.HTTPStatusCode == http.StatusTooManyRequests
+}
@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// EncryptedStore wraps a Store, sealing each memory's description, embedding,
+// keywords and reflection question with AES-GCM before they reach the
+// backend, for agents seeded from private data. The sealed fields are held
+// base64 encoded in the stored description and the stored embedding is empty,
+// so the backend must accept memories without embeddings, as SQLite, Postgres
+// and kv do, and cannot serve as the stream's Index.
+type EncryptedStore struct {
+	Store Store
+	aead  cipher.AEAD
+}
+
+// NewEncryptedStore wraps the store with encryption under the key, which must
+// be 16, 24 or 32 bytes long to select AES-128, AES-192 or AES-256.
+func NewEncryptedStore(store Store, key []byte) (*EncryptedStore, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return &EncryptedStore{Store: store, aead: aead}, nil
+}
+
+// sealed is the plaintext encrypted into a stored memory's description.
+type sealed struct {
+	Description string    `json:"description"`
+	Embedding   []float32 `json:"embedding,omitempty"`
+	Keywords    []string  `json:"keywords,omitempty"`
+	Question    string    `json:"question,omitempty"`
+}
+
+// Load decrypts every memory in the underlying store.
+func (s *EncryptedStore) Load() ([]MemoryObject, error) {
+	return s.LoadContext(context.Background())
+}
+
+// LoadContext is Load under the given context.
+func (s *EncryptedStore) LoadContext(ctx context.Context) ([]MemoryObject, error) {
+	memories, err := loadContext(ctx, s.Store)
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range memories {
+		if memories[i], err = s.open(m); err != nil {
+			return nil, fmt.Errorf("failed to decrypt memory %s: %w", m.ID, err)
+		}
+	}
+	return memories, nil
+}
+
+// Put encrypts the memory and writes it to the underlying store.
+func (s *EncryptedStore) Put(m MemoryObject) error {
+	return s.PutContext(context.Background(), m)
+}
+
+// PutContext is Put under the given context.
+func (s *EncryptedStore) PutContext(ctx context.Context, m MemoryObject) error {
+	m, err := s.seal(m)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt memory: %w", err)
+	}
+	return putContext(ctx, s.Store, m)
+}
+
+// Delete removes a memory from the underlying store.
+func (s *EncryptedStore) Delete(id string) error {
+	return s.DeleteContext(context.Background(), id)
+}
+
+// DeleteContext is Delete under the given context.
+func (s *EncryptedStore) DeleteContext(ctx context.Context, id string) error {
+	return deleteContext(ctx, s.Store, id)
+}
+
+// seal moves the memory's private fields into its encrypted description. The
+// ID is bound to the ciphertext so it cannot be swapped onto another memory.
+func (s *EncryptedStore) seal(m MemoryObject) (MemoryObject, error) {
+	plain, err := json.Marshal(sealed{Description: m.Description, Embedding: m.Embedding, Keywords: m.Keywords, Question: m.Question})
+	if err != nil {
+		return m, err
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return m, err
+	}
+	m.Description = base64.StdEncoding.EncodeToString(s.aead.Seal(nonce, nonce, plain, []byte(m.ID)))
+	m.Embedding = nil
+	m.Keywords = nil
+	m.Question = ""
+	return m, nil
+}
+
+// open is the inverse of seal.
+func (s *EncryptedStore) open(m MemoryObject) (MemoryObject, error) {
+	data, err := base64.StdEncoding.DecodeString(m.Description)
+	if err != nil {
+		return m, err
+	}
+	n := s.aead.NonceSize()
+	if len(data) < n {
+		return m, errors.New("ciphertext too short")
+	}
+	plain, err := s.aead.Open(nil, data[:n], data[n:], []byte(m.ID))
+	if err != nil {
+		return m, err
+	}
+	var p sealed
+	if err := json.Unmarshal(plain, &p); err != nil {
+		return m, err
+	}
+	m.Description, m.Embedding, m.Keywords, m.Question = p.Description, p.Embedding, p.Keywords, p.Question
+	return m, nil
+}
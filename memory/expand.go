@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// expandQuery asks the language model for n paraphrases or related queries.
+func (ms *MemoryStream) expandQuery(ctx context.Context, query string, n int) ([]string, error) {
+	sysPrompt := fmt.Sprintf("Rewrite the given memory search query %d different ways, as paraphrases or closely related queries adding synonyms of its key terms and mentioning any people, places or topics it implies.  Output one query per line with no numbering or other comment.", n)
+	resp, err := ms.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: ms.model(),
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: sysPrompt},
+			{Role: "user", Content: query},
+		},
+		Temperature: ms.temperature(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var queries []string
+	for _, line := range strings.Split(resp.Choices[0].Message.Content, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(line, "-*0123456789.) "))
+		if line != "" && len(queries) < n {
+			queries = append(queries, line)
+		}
+	}
+	return queries, nil
+}
+
+// retrieveExpanded retrieves memories for the query and n expansions of it,
+// keeping each memory's best score across all of them.
+func (ms *MemoryStream) retrieveExpanded(ctx context.Context, query string, n int, keep func(MemoryObject) bool) ([]RetrievedMemory, error) {
+	expansions, err := ms.expandQuery(ctx, query, n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand query: %w", err)
+	}
+	embeddings, err := embedContext(ctx, ms.embedder(), append([]string{query}, expansions...))
+	if err != nil {
+		return nil, err
+	}
+	for i, e := range embeddings {
+		embeddings[i], _ = normalize(e)
+	}
+	if err := ms.backfillFor(ctx, embeddings[0]); err != nil {
+		return nil, err
+	}
+	matches := make([][]Match, len(embeddings))
+	for i, e := range embeddings {
+		if matches[i], err = ms.search(ctx, e, keep); err != nil {
+			return nil, err
+		}
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	best := make(map[string]RetrievedMemory)
+	for i, e := range embeddings {
+		for _, m := range ms.retrieve(e, matches[i], keep) {
+			if prev, ok := best[m.Memory.ID]; !ok || m.Score > prev.Score {
+				best[m.Memory.ID] = m
+			}
+		}
+	}
+	merged := make([]RetrievedMemory, 0, len(best))
+	for _, m := range best {
+		merged = append(merged, m)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].Score > merged[j].Score
+	})
+	if keep == nil {
+		ms.store(query, merged)
+	}
+	return merged, nil
+}
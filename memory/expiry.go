@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// Sweep removes the memories that have expired by now, or archives them if
+// ArchiveExpired is set, and returns them. Each expired memory is passed to the
+// OnMemoryExpired hook. The Store is updated on a best-effort basis; expired
+// memories it still holds are swept again after loading.
+func (ms *MemoryStream) Sweep(now time.Time) []MemoryObject {
+	ms.mu.Lock()
+	var expired []MemoryObject
+	for i := range ms.memories {
+		m := &ms.memories[i]
+		if m.Expired(now) && !m.Archived {
+			if ms.ArchiveExpired {
+				m.Archived = true
+			}
+			expired = append(expired, *m)
+		}
+	}
+	if len(expired) > 0 {
+		if !ms.ArchiveExpired {
+			ms.memories = slices.DeleteFunc(ms.memories, func(m MemoryObject) bool {
+				return m.Expired(now) && !m.Archived
+			})
+		}
+		ms.version++
+	}
+	for _, m := range expired {
+		ms.unindexed(m.ID)
+	}
+	ms.mu.Unlock()
+
+	if ms.Store != nil {
+		for _, m := range expired {
+			if ms.ArchiveExpired {
+				ms.Store.Put(m)
+			} else {
+				ms.Store.Delete(m.ID)
+			}
+		}
+	}
+	if ms.Hooks.OnMemoryExpired != nil {
+		for _, m := range expired {
+			ms.Hooks.OnMemoryExpired(m)
+		}
+	}
+	return expired
+}
+
+// StartSweeper sweeps expired memories in the background at the given interval
+// until the context is cancelled.
+func (ms *MemoryStream) StartSweeper(ctx context.Context, interval time.Duration) {
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-t.C:
+				ms.Sweep(now)
+			}
+		}
+	}()
+}
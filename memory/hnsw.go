@@ -0,0 +1,270 @@
+package memory
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// IndexUpdater is implemented by in-process indexes that the stream keeps up to
+// date as memories are added, archived, restored, re-embedded or removed.
+type IndexUpdater interface {
+	Insert(MemoryObject)
+	Remove(id string)
+}
+
+// HNSW is an in-process approximate nearest neighbour index over memory
+// embeddings using a hierarchical navigable small world graph, so retrieval from
+// very large streams is sub-linear rather than a scan of every memory. Removed
+// memories are tombstoned and skipped by searches.
+type HNSW struct {
+	M              int // Neighbours kept per node and layer; twice this on the bottom layer. Defaults to 16.
+	EfConstruction int // Candidate list size while inserting. Defaults to 200.
+	EfSearch       int // Candidate list size while searching. Defaults to 64.
+
+	mu       sync.RWMutex
+	nodes    []*hnswNode
+	byID     map[string]int
+	entry    int
+	maxLevel int
+	rng      *rand.Rand
+}
+
+// hnswNode is a memory in the graph.
+type hnswNode struct {
+	id        string
+	vec       []float32
+	expiresAt time.Time
+	deleted   bool
+	neighbors [][]int // Neighbours on each layer the node is in.
+}
+
+// UseHNSW builds an HNSW index over the stream's memories and sets it as the
+// stream's Index.
+func (ms *MemoryStream) UseHNSW() *HNSW {
+	h := &HNSW{}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	for _, m := range ms.memories {
+		h.Insert(m)
+	}
+	ms.Index = h
+	return h
+}
+
+// Insert adds a memory to the index, replacing any earlier version. Archived
+// memories and memories without an embedding are not indexed.
+func (h *HNSW) Insert(m MemoryObject) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.byID == nil {
+		h.byID = make(map[string]int)
+		h.entry = -1
+		h.rng = rand.New(rand.NewSource(1))
+	}
+	if i, ok := h.byID[m.ID]; ok {
+		h.nodes[i].deleted = true
+		delete(h.byID, m.ID)
+	}
+	if m.Archived || len(m.Embedding) == 0 {
+		return
+	}
+
+	level := int(math.Floor(-math.Log(1-h.rng.Float64()) / math.Log(float64(h.m()))))
+	n := len(h.nodes)
+	h.nodes = append(h.nodes, &hnswNode{
+		id:        m.ID,
+		vec:       m.Embedding,
+		expiresAt: m.ExpiresAt,
+		neighbors: make([][]int, level+1),
+	})
+	h.byID[m.ID] = n
+	if h.entry < 0 {
+		h.entry, h.maxLevel = n, level
+		return
+	}
+
+	ep := h.entry
+	for l := h.maxLevel; l > level; l-- {
+		ep = h.greedy(m.Embedding, ep, l)
+	}
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		candidates := h.searchLayer(m.Embedding, ep, h.efConstruction(), l)
+		neighbors := candidates[:min(len(candidates), h.maxNeighbors(l))]
+		for _, c := range neighbors {
+			h.nodes[n].neighbors[l] = append(h.nodes[n].neighbors[l], c.node)
+			h.connect(c.node, n, l)
+		}
+		ep = candidates[0].node
+	}
+	if level > h.maxLevel {
+		h.entry, h.maxLevel = n, level
+	}
+}
+
+// Remove tombstones a memory so searches skip it.
+func (h *HNSW) Remove(id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if i, ok := h.byID[id]; ok {
+		h.nodes[i].deleted = true
+		delete(h.byID, id)
+	}
+}
+
+// Search returns up to k unexpired memories most similar to the query.
+func (h *HNSW) Search(query []float32, k int, now time.Time) ([]Match, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if len(h.byID) == 0 {
+		return nil, nil
+	}
+	ep := h.entry
+	for l := h.maxLevel; l > 0; l-- {
+		ep = h.greedy(query, ep, l)
+	}
+	var matches []Match
+	for _, c := range h.searchLayer(query, ep, max(h.efSearch(), k), 0) {
+		n := h.nodes[c.node]
+		if n.deleted || (!n.expiresAt.IsZero() && !now.Before(n.expiresAt)) {
+			continue
+		}
+		matches = append(matches, Match{ID: n.id, Similarity: c.sim})
+		if len(matches) == k {
+			break
+		}
+	}
+	return matches, nil
+}
+
+// greedy walks a layer towards the query from ep and returns the closest node found.
+func (h *HNSW) greedy(q []float32, ep, layer int) int {
+	best := h.sim(q, ep)
+	for changed := true; changed; {
+		changed = false
+		for _, nb := range h.nodes[ep].neighbors[layer] {
+			if s := h.sim(q, nb); s > best {
+				best, ep, changed = s, nb, true
+			}
+		}
+	}
+	return ep
+}
+
+// searchLayer finds the ef nodes of a layer closest to the query, starting from
+// ep, ordered from most to least similar.
+func (h *HNSW) searchLayer(q []float32, ep, ef, layer int) []hnswCandidate {
+	start := hnswCandidate{node: ep, sim: h.sim(q, ep)}
+	visited := map[int]bool{ep: true}
+	candidates := &hnswHeap{items: []hnswCandidate{start}}
+	results := &hnswHeap{items: []hnswCandidate{start}, worstFirst: true}
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(hnswCandidate)
+		if results.Len() >= ef && c.sim < results.items[0].sim {
+			break
+		}
+		for _, nb := range h.nodes[c.node].neighbors[layer] {
+			if visited[nb] {
+				continue
+			}
+			visited[nb] = true
+			s := h.sim(q, nb)
+			if results.Len() < ef || s > results.items[0].sim {
+				heap.Push(candidates, hnswCandidate{node: nb, sim: s})
+				heap.Push(results, hnswCandidate{node: nb, sim: s})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+	out := make([]hnswCandidate, results.Len())
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = heap.Pop(results).(hnswCandidate)
+	}
+	return out
+}
+
+// connect adds a link from node a to node b on a layer, dropping a's least
+// similar neighbour if it has too many.
+func (h *HNSW) connect(a, b, layer int) {
+	n := h.nodes[a]
+	n.neighbors[layer] = append(n.neighbors[layer], b)
+	limit := h.maxNeighbors(layer)
+	if len(n.neighbors[layer]) <= limit {
+		return
+	}
+	worst, worstSim := 0, float32(math.Inf(1))
+	for i, nb := range n.neighbors[layer] {
+		if s := dot(n.vec, h.nodes[nb].vec); s < worstSim {
+			worst, worstSim = i, s
+		}
+	}
+	n.neighbors[layer] = append(n.neighbors[layer][:worst], n.neighbors[layer][worst+1:]...)
+}
+
+// sim is the cosine similarity between the query and a node.
+func (h *HNSW) sim(q []float32, node int) float32 {
+	v := h.nodes[node].vec
+	if len(v) != len(q) {
+		return -1
+	}
+	return dot(q, v)
+}
+
+func (h *HNSW) m() int {
+	if h.M > 1 {
+		return h.M
+	}
+	return 16
+}
+
+func (h *HNSW) maxNeighbors(layer int) int {
+	if layer == 0 {
+		return 2 * h.m()
+	}
+	return h.m()
+}
+
+func (h *HNSW) efConstruction() int {
+	if h.EfConstruction > 0 {
+		return h.EfConstruction
+	}
+	return 200
+}
+
+func (h *HNSW) efSearch() int {
+	if h.EfSearch > 0 {
+		return h.EfSearch
+	}
+	return 64
+}
+
+// hnswCandidate is a node and its similarity to the query.
+type hnswCandidate struct {
+	node int
+	sim  float32
+}
+
+// hnswHeap is a heap of candidates, most similar first unless worstFirst is set.
+type hnswHeap struct {
+	items      []hnswCandidate
+	worstFirst bool
+}
+
+func (h *hnswHeap) Len() int { return len(h.items) }
+func (h *hnswHeap) Less(i, j int) bool {
+	if h.worstFirst {
+		return h.items[i].sim < h.items[j].sim
+	}
+	return h.items[i].sim > h.items[j].sim
+}
+func (h *hnswHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *hnswHeap) Push(x any)    { h.items = append(h.items, x.(hnswCandidate)) }
+func (h *hnswHeap) Pop() any {
+	x := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return x
+}
@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestHNSWRecall(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	h := &HNSW{}
+	var memories []MemoryObject
+	for i := 0; i < 500; i++ {
+		m := MemoryObject{ID: fmt.Sprint(i), Embedding: randomVector(r, 32)}
+		memories = append(memories, m)
+		h.Insert(m)
+	}
+	const k = 10
+	found, total := 0, 0
+	for q := 0; q < 20; q++ {
+		query := randomVector(r, 32)
+		exact := slices.Clone(memories)
+		sort.Slice(exact, func(i, j int) bool {
+			return dot(exact[i].Embedding, query) > dot(exact[j].Embedding, query)
+		})
+		matches, err := h.Search(query, k, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range exact[:k] {
+			total++
+			if slices.ContainsFunc(matches, func(x Match) bool { return x.ID == m.ID }) {
+				found++
+			}
+		}
+	}
+	if recall := float64(found) / float64(total); recall < 0.9 {
+		t.Errorf("recall@%d = %.2f, want at least 0.9", k, recall)
+	}
+}
+
+func TestHNSWSearch(t *testing.T) {
+	now := time.Date(2024, 2, 14, 12, 0, 0, 0, time.UTC)
+	a, b, c := []float32{1, 0, 0}, []float32{0, 1, 0}, []float32{0, 0, 1}
+	tests := []struct {
+		name   string
+		insert []MemoryObject
+		remove []string
+		query  []float32
+		want   []string
+	}{
+		{"nearest first", []MemoryObject{{ID: "a", Embedding: a}, {ID: "b", Embedding: b}}, nil, a, []string{"a", "b"}},
+		{"removed", []MemoryObject{{ID: "a", Embedding: a}, {ID: "b", Embedding: b}}, []string{"a"}, a, []string{"b"}},
+		{"expired", []MemoryObject{{ID: "a", Embedding: a, ExpiresAt: now}, {ID: "b", Embedding: b}}, nil, a, []string{"b"}},
+		{"archived", []MemoryObject{{ID: "a", Embedding: a, Archived: true}, {ID: "b", Embedding: b}}, nil, a, []string{"b"}},
+		{"unembedded", []MemoryObject{{ID: "a"}, {ID: "b", Embedding: b}}, nil, b, []string{"b"}},
+		{"re-embedded", []MemoryObject{{ID: "a", Embedding: a}, {ID: "b", Embedding: b}, {ID: "a", Embedding: c}}, nil, c, []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		h := &HNSW{}
+		for _, m := range tt.insert {
+			h.Insert(m)
+		}
+		for _, id := range tt.remove {
+			h.Remove(id)
+		}
+		matches, err := h.Search(tt.query, 5, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, m := range matches {
+			got = append(got, m.ID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Search = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
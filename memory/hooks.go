@@ -0,0 +1,62 @@
+package memory
+
+// Hooks are callbacks invoked as the memory stream changes, for mirroring
+// memories to analytics, updating UIs or enforcing custom policies. Unset hooks
+// are skipped. Hooks are called without the stream's lock held.
+type Hooks struct {
+	// BeforeMemoryAdded is called with each memory given to Add or AddAll before
+	// it is embedded, but not with updated ones. It may modify the memory, or
+	// return an error to reject it.
+	BeforeMemoryAdded func(*MemoryObject) error
+	// OnMemoryAdded is called after a memory is added to the stream.
+	OnMemoryAdded func(MemoryObject)
+	// OnMemoryRetrieved is called with the results of each retrieval.
+	OnMemoryRetrieved func(query string, retrieved []RetrievedMemory)
+	// OnMemoryEvicted is called for each memory evicted to keep the stream
+	// within its Capacity.
+	OnMemoryEvicted func(MemoryObject)
+	// OnMemoryExpired is called for each expired memory removed by Sweep, so it
+	// can be archived elsewhere.
+	OnMemoryExpired func(MemoryObject)
+	// OnRatingFallback is called when the Rater fails and a memory's
+	// importance is rated by the FallbackRater instead.
+	OnRatingFallback func(description string, err error)
+}
+
+// adding invokes the BeforeMemoryAdded hook.
+func (ms *MemoryStream) adding(m *MemoryObject) error {
+	if ms.Hooks.BeforeMemoryAdded != nil {
+		return ms.Hooks.BeforeMemoryAdded(m)
+	}
+	return nil
+}
+
+// ratingFellBack invokes the OnRatingFallback hook.
+func (ms *MemoryStream) ratingFellBack(description string, err error) {
+	if ms.Hooks.OnRatingFallback != nil {
+		ms.Hooks.OnRatingFallback(description, err)
+	}
+}
+
+// added invokes the OnMemoryAdded hook.
+func (ms *MemoryStream) added(m MemoryObject) {
+	if ms.Hooks.OnMemoryAdded != nil {
+		ms.Hooks.OnMemoryAdded(m)
+	}
+}
+
+// retrieved invokes the OnMemoryRetrieved hook.
+func (ms *MemoryStream) retrieved(query string, r []RetrievedMemory) {
+	if ms.Hooks.OnMemoryRetrieved != nil {
+		ms.Hooks.OnMemoryRetrieved(query, r)
+	}
+}
+
+// evicted invokes the OnMemoryEvicted hook for each memory.
+func (ms *MemoryStream) evicted(memories []MemoryObject) {
+	if ms.Hooks.OnMemoryEvicted != nil {
+		for _, m := range memories {
+			ms.Hooks.OnMemoryEvicted(m)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/lordtatty/a25/llm"
+	"github.com/sashabaranov/go-openai"
+)
+
+// ImportanceRater rates how important a memory is, from 1 (mundane) to 10
+// (poignant).
+type ImportanceRater interface {
+	Rate(description string) (float64, error)
+}
+
+// BatchRater is an ImportanceRater that can rate many memories at once.
+type BatchRater interface {
+	ImportanceRater
+	RateAll(descriptions []string) ([]float64, error)
+}
+
+// ContextBatchRater is a BatchRater whose requests can be cancelled with a
+// context.
+type ContextBatchRater interface {
+	BatchRater
+	RateAllContext(ctx context.Context, descriptions []string) ([]float64, error)
+}
+
+// rateAllContext rates the memories with r, under ctx if r supports it.
+func rateAllContext(ctx context.Context, r BatchRater, descriptions []string) ([]float64, error) {
+	if cr, ok := r.(ContextBatchRater); ok {
+		return cr.RateAllContext(ctx, descriptions)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.RateAll(descriptions)
+}
+
+// ContextRater is an ImportanceRater whose requests can be cancelled with a
+// context.
+type ContextRater interface {
+	ImportanceRater
+	RateContext(ctx context.Context, description string) (float64, error)
+}
+
+// rateContext rates the memory with r, under ctx if r supports it.
+func rateContext(ctx context.Context, r ImportanceRater, description string) (float64, error) {
+	if cr, ok := r.(ContextRater); ok {
+		return cr.RateContext(ctx, description)
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.Rate(description)
+}
+
+// LLMRater rates importance with a language model.
+type LLMRater struct {
+	Client      OpenAIClient
+	Model       string   // Chat model; defaults to GPT-4o mini.
+	Temperature *float32 // Sampling temperature, set with llm.Temp; defaults to 1.
+	BatchSize   int      // Memories rated per call by RateAll; defaults to 50.
+}
+
+// importancePrompt describes the rating scale to the model.
+const importancePrompt = "On a scale of 1 to 10, where 1 is mundane (e.g., brushing teeth) and 10 is poignant (e.g., a life-changing event), "
+
+// Rate asks the model to rate a single memory.
+func (r *LLMRater) Rate(description string) (float64, error) {
+	return r.RateContext(context.Background(), description)
+}
+
+// RateContext is Rate under the given context.
+func (r *LLMRater) RateContext(ctx context.Context, description string) (float64, error) {
+	sysPrompt := importancePrompt + "rate the importance of the given reflection.  Output a single float value only, e.g., 7.5.  Include no other comment or opinion."
+	resp, err := r.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: r.model(),
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: sysPrompt},
+			{Role: "user", Content: description},
+		},
+		Temperature: r.temperature(),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Parse the model's response to extract the importance rating.
+	return parseImportanceRating(resp.Choices[0].Message.Content)
+}
+
+// RateAll rates many memories with one call per BatchSize memories, sending a
+// numbered list and reading back a list of scores. This is far cheaper than
+// rating memories one at a time when importing large observation logs.
+func (r *LLMRater) RateAll(descriptions []string) ([]float64, error) {
+	return r.RateAllContext(context.Background(), descriptions)
+}
+
+// RateAllContext is RateAll under the given context.
+func (r *LLMRater) RateAllContext(ctx context.Context, descriptions []string) ([]float64, error) {
+	size := r.BatchSize
+	if size <= 0 {
+		size = 50
+	}
+	var ratings []float64
+	for lo := 0; lo < len(descriptions); lo += size {
+		batch, err := r.rateBatch(ctx, descriptions[lo:min(lo+size, len(descriptions))])
+		if err != nil {
+			return nil, err
+		}
+		ratings = append(ratings, batch...)
+	}
+	return ratings, nil
+}
+
+// rateBatch asks the model to rate several memories in one call.
+func (r *LLMRater) rateBatch(ctx context.Context, descriptions []string) ([]float64, error) {
+	sysPrompt := importancePrompt + `rate the importance of each numbered memory.
+Respond with a JSON object of the form {"ratings": [7.5, 2]} containing one rating per memory, in order.`
+	var lines []string
+	for i, d := range descriptions {
+		lines = append(lines, fmt.Sprintf("%d. %s", i+1, d))
+	}
+	resp, err := r.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: r.model(),
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: sysPrompt},
+			{Role: "user", Content: strings.Join(lines, "\n")},
+		},
+		Temperature:    r.temperature(),
+		ResponseFormat: &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseImportanceRatings(resp.Choices[0].Message.Content, len(descriptions))
+}
+
+// model returns the chat model, defaulting to GPT-4o mini.
+func (r *LLMRater) model() string {
+	return llm.Model(r.Model)
+}
+
+// temperature returns the sampling temperature, defaulting to 1.
+func (r *LLMRater) temperature() float32 {
+	return llm.Temperature(r.Temperature)
+}
+
+// parseImportanceRating extracts the importance score from the response.
+func parseImportanceRating(response string) (float64, error) {
+	// Assume the response is a number from 1 to 10, parse it.
+	rating, err := strconv.ParseFloat(strings.TrimSpace(response), 32)
+	if err != nil {
+		return 0, err
+	}
+	return clampImportance(rating)
+}
+
+// clampImportance clamps a rating to the scale of 1 to 10, so a rating of zero
+// is never mistaken for a memory left unrated.
+func clampImportance(rating float64) (float64, error) {
+	if math.IsNaN(rating) {
+		return 0, errors.New("importance rating is not a number")
+	}
+	return min(max(rating, 1), 10), nil
+}
+
+// parseImportanceRatings decodes the ratings list, checking one was given per memory.
+func parseImportanceRatings(response string, n int) ([]float64, error) {
+	var out struct {
+		Ratings []float64 `json:"ratings"`
+	}
+	if err := json.Unmarshal([]byte(response), &out); err != nil {
+		return nil, fmt.Errorf("failed to parse ratings: %w", err)
+	}
+	if len(out.Ratings) != n {
+		return nil, fmt.Errorf("expected %d ratings but got %d", n, len(out.Ratings))
+	}
+	for i, r := range out.Ratings {
+		var err error
+		if out.Ratings[i], err = clampImportance(r); err != nil {
+			return nil, err
+		}
+	}
+	return out.Ratings, nil
+}
+
+// StaticRater rates every memory with the same importance.
+type StaticRater float64
+
+// Rate returns the static importance.
+func (r StaticRater) Rate(string) (float64, error) {
+	return float64(r), nil
+}
+
+// defaultKeywords are words suggesting a memory matters more than most.
+var defaultKeywords = map[string]float64{
+	"love": 3, "married": 4, "wedding": 4, "died": 5, "death": 5, "funeral": 5,
+	"born": 4, "birthday": 2, "party": 2, "argument": 3, "fight": 3, "angry": 2,
+	"promotion": 3, "fired": 4, "job": 2, "moved": 2, "broke up": 4, "accident": 4,
+	"election": 3, "decided": 1, "invited": 1, "secret": 2,
+}
+
+// HeuristicRater rates importance without a language model, starting from Base
+// and adding the weight of each keyword the memory mentions as whole words,
+// capped at 10. It is the default fallback when a rater fails.
+type HeuristicRater struct {
+	Base     float64            // Defaults to 3.
+	Keywords map[string]float64 // Lower-case keywords and their weights; a built-in list when nil.
+}
+
+// Rate scores the memory by its keywords.
+func (r HeuristicRater) Rate(description string) (float64, error) {
+	score := r.Base
+	if score == 0 {
+		score = 3
+	}
+	keywords := r.Keywords
+	if keywords == nil {
+		keywords = defaultKeywords
+	}
+	// Padding the words with spaces matches keywords, even those of several
+	// words, only at word boundaries, so "job" doesn't match "jobless".
+	words := " " + strings.Join(strings.FieldsFunc(strings.ToLower(description), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ") + " "
+	for k, w := range keywords {
+		if strings.Contains(words, " "+k+" ") {
+			score += w
+		}
+	}
+	return min(max(score, 1), 10), nil
+}
+
+// rater returns the stream's importance rater, defaulting to the language model.
+func (ms *MemoryStream) rater() ImportanceRater {
+	if ms.Rater != nil {
+		return ms.Rater
+	}
+	return &LLMRater{Client: ms.Client, Model: ms.Model, Temperature: ms.Temperature}
+}
+
+// fallbackRater returns the rater used when the rater fails.
+func (ms *MemoryStream) fallbackRater() ImportanceRater {
+	if ms.FallbackRater != nil {
+		return ms.FallbackRater
+	}
+	return HeuristicRater{}
+}
+
+// rateImportance rates a memory from 1 to 10, falling back if the rater fails
+// for any reason other than the context ending.
+func (ms *MemoryStream) rateImportance(ctx context.Context, description string) (float64, error) {
+	rating, err := rateContext(ctx, ms.rater(), description)
+	if err == nil {
+		rating, err = clampImportance(rating)
+	}
+	if err == nil {
+		return rating, nil
+	}
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+	ms.ratingFellBack(description, err)
+	rating, err = rateContext(ctx, ms.fallbackRater(), description)
+	if err != nil {
+		return 0, err
+	}
+	return clampImportance(rating)
+}
+
+// RateImportances rates the importance of many memories without adding them,
+// in batches if the rater is a BatchRater, falling back memory by memory if
+// rating fails.
+func (ms *MemoryStream) RateImportances(descriptions []string) ([]float64, error) {
+	return ms.RateImportancesContext(context.Background(), descriptions)
+}
+
+// RateImportancesContext is RateImportances under the given context.
+func (ms *MemoryStream) RateImportancesContext(ctx context.Context, descriptions []string) ([]float64, error) {
+	if r, ok := ms.rater().(BatchRater); ok && len(descriptions) > 1 {
+		ratings, err := rateAllContext(ctx, r, descriptions)
+		if err == nil && len(ratings) != len(descriptions) {
+			err = fmt.Errorf("expected %d ratings but got %d", len(descriptions), len(ratings))
+		}
+		for i := 0; err == nil && i < len(ratings); i++ {
+			ratings[i], err = clampImportance(ratings[i])
+		}
+		if err == nil {
+			return ratings, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+	ratings := make([]float64, len(descriptions))
+	for i, d := range descriptions {
+		rating, err := ms.rateImportance(ctx, d)
+		if err != nil {
+			return nil, err
+		}
+		ratings[i] = rating
+	}
+	return ratings, nil
+}
@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestHeuristicRater(t *testing.T) {
+	tests := []struct {
+		description string
+		want        float64
+	}{
+		{"Brushed teeth.", 3},
+		{"Lost my job today.", 5},
+		{"Still jobless and lovesick.", 3},
+		{"They broke up after the party.", 9},
+		{"A birthday, a wedding, a funeral and a death.", 10},
+	}
+	for _, tt := range tests {
+		if got, _ := (HeuristicRater{}).Rate(tt.description); got != tt.want {
+			t.Errorf("Rate(%q) = %v, want %v", tt.description, got, tt.want)
+		}
+	}
+}
+
+func TestParseImportanceRating(t *testing.T) {
+	tests := []struct {
+		response string
+		want     float64
+		wantErr  bool
+	}{
+		{"7.5", 7.5, false},
+		{" 3\n", 3, false},
+		{"0", 1, false},
+		{"-2", 1, false},
+		{"42", 10, false},
+		{"NaN", 0, true},
+		{"very important", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseImportanceRating(tt.response)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseImportanceRating(%q) = %v, %v; want %v, error %t", tt.response, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseImportanceRatings(t *testing.T) {
+	got, err := parseImportanceRatings(`{"ratings": [0, 5, 11]}`, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []float64{1, 5, 10}; got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("parseImportanceRatings = %v, want %v", got, want)
+	}
+	if _, err := parseImportanceRatings(`{"ratings": [5]}`, 2); err == nil {
+		t.Error("parseImportanceRatings accepted too few ratings")
+	}
+}
+
+// failingRater always fails to rate.
+type failingRater struct{}
+
+func (failingRater) Rate(string) (float64, error) {
+	return 0, errors.New("rater down")
+}
+
+func TestRateImportanceFallback(t *testing.T) {
+	var fellBack []string
+	ms := MemoryStream{
+		Rater:         failingRater{},
+		FallbackRater: StaticRater(0),
+		Hooks: Hooks{OnRatingFallback: func(description string, err error) {
+			fellBack = append(fellBack, description)
+		}},
+	}
+	got, err := ms.RateImportancesContext(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0] != 1 || got[1] != 1 {
+		t.Errorf("ratings = %v, want both clamped to 1", got)
+	}
+	if len(fellBack) != 2 {
+		t.Errorf("OnRatingFallback called for %q, want both memories", fellBack)
+	}
+}
@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Match is a memory found by a VectorIndex.
+type Match struct {
+	ID         string
+	Similarity float32 // Cosine similarity to the query.
+}
+
+// VectorIndex searches memory embeddings outside the process, typically in a
+// database, so retrieval scores a shortlist of candidates instead of scanning
+// every memory in Go.
+type VectorIndex interface {
+	// Search returns up to k unarchived memories, unexpired at now, most
+	// similar to a unit-length query embedding.
+	Search(query []float32, k int, now time.Time) ([]Match, error)
+}
+
+// ContextIndex is a VectorIndex whose searches can be cancelled with a context.
+type ContextIndex interface {
+	VectorIndex
+	SearchContext(ctx context.Context, query []float32, k int, now time.Time) ([]Match, error)
+}
+
+// searchContext searches idx, under ctx if idx supports it.
+func searchContext(ctx context.Context, idx VectorIndex, query []float32, k int, now time.Time) ([]Match, error) {
+	if ci, ok := idx.(ContextIndex); ok {
+		return ci.SearchContext(ctx, query, k, now)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return idx.Search(query, k, now)
+}
+
+// defaultSearchLimit is the number of candidates requested from a VectorIndex.
+const defaultSearchLimit = 100
+
+// searchLimit returns the number of candidates to request from the index.
+func (ms *MemoryStream) searchLimit() int {
+	if ms.SearchLimit > 0 {
+		return ms.SearchLimit
+	}
+	return defaultSearchLimit
+}
+
+// search asks the Index, if there is one, for the memories most similar to a
+// unit-length query embedding. A filtered search, where keep is set, asks it
+// for every memory, so none that keep accepts are missed for falling outside
+// the usual shortlist. It must be called without the lock held, so a slow
+// remote index doesn't hold up the rest of the stream.
+func (ms *MemoryStream) search(ctx context.Context, queryEmbedding []float32, keep func(MemoryObject) bool) ([]Match, error) {
+	ms.mu.Lock()
+	index := ms.Index
+	limit := ms.searchLimit()
+	if keep != nil {
+		limit = max(limit, len(ms.memories))
+	}
+	now := ms.now()
+	ms.mu.Unlock()
+	if index == nil {
+		return nil, nil
+	}
+	matches, err := searchContext(ctx, index, queryEmbedding, limit, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search index: %w", err)
+	}
+	return matches, nil
+}
+
+// candidates returns the positions of the memories keep accepts, or all of
+// them if it is nil, to score and the relevance of each as found by the index
+// search. Without an index every memory is a candidate and the relevance map
+// is nil. It must be called with the lock held.
+func (ms *MemoryStream) candidates(matches []Match, keep func(MemoryObject) bool) ([]int, map[int]float32) {
+	if ms.Index == nil {
+		var idx []int
+		for i, m := range ms.memories {
+			if keep == nil || keep(m) {
+				idx = append(idx, i)
+			}
+		}
+		return idx, nil
+	}
+	positions := make(map[string]int, len(ms.memories))
+	for i, m := range ms.memories {
+		positions[m.ID] = i
+	}
+	var idx []int
+	relevance := make(map[int]float32, len(matches))
+	for _, match := range matches {
+		i, ok := positions[match.ID]
+		if !ok || (keep != nil && !keep(ms.memories[i])) {
+			continue
+		}
+		idx = append(idx, i)
+		relevance[i] = match.Similarity
+	}
+	return idx, relevance
+}
+
+// indexed updates an in-process Index with a changed memory.
+func (ms *MemoryStream) indexed(m MemoryObject) {
+	if u, ok := ms.Index.(IndexUpdater); ok {
+		u.Insert(m)
+	}
+}
+
+// unindexed removes a memory from an in-process Index.
+func (ms *MemoryStream) unindexed(id string) {
+	if u, ok := ms.Index.(IndexUpdater); ok {
+		u.Remove(id)
+	}
+}
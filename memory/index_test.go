@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"testing"
+	"time"
+)
+
+// lockCheckingIndex searches an HNSW index, recording whether the stream's
+// lock was held during any search.
+type lockCheckingIndex struct {
+	*HNSW
+	ms     *MemoryStream
+	locked bool
+}
+
+func (x *lockCheckingIndex) Search(query []float32, k int, now time.Time) ([]Match, error) {
+	if x.ms.mu.TryLock() {
+		x.ms.mu.Unlock()
+	} else {
+		x.locked = true
+	}
+	return x.HNSW.Search(query, k, now)
+}
+
+func TestRetrieveSearchesIndexWithoutLock(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		retrieve func(ms *MemoryStream) ([]RetrievedMemory, error)
+	}{
+		{"unfiltered", func(ms *MemoryStream) ([]RetrievedMemory, error) {
+			return ms.RetrieveMemories("Maria")
+		}},
+		{"time range", func(ms *MemoryStream) ([]RetrievedMemory, error) {
+			return ms.RetrieveMemoriesBetween("Maria", now.Add(-time.Hour), now.Add(time.Hour))
+		}},
+	}
+	for _, tt := range tests {
+		ms := NewStream(nil)
+		ms.Embedder = wordEmbedder{}
+		embeddings, _ := wordEmbedder{}.Embed([]string{"Maria", "Klaus"})
+		ms.SetMemories([]MemoryObject{
+			{ID: "1", Description: "Maria is at the cafe", Importance: 3, CreationTime: now, LastAccessedTime: now, Embedding: embeddings[0]},
+			{ID: "2", Description: "Klaus is reading", Importance: 3, CreationTime: now, LastAccessedTime: now, Embedding: embeddings[1]},
+		})
+		index := &lockCheckingIndex{HNSW: ms.UseHNSW(), ms: ms}
+		ms.Index = index
+		retrieved, err := tt.retrieve(ms)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if index.locked {
+			t.Errorf("%s: searched the index with the stream's lock held", tt.name)
+		}
+		if len(retrieved) == 0 || retrieved[0].Memory.ID != "1" {
+			t.Errorf("%s: retrieved %v, want memory 1 first", tt.name, retrieved)
+		}
+	}
+}
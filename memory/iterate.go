@@ -0,0 +1,101 @@
+package memory
+
+// Memories returns a copy of every memory in the stream, in the order they were
+// added. Prefer Iterate or Page for large streams.
+func (ms *MemoryStream) Memories() []MemoryObject {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	out := make([]MemoryObject, len(ms.memories))
+	copy(out, ms.memories)
+	return out
+}
+
+// SetMemories replaces every memory in the stream, such as when restoring it
+// from a save. The stream's Store, if any, is not written to.
+func (ms *MemoryStream) SetMemories(memories []MemoryObject) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.memories = make([]MemoryObject, len(memories))
+	copy(ms.memories, memories)
+	ms.version++
+	for _, m := range ms.memories {
+		ms.indexed(m)
+	}
+}
+
+// Len returns the number of memories in the stream, including archived ones.
+func (ms *MemoryStream) Len() int {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return len(ms.memories)
+}
+
+// Page returns up to limit memories starting at offset, in the order they were
+// added. It returns nil once offset passes the end of the stream.
+func (ms *MemoryStream) Page(offset, limit int) []MemoryObject {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if offset < 0 || offset >= len(ms.memories) {
+		return nil
+	}
+	end := min(offset+limit, len(ms.memories))
+	out := make([]MemoryObject, end-offset)
+	copy(out, ms.memories[offset:end])
+	return out
+}
+
+// MemoryIterator walks memories one at a time, fetching them a page at a time
+// so callers never need every memory in RAM at once:
+//
+//	it := ms.Iterate(100)
+//	for it.Next() {
+//		m := it.Memory()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type MemoryIterator struct {
+	fetch func(page int) ([]MemoryObject, error)
+	page  int
+	buf   []MemoryObject
+	cur   MemoryObject
+	err   error
+	done  bool
+}
+
+// Next advances to the next memory, reporting whether there is one.
+func (it *MemoryIterator) Next() bool {
+	for len(it.buf) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+		it.buf, it.err = it.fetch(it.page)
+		it.page++
+		if it.err == nil && it.buf == nil {
+			it.done = true
+		}
+	}
+	it.cur, it.buf = it.buf[0], it.buf[1:]
+	return true
+}
+
+// Memory returns the current memory.
+func (it *MemoryIterator) Memory() MemoryObject {
+	return it.cur
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *MemoryIterator) Err() error {
+	return it.err
+}
+
+// Iterate returns an iterator over the stream's memories, fetched pageSize at
+// a time. Memories added during iteration may or may not be visited.
+func (ms *MemoryStream) Iterate(pageSize int) *MemoryIterator {
+	if pageSize <= 0 {
+		pageSize = defaultBatchSize
+	}
+	return &MemoryIterator{fetch: func(page int) ([]MemoryObject, error) {
+		return ms.Page(page*pageSize, pageSize), nil
+	}}
+}
@@ -0,0 +1,112 @@
+package memory
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Export writes every memory to w as one JSON object per line, for offline
+// analysis or moving an agent between environments.
+func (ms *MemoryStream) Export(w io.Writer) error {
+	ms.mu.Lock()
+	memories := make([]MemoryObject, len(ms.memories))
+	copy(memories, ms.memories)
+	ms.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, m := range memories {
+		if err := enc.Encode(m); err != nil {
+			return fmt.Errorf("failed to export memory %s: %w", m.ID, err)
+		}
+	}
+	return bw.Flush()
+}
+
+// Import reads memories written one JSON object per line, such as by Export or
+// from a dataset, and adds them to the stream. Lines need only a Description:
+// missing embeddings are fetched and missing importances rated in batches, and
+// IDs and timestamps default as in Add. Embedding requests are sent
+// concurrently by EmbedWorkers. It returns the number imported.
+func (ms *MemoryStream) Import(r io.Reader) (int, error) {
+	return ms.ImportContext(context.Background(), r)
+}
+
+// ImportContext is Import under the given context.
+func (ms *MemoryStream) ImportContext(ctx context.Context, r io.Reader) (int, error) {
+	dec := json.NewDecoder(r)
+	var batch []MemoryObject
+	imported := 0
+	for {
+		var m MemoryObject
+		err := dec.Decode(&m)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return imported, fmt.Errorf("failed to decode memory %d: %w", imported+len(batch)+1, err)
+		}
+		batch = append(batch, m)
+		if len(batch) == defaultBatchSize*ms.embedWorkers() {
+			if err := ms.importBatch(ctx, batch); err != nil {
+				return imported, err
+			}
+			imported += len(batch)
+			batch = batch[:0]
+		}
+	}
+	if err := ms.importBatch(ctx, batch); err != nil {
+		return imported, err
+	}
+	return imported + len(batch), ms.enforceCapacity()
+}
+
+// importBatch completes and inserts a batch of imported memories.
+func (ms *MemoryStream) importBatch(ctx context.Context, batch []MemoryObject) error {
+	var embed, rate []int
+	for i, m := range batch {
+		if len(m.Embedding) == 0 {
+			embed = append(embed, i)
+		} else {
+			batch[i].Embedding, batch[i].Norm = normalize(m.Embedding)
+		}
+		if m.Importance == 0 {
+			rate = append(rate, i)
+		}
+	}
+	if len(embed) > 0 {
+		texts := make([]string, len(embed))
+		for j, i := range embed {
+			texts[j] = batch[i].Description
+		}
+		embeddings, err := ms.embedAll(ctx, texts)
+		if err != nil {
+			return err
+		}
+		for j, i := range embed {
+			batch[i].Embedding, batch[i].Norm = normalize(embeddings[j])
+		}
+	}
+	if len(rate) > 0 {
+		texts := make([]string, len(rate))
+		for j, i := range rate {
+			texts[j] = batch[i].Description
+		}
+		ratings, err := ms.RateImportancesContext(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("failed to rate importance: %w", err)
+		}
+		for j, i := range rate {
+			batch[i].Importance = ratings[j]
+		}
+	}
+	for _, m := range batch {
+		if err := ms.insert(ctx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
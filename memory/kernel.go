@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"os"
+	"runtime"
+
+	"gonum.org/v1/gonum/blas/gonum"
+)
+
+// DotKernel computes the dot product of two equal-length vectors.
+type DotKernel func(a, b []float32) float32
+
+var (
+	// ScalarDot is the portable pure Go kernel.
+	ScalarDot DotKernel = dot
+	// BLASDot uses gonum's BLAS, which is backed by SIMD assembly on amd64.
+	BLASDot DotKernel = blasDot
+)
+
+// defaultKernel is the kernel used by streams that don't set one. It can be
+// forced with the A25_DOT_KERNEL environment variable ("scalar" or "blas"),
+// which is read once at startup; other values are ignored.
+var defaultKernel = selectKernel()
+
+// selectKernel picks the fastest kernel available on this platform.
+func selectKernel() DotKernel {
+	switch os.Getenv("A25_DOT_KERNEL") {
+	case "scalar":
+		return ScalarDot
+	case "blas":
+		return BLASDot
+	}
+	if runtime.GOARCH == "amd64" {
+		return BLASDot
+	}
+	return ScalarDot
+}
+
+// blasDot computes the dot product with gonum's BLAS implementation.
+func blasDot(a, b []float32) float32 {
+	return gonum.Implementation{}.Sdot(len(a), a, 1, b, 1)
+}
+
+// kernel returns the dot product kernel used by the stream.
+func (ms *MemoryStream) kernel() DotKernel {
+	if ms.Kernel != nil {
+		return ms.Kernel
+	}
+	return defaultKernel
+}
@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// randomVector returns a unit-length vector of n random components.
+func randomVector(r *rand.Rand, n int) []float32 {
+	v := make([]float32, n)
+	for i := range v {
+		v[i] = r.Float32()*2 - 1
+	}
+	v, _ = normalize(v)
+	return v
+}
+
+func TestKernelsAgree(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 1, 3, 16, 17, 384, 1536} {
+		a, b := randomVector(r, n), randomVector(r, n)
+		scalar, blas := ScalarDot(a, b), BLASDot(a, b)
+		if math.Abs(float64(scalar-blas)) > 1e-5 {
+			t.Errorf("dimension %d: ScalarDot = %v, BLASDot = %v", n, scalar, blas)
+		}
+	}
+}
+
+func BenchmarkDot(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	x, y := randomVector(r, 1536), randomVector(r, 1536)
+	for _, k := range []struct {
+		name   string
+		kernel DotKernel
+	}{
+		{"scalar", ScalarDot},
+		{"blas", BLASDot},
+	} {
+		b.Run(k.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				k.kernel(x, y)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package memory
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// KeywordExtractor picks out the salient keywords of a memory, such as the
+// people, places and other entities it mentions.
+type KeywordExtractor interface {
+	Extract(text string) []string
+}
+
+// ProperNounExtractor extracts runs of capitalised words, such as "Maria Lopez"
+// or "Hobbs Cafe", as keywords. It needs no language model.
+type ProperNounExtractor struct{}
+
+// properNounStopwords are capitalised words that don't name anything, mostly
+// those that open a sentence.
+var properNounStopwords = map[string]bool{
+	"A": true, "An": true, "The": true, "I": true, "He": true, "She": true, "They": true,
+	"It": true, "We": true, "You": true, "His": true, "Her": true, "Their": true, "This": true,
+	"That": true, "In": true, "On": true, "At": true, "After": true, "Before": true, "When": true,
+	"Started": true, "Generated": true, "Yesterday": true, "Today": true, "Tomorrow": true,
+	"Tonight": true, "Then": true, "Now": true, "Later": true, "Earlier": true, "Last": true,
+	"Next": true, "Every": true, "Each": true, "Some": true, "Also": true, "But": true, "And": true,
+	"So": true, "If": true, "While": true, "During": true, "Since": true, "Meanwhile": true,
+	"However": true, "My": true, "Our": true, "Your": true, "There": true, "Here": true,
+	"What": true, "Why": true, "How": true, "Where": true, "Who": true, "Yes": true, "No": true,
+	"Maybe": true, "Finally": true, "Suddenly": true, "Recently": true, "Please": true,
+}
+
+// Extract returns the runs of capitalised words in the text.
+func (ProperNounExtractor) Extract(text string) []string {
+	var keywords, run []string
+	flush := func() {
+		if len(run) > 0 {
+			if k := strings.Join(run, " "); !slices.Contains(keywords, k) {
+				keywords = append(keywords, k)
+			}
+			run = nil
+		}
+	}
+	for _, word := range strings.Fields(text) {
+		w := strings.TrimFunc(word, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		w = strings.TrimSuffix(strings.TrimSuffix(w, "'s"), "’s")
+		if w == "" || !unicode.IsUpper([]rune(w)[0]) || (len(run) == 0 && properNounStopwords[w]) {
+			flush()
+			continue
+		}
+		run = append(run, w)
+		// Punctuation after a word ends the run.
+		if last := word[len(word)-1]; last == ',' || last == '.' || last == ';' || last == ':' || last == '!' || last == '?' {
+			flush()
+		}
+	}
+	flush()
+	return keywords
+}
+
+// keywordIndex maps lower-case keywords to the IDs of memories mentioning them.
+type keywordIndex struct {
+	version uint64
+	ids     map[string][]string
+}
+
+// extractor returns the stream's keyword extractor.
+func (ms *MemoryStream) extractor() KeywordExtractor {
+	if ms.KeywordExtractor != nil {
+		return ms.KeywordExtractor
+	}
+	return ProperNounExtractor{}
+}
+
+// Mentioning returns the unarchived memories whose keywords include the given
+// keyword, ignoring case, in the order they were added. It uses an inverted
+// index rather than vector search, for entity-centric lookups such as every
+// memory mentioning "Maria Lopez".
+func (ms *MemoryStream) Mentioning(keyword string) []MemoryObject {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.keywords == nil || ms.keywords.version != ms.version {
+		ms.rebuildKeywords()
+	}
+	ids := make(map[string]bool)
+	for _, id := range ms.keywords.ids[strings.ToLower(keyword)] {
+		ids[id] = true
+	}
+	var out []MemoryObject
+	for _, m := range ms.memories {
+		if ids[m.ID] && !m.Archived {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// MentioningName returns the unarchived memories mentioning the named entity
+// by any part of its name, ignoring case, in the order they were added: those
+// mentioning "Maria" or "Maria Lopez's cafe" as well as "Maria Lopez" itself,
+// but not "Carlos Lopez".
+func (ms *MemoryStream) MentioningName(name string) []MemoryObject {
+	tokens := strings.Fields(strings.ToLower(name))
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.keywords == nil || ms.keywords.version != ms.version {
+		ms.rebuildKeywords()
+	}
+	ids := make(map[string]bool)
+	for kw, kwIDs := range ms.keywords.ids {
+		kwTokens := strings.Fields(kw)
+		if subset(kwTokens, tokens) || subset(tokens, kwTokens) {
+			for _, id := range kwIDs {
+				ids[id] = true
+			}
+		}
+	}
+	var out []MemoryObject
+	for _, m := range ms.memories {
+		if ids[m.ID] && !m.Archived {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// subset reports whether every token of a is in b, and a is not empty.
+func subset(a, b []string) bool {
+	for _, t := range a {
+		if !slices.Contains(b, t) {
+			return false
+		}
+	}
+	return len(a) > 0
+}
+
+// rebuildKeywords indexes every memory, extracting keywords for any that have
+// none. It must be called with the lock held.
+func (ms *MemoryStream) rebuildKeywords() {
+	ms.keywords = &keywordIndex{version: ms.version, ids: make(map[string][]string)}
+	for i := range ms.memories {
+		m := &ms.memories[i]
+		if m.Keywords == nil {
+			m.Keywords = ms.extractor().Extract(m.Description)
+		}
+		ms.keywords.add(*m)
+	}
+}
+
+// add indexes a memory's keywords.
+func (k *keywordIndex) add(m MemoryObject) {
+	for _, kw := range m.Keywords {
+		kw = strings.ToLower(kw)
+		k.ids[kw] = append(k.ids[kw], m.ID)
+	}
+}
+
+// keywordsAdded updates the index after a memory is appended, if the index was
+// current beforehand. It must be called with the lock held, after the version
+// is incremented.
+func (ms *MemoryStream) keywordsAdded(m MemoryObject) {
+	if ms.keywords != nil && ms.keywords.version == ms.version-1 {
+		ms.keywords.add(m)
+		ms.keywords.version = ms.version
+	}
+}
@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestProperNounExtractor(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Yesterday Maria Lopez went to Hobbs Cafe.", []string{"Maria Lopez", "Hobbs Cafe"}},
+		{"Maria’s cat sat with Maria's dog", []string{"Maria"}},
+		{"Then Klaus said hello, Maria.", []string{"Klaus", "Maria"}},
+		{"the cafe is quiet", nil},
+	}
+	for _, tt := range tests {
+		if got := (ProperNounExtractor{}).Extract(tt.text); !slices.Equal(got, tt.want) {
+			t.Errorf("Extract(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestMentioningName(t *testing.T) {
+	var ms MemoryStream
+	ms.SetMemories([]MemoryObject{
+		{ID: "1", Description: "Maria Lopez is studying physics."},
+		{ID: "2", Description: "Yesterday Maria’s sister visited."},
+		{ID: "3", Description: "Carlos Lopez opened a shop."},
+		{ID: "4", Description: "Lunch at Maria Lopez's cafe.", Archived: true},
+	})
+	var got []string
+	for _, m := range ms.MentioningName("Maria Lopez") {
+		got = append(got, m.ID)
+	}
+	if want := []string{"1", "2"}; !slices.Equal(got, want) {
+		t.Errorf("MentioningName = %q, want %q", got, want)
+	}
+}
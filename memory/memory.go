@@ -3,10 +3,14 @@ package memory
 import (
 	"context"
 	"fmt"
-	"strconv"
-	"strings"
+	"slices"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/lordtatty/a25/clock"
+	"github.com/lordtatty/a25/llm"
+	"github.com/lordtatty/a25/ratelimit"
 	"github.com/sashabaranov/go-openai"
 )
 
@@ -17,100 +21,301 @@ type OpenAIClient interface {
 
 // MemoryObject represents a single memory with associated metadata.
 type MemoryObject struct {
+	ID               string
 	Description      string
 	CreationTime     time.Time
 	LastAccessedTime time.Time
 	Importance       float64
-	Embedding        []float32
+	Embedding        []float32 // Normalised to unit length.
+	Norm             float32   // Length of the embedding before normalisation.
+	ExpiresAt        time.Time // When the memory expires; zero if it never does.
+	Archived         bool      // Archived memories are hidden from retrieval but kept.
+	Kind             string    // What produced the memory, e.g. Observation or Reflection.
+	Source           string    // Who or what the memory came from, e.g. an agent's name.
+	Recurrences      int       // Times the memory recurred and was merged instead of stored again.
+	Evidence         []string  // IDs of the memories a reflection or summary was drawn from.
+	Keywords         []string  // Salient people, places and other entities mentioned.
+	// Strength scales how slowly the memory is forgotten: its recency decays over
+	// a day of simulated time per unit of strength. Zero counts as 1.
+	Strength float64
+	// Question is the reflection question a reflection answers, and Depth how
+	// many levels of reflection it is removed from observation: 0 for
+	// observations, 1 for reflections on them, 2 for reflections on those.
+	Question string
+	Depth    int
+}
+
+// Memory kinds.
+const (
+	Observation = "observation"
+	Reflection  = "reflection"
+	Plan        = "plan"
+	Summary     = "summary"
+)
+
+// Expired reports whether the memory has expired by the given time.
+func (m MemoryObject) Expired(now time.Time) bool {
+	return !m.ExpiresAt.IsZero() && !now.Before(m.ExpiresAt)
 }
 
 // MemoryStream holds all memories of an agent.
 type MemoryStream struct {
-	Client   OpenAIClient
-	Memories []MemoryObject
+	Client      OpenAIClient
+	Model       string    // Chat model used by the stream; defaults to GPT-4o mini.
+	Temperature *float32  // Sampling temperature, set with llm.Temp; defaults to 1.
+	Kernel      DotKernel // Similarity kernel; chosen for the platform when nil.
+	Weights     Weights   // Retrieval score weights; all components weigh 1 when unset.
+	// TimeScale is the number of simulated hours that pass per hour of the
+	// system clock, so recency decays in simulated time. It defaults to 1 and
+	// is ignored when Clock is simulated, as its time is simulated already.
+	TimeScale float64
+	// SpacedRepetition strengthens memories each time they are accessed, more so
+	// the closer they were to being forgotten, so often-recalled memories fade
+	// slowly while neglected ones are forgotten.
+	SpacedRepetition bool
+	Hooks            Hooks
+	Embedder         Embedder // Embeds memories and queries; OpenAI's EmbeddingModel when nil.
+	// EmbeddingModel and EmbeddingDimensions configure the OpenAI embedder used
+	// when Embedder is nil. The model defaults to text-embedding-3-small.
+	EmbeddingModel      openai.EmbeddingModel
+	EmbeddingDimensions int
+	// ReembedOnMismatch makes retrieval re-embed stored memories whose embedding
+	// length differs from the query's, instead of refusing embeddings that don't
+	// match the stream with ErrDimensionMismatch.
+	ReembedOnMismatch bool
+	// EmbedWorkers is the number of embedding requests AddAll and Import send at
+	// once when adding many memories. Defaults to 4.
+	EmbedWorkers int
+	// Limiter, if set, paces those requests, and their retries when the API's
+	// rate limit is exceeded, on behalf of LimiterAgent. Leave it unset if the
+	// Client is already rate limited by ratelimit.Limiter.Wrap.
+	Limiter      *ratelimit.Limiter
+	LimiterAgent string
+	// KeywordExtractor extracts each memory's Keywords when it is added, for
+	// lookups with Mentioning. Defaults to ProperNounExtractor.
+	KeywordExtractor KeywordExtractor
+	// Rater rates the importance of new memories, defaulting to the language
+	// model. If it fails, FallbackRater is used instead, defaulting to a keyword
+	// heuristic, so adding a memory never fails on its rating alone.
+	Rater         ImportanceRater
+	FallbackRater ImportanceRater
+	Redactor      Redactor // Masks sensitive content before memories are embedded, if set.
+	Store         Store    // Persists memories as they change, if set; see Load.
+	// Index, if set, finds the candidates for retrieval by vector similarity in
+	// place of scanning every memory. SearchLimit caps the number of candidates
+	// and defaults to 100.
+	Index       VectorIndex
+	SearchLimit int
+	// Parallelism is the number of goroutines used to score memories during
+	// retrieval. Values below 2 score on the calling goroutine.
+	Parallelism int
+	// ArchiveExpired makes Sweep archive expired memories instead of removing them.
+	ArchiveExpired bool
+	// ExpandQueries, if positive, has the language model rewrite each retrieval
+	// query into this many related queries whose results are merged, improving
+	// recall for terse queries.
+	ExpandQueries int
+	// RewriteQueries has the language model rewrite each retrieval query once,
+	// adding synonyms and the entities it implies, so terse questions find
+	// memories worded differently. It is a single expansion, and is ignored
+	// when ExpandQueries is set.
+	RewriteQueries bool
+	// Diversity, between 0 and 1, reorders the top TopK results of each retrieval
+	// by Maximal Marginal Relevance, trading score for novelty so repeated
+	// observations of one event don't fill the results. Zero disables it.
+	Diversity float32
+	// Reranker, if set, re-orders the top RerankCandidates of every retrieval by
+	// true relevance, dropping those it judges irrelevant, so callers such as
+	// the Reflector get relevant evidence rather than near neighbours in
+	// embedding space. RetrieveReranked always re-ranks, with the language
+	// model if Reranker is unset. RerankCandidates defaults to 30.
+	Reranker         Reranker
+	RerankCandidates int
+	// Capacity, if positive, is the most memories the stream holds. Adding beyond
+	// it evicts memories with Eviction, which defaults to EvictLRU.
+	Capacity int
+	Eviction Evictor
+	// DedupThreshold, if positive, merges a new memory into an earlier one of the
+	// same kind created within DedupWindow (default an hour) whose embedding is
+	// at least this similar, increasing its Recurrences instead of storing a copy.
+	DedupThreshold float32
+	DedupWindow    time.Duration
+	// Access decides which retrieved memories have their LastAccessedTime reset:
+	// by default the top TopK, which defaults to 10.
+	Access AccessPolicy
+	TopK   int
+	// CacheRetrievals caches retrieval results by query until the stream changes
+	// or ResetCache is called, typically once per simulation tick. Retrievals
+	// that touch memories change them too, so results are only reused between
+	// retrievals under TouchNone.
+	CacheRetrievals bool
+	// Clock supplies the current time for timestamps, recency and expiry,
+	// defaulting to the system clock, so memories follow simulated time.
+	Clock clock.Clock
+
+	mu       sync.Mutex // Guards the unexported state below.
+	memories []MemoryObject
+	version  uint64 // Incremented whenever memories are added or changed.
+	// accesses is incremented whenever retrieval touches memories. It
+	// invalidates cached results, which hold copies of the memories, without
+	// rebuilding the slab or keyword index as a change of version would.
+	accesses  uint64
+	slab      *slab
+	cache     map[cacheKey][]RetrievedMemory
+	migrating *migration
+	keywords  *keywordIndex
 }
 
 func NewStream(client OpenAIClient) *MemoryStream {
 	return &MemoryStream{
 		Client:   client,
-		Memories: make([]MemoryObject, 0),
+		memories: make([]MemoryObject, 0),
 	}
 }
 
 // AddMemory adds a new memory to the memory stream.
 func (ms *MemoryStream) AddMemory(description string) error {
-	embed, err := getEmbedding(description, ms.Client)
-	if err != nil {
-		return fmt.Errorf("failed to get embedding: %w", err)
+	return ms.AddMemoryContext(context.Background(), description)
+}
+
+// AddMemoryContext is AddMemory under the given context, which bounds the
+// embedding and rating requests.
+func (ms *MemoryStream) AddMemoryContext(ctx context.Context, description string) error {
+	return ms.AddContext(ctx, MemoryObject{Description: description})
+}
+
+// AddMemoryWithTTL adds a memory that expires after the given duration, for
+// transient context such as "the cafe is crowded right now".
+func (ms *MemoryStream) AddMemoryWithTTL(description string, ttl time.Duration) error {
+	return ms.Add(MemoryObject{Description: description, ExpiresAt: ms.now().Add(ttl)})
+}
+
+// Add adds a memory to the stream. The description is redacted and embedded,
+// and rated for importance unless the memory already has one; timestamps
+// default to now.
+func (ms *MemoryStream) Add(memory MemoryObject) error {
+	return ms.AddContext(context.Background(), memory)
+}
+
+// AddContext is Add under the given context, which bounds the embedding and
+// rating requests.
+func (ms *MemoryStream) AddContext(ctx context.Context, memory MemoryObject) error {
+	if err := ms.adding(&memory); err != nil {
+		return fmt.Errorf("memory rejected: %w", err)
 	}
-	importance, err := rateImportance(description, ms.Client)
+	memory, err := ms.prepare(ctx, memory)
 	if err != nil {
-		return fmt.Errorf("failed to rate importance: %w", err)
+		return err
 	}
-	memory := MemoryObject{
-		Description:      description,
-		CreationTime:     time.Now(),
-		LastAccessedTime: time.Now(),
-		Importance:       importance,
-		Embedding:        embed,
+	if merged, err := ms.merge(ctx, memory); err != nil || merged {
+		return err
 	}
-	ms.Memories = append(ms.Memories, memory)
-	return nil
+	if memory.Importance == 0 {
+		if memory.Importance, err = ms.rateImportance(ctx, memory.Description); err != nil {
+			return fmt.Errorf("failed to rate importance: %w", err)
+		}
+	}
+	if err := ms.insert(ctx, memory); err != nil {
+		return err
+	}
+	return ms.enforceCapacity()
 }
 
-// rateImportance uses the language model to estimate the importance of a reflection.
-func rateImportance(reflection string, client OpenAIClient) (float64, error) {
-	sysPrompt := "On a scale of 1 to 10, where 1 is mundane (e.g., brushing teeth) and 10 is poignant (e.g., a life-changing event), rate the importance of the given reflection.  Output a single float value only, e.g., 7.5.  Include no other comment or opinion."
-	resp, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
-		Model: openai.GPT4oMini,
-		Messages: []openai.ChatCompletionMessage{
-			{Role: "system", Content: sysPrompt},
-			{Role: "user", Content: reflection},
-		},
-		Temperature: 1,
-	})
-
+// prepare redacts and embeds a memory ahead of insertion. A memory given an
+// embedding, such as one from Embed, keeps it unless redaction changes its
+// description.
+func (ms *MemoryStream) prepare(ctx context.Context, memory MemoryObject) (MemoryObject, error) {
+	description := memory.Description
+	memory, err := ms.redact(ctx, memory)
 	if err != nil {
-		return 0, err
+		return memory, err
 	}
-
-	// Parse the model's response to extract the importance rating.
-	rating, err := parseImportanceRating(resp.Choices[0].Message.Content)
+	if len(memory.Embedding) > 0 && memory.Description == description {
+		if err := ms.checkDimension(memory.Embedding); err != nil {
+			return memory, err
+		}
+		memory.Embedding, memory.Norm = normalize(memory.Embedding)
+		return memory, nil
+	}
+	embed, err := ms.embed(ctx, memory.Description)
 	if err != nil {
-		return 0, err
+		return memory, fmt.Errorf("failed to get embedding: %w", err)
 	}
+	memory.Embedding, memory.Norm = normalize(embed)
+	return memory, nil
+}
 
-	return rating, nil
+// redact masks the memory's description with the Redactor, if any.
+func (ms *MemoryStream) redact(ctx context.Context, memory MemoryObject) (MemoryObject, error) {
+	if ms.Redactor == nil {
+		return memory, nil
+	}
+	var err error
+	if memory.Description, err = redactContext(ctx, ms.Redactor, memory.Description); err != nil {
+		return memory, fmt.Errorf("failed to redact memory: %w", err)
+	}
+	return memory, nil
 }
 
-// parseImportanceRating extracts the importance score from the response.
-func parseImportanceRating(response string) (float64, error) {
-	// Assume the response is a number from 1 to 10, parse it.
-	rating, err := strconv.ParseFloat(strings.TrimSpace(response), 32)
-	if err != nil {
-		return 0, err
+// insert assigns the memory an ID and timestamps, writes it to the Store and
+// appends it to the stream.
+func (ms *MemoryStream) insert(ctx context.Context, memory MemoryObject) error {
+	if memory.ID == "" {
+		memory.ID = uuid.NewString()
+	}
+	now := ms.now()
+	if memory.CreationTime.IsZero() {
+		memory.CreationTime = now
+	}
+	if memory.LastAccessedTime.IsZero() {
+		memory.LastAccessedTime = now
+	}
+	if memory.Keywords == nil {
+		memory.Keywords = ms.extractor().Extract(memory.Description)
+	}
+	if err := ms.persist(ctx, memory); err != nil {
+		return err
 	}
 
-	return rating, nil
+	ms.mu.Lock()
+	ms.memories = append(ms.memories, memory)
+	ms.version++
+	ms.indexed(memory)
+	ms.keywordsAdded(memory)
+	ms.mu.Unlock()
+	ms.dualWrite(memory)
+	ms.added(memory)
+	return nil
 }
 
-// GetRecentMemories returns the N most recent memories.
-func (ms *MemoryStream) GetRecentMemories(n int) []MemoryObject {
-	if len(ms.Memories) < n {
-		n = len(ms.Memories)
+// model returns the chat model, defaulting to GPT-4o mini.
+func (ms *MemoryStream) model() string {
+	return llm.Model(ms.Model)
+}
+
+// now returns the current time on the stream's clock.
+func (ms *MemoryStream) now() time.Time {
+	if ms.Clock != nil {
+		return ms.Clock.Now()
 	}
-	return ms.Memories[len(ms.Memories)-n:]
+	return time.Now()
 }
 
-// getEmbedding retrieves the embedding vector for a given text.
-func getEmbedding(text string, client OpenAIClient) ([]float32, error) {
-	ctx := context.Background()
-	resp, err := client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
-		Input: []string{text},
-		Model: openai.SmallEmbedding3,
-	})
-	if err != nil {
-		return nil, err
+// temperature returns the sampling temperature, defaulting to 1.
+func (ms *MemoryStream) temperature() float32 {
+	return llm.Temperature(ms.Temperature)
+}
+
+// GetRecentMemories returns the N most recent memories.
+func (ms *MemoryStream) GetRecentMemories(n int) []MemoryObject {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	var recent []MemoryObject
+	for i := len(ms.memories) - 1; i >= 0 && len(recent) < n; i-- {
+		if !ms.memories[i].Archived {
+			recent = append(recent, ms.memories[i])
+		}
 	}
-	return resp.Data[0].Embedding, nil
+	slices.Reverse(recent)
+	return recent
 }
@@ -0,0 +1,145 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// migration tracks an embedding model migration in progress.
+type migration struct {
+	embedder   Embedder
+	embeddings map[string][]float32 // New embeddings keyed by memory ID.
+}
+
+// Migrate re-embeds every memory with a new embedder in batches and then
+// switches the stream over to it. Memories added while the migration runs are
+// embedded with both embedders, so retrieval keeps working on the old vectors
+// until the switch. The new embeddings are validated before switching; if
+// anything fails, the stream keeps its old embeddings and embedder.
+// Progress, if non-nil, is called after each batch.
+func (ms *MemoryStream) Migrate(next Embedder, batchSize int, progress func(done, total int)) error {
+	return ms.MigrateContext(context.Background(), next, batchSize, progress)
+}
+
+// MigrateContext is Migrate under the given context.
+func (ms *MemoryStream) MigrateContext(ctx context.Context, next Embedder, batchSize int, progress func(done, total int)) error {
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	ms.mu.Lock()
+	if ms.migrating != nil {
+		ms.mu.Unlock()
+		return errors.New("an embedding migration is already running")
+	}
+	ms.migrating = &migration{embedder: next, embeddings: make(map[string][]float32)}
+	ms.mu.Unlock()
+
+	err := ms.migrate(ctx, next, batchSize, progress)
+
+	ms.mu.Lock()
+	if err == nil {
+		err = ms.switchEmbedder(next)
+	}
+	ms.migrating = nil
+	migrated := slices.Clone(ms.memories)
+	ms.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	for _, m := range migrated {
+		if err := ms.persist(ctx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrate embeds memories missing a new embedding until none remain, which
+// also catches memories added after the migration started.
+func (ms *MemoryStream) migrate(ctx context.Context, next Embedder, batchSize int, progress func(done, total int)) error {
+	done := 0
+	for {
+		ms.mu.Lock()
+		var ids, texts []string
+		for _, m := range ms.memories {
+			if _, ok := ms.migrating.embeddings[m.ID]; !ok {
+				ids = append(ids, m.ID)
+				texts = append(texts, m.Description)
+			}
+		}
+		ms.mu.Unlock()
+		if len(ids) == 0 {
+			return nil
+		}
+		total := done + len(ids)
+
+		for lo := 0; lo < len(ids); lo += batchSize {
+			hi := min(lo+batchSize, len(ids))
+			embeddings, err := embedContext(ctx, next, texts[lo:hi])
+			if err != nil {
+				return fmt.Errorf("failed to embed batch: %w", err)
+			}
+			if len(embeddings) != hi-lo {
+				return fmt.Errorf("expected %d embeddings but got %d", hi-lo, len(embeddings))
+			}
+			ms.mu.Lock()
+			for i, e := range embeddings {
+				ms.migrating.embeddings[ids[lo+i]] = e
+			}
+			ms.mu.Unlock()
+			done += hi - lo
+			if progress != nil {
+				progress(done, total)
+			}
+		}
+	}
+}
+
+// switchEmbedder validates the new embeddings and replaces the old ones. It
+// must be called with the lock held.
+func (ms *MemoryStream) switchEmbedder(next Embedder) error {
+	dim := -1
+	for _, m := range ms.memories {
+		e, ok := ms.migrating.embeddings[m.ID]
+		if !ok {
+			return fmt.Errorf("memory %s was not re-embedded", m.ID)
+		}
+		if len(e) == 0 {
+			return fmt.Errorf("memory %s has an empty embedding", m.ID)
+		}
+		if dim >= 0 && len(e) != dim {
+			return fmt.Errorf("inconsistent embedding dimensions %d and %d", dim, len(e))
+		}
+		dim = len(e)
+	}
+	for i := range ms.memories {
+		m := &ms.memories[i]
+		m.Embedding, m.Norm = normalize(ms.migrating.embeddings[m.ID])
+		ms.indexed(*m)
+	}
+	ms.Embedder = next
+	ms.version++
+	return nil
+}
+
+// dualWrite embeds a newly added memory with the embedder being migrated to.
+// Failures are ignored, since the migration re-embeds anything missing.
+func (ms *MemoryStream) dualWrite(m MemoryObject) {
+	ms.mu.Lock()
+	mig := ms.migrating
+	ms.mu.Unlock()
+	if mig == nil {
+		return
+	}
+	embeddings, err := mig.embedder.Embed([]string{m.Description})
+	if err != nil || len(embeddings) != 1 {
+		return
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.migrating == mig {
+		mig.embeddings[m.ID] = embeddings[0]
+	}
+}
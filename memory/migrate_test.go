@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	ms := NewStream(nil)
+	ms.SetMemories([]MemoryObject{
+		{ID: "1", Description: "apple", Embedding: []float32{1, 0}},
+		{ID: "2", Description: "banana", Embedding: []float32{0, 1}},
+		{ID: "3", Description: "cherry", Embedding: []float32{1, 1}},
+	})
+	var calls [][2]int
+	err := ms.Migrate(wordEmbedder{}, 2, func(done, total int) {
+		calls = append(calls, [2]int{done, total})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 2 || calls[1] != [2]int{3, 3} {
+		t.Errorf("progress calls = %v, want two ending at 3 of 3", calls)
+	}
+	for _, m := range ms.Memories() {
+		if len(m.Embedding) != 26 || m.Embedding[m.Description[0]-'a'] != 1 {
+			t.Errorf("memory %s was not re-embedded: %v", m.ID, m.Embedding)
+		}
+	}
+	if _, ok := ms.Embedder.(wordEmbedder); !ok {
+		t.Errorf("Embedder = %T, want the new embedder", ms.Embedder)
+	}
+}
+
+// emptyEmbedder returns no embedding for any text.
+type emptyEmbedder struct{}
+
+func (emptyEmbedder) Embed(texts []string) ([][]float32, error) {
+	return make([][]float32, len(texts)), nil
+}
+
+func TestMigrateKeepsOldEmbeddingsOnFailure(t *testing.T) {
+	ms := NewStream(nil)
+	ms.SetMemories([]MemoryObject{{ID: "1", Description: "apple", Embedding: []float32{1, 0}}})
+	if err := ms.Migrate(emptyEmbedder{}, 0, nil); err == nil {
+		t.Fatal("Migrate accepted empty embeddings")
+	}
+	if m := ms.Memories()[0]; len(m.Embedding) != 2 {
+		t.Errorf("embedding = %v, want the old one kept", m.Embedding)
+	}
+	if ms.Embedder != nil {
+		t.Errorf("Embedder = %T, want it unchanged", ms.Embedder)
+	}
+}
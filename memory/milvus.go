@@ -0,0 +1,405 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// milvusPageSize is the number of memories fetched per query when loading.
+const milvusPageSize = 1000
+
+// MilvusStore keeps one agent's memories in a partition of their own within a
+// Milvus collection, so a very large simulation can hold every agent in one
+// collection. It is both a Store and a VectorIndex.
+//
+// Puts are buffered and upserted in batches of BatchSize, which defaults to
+// 100; Flush writes any that are pending, and the stream calls it on Flush
+// and Sync. Loads, searches and deletes flush first, so they always see
+// earlier puts. A failed upsert keeps its memories queued for the next flush.
+type MilvusStore struct {
+	Host       string // Milvus address, e.g. http://localhost:19530.
+	Token      string // API key or "user:password"; optional.
+	Collection string
+	Agent      string
+	BatchSize  int
+	HTTPClient *http.Client // Defaults to http.DefaultClient.
+
+	mu        sync.Mutex
+	pending   []MemoryObject
+	partition bool // Whether the agent's partition is known to exist.
+}
+
+// MilvusShared is a SharedStore keeping every agent's memories in one Milvus
+// collection, each in its own partition.
+type MilvusShared struct {
+	Host       string
+	Token      string
+	Collection string
+	BatchSize  int
+	HTTPClient *http.Client // Defaults to http.DefaultClient.
+}
+
+// Namespace returns the store for one agent's memories, which is also a VectorIndex.
+func (s *MilvusShared) Namespace(agent string) Store {
+	return &MilvusStore{Host: s.Host, Token: s.Token, Collection: s.Collection, Agent: agent, BatchSize: s.BatchSize, HTTPClient: s.HTTPClient}
+}
+
+// NewMilvusStore creates a store for the agent's memories in the collection.
+func NewMilvusStore(host, token, collection, agent string) *MilvusStore {
+	return &MilvusStore{Host: host, Token: token, Collection: collection, Agent: agent}
+}
+
+// CreateMilvusCollection creates a collection for memories with embeddings of
+// the given length, indexed for cosine similarity, if it does not exist.
+func CreateMilvusCollection(host, token, collection string, dimensions int) error {
+	s := &MilvusStore{Host: host, Token: token, Collection: collection}
+	var has struct {
+		Has bool `json:"has"`
+	}
+	if err := s.do(context.Background(), "/v2/vectordb/collections/has", map[string]any{"collectionName": collection}, &has); err != nil {
+		return fmt.Errorf("failed to check collection: %w", err)
+	}
+	if has.Has {
+		return nil
+	}
+	varchar := func(name string, length int) map[string]any {
+		return map[string]any{"fieldName": name, "dataType": "VarChar", "elementTypeParams": map[string]any{"max_length": length}}
+	}
+	field := func(name, dataType string) map[string]any {
+		return map[string]any{"fieldName": name, "dataType": dataType}
+	}
+	id := varchar("id", 64)
+	id["isPrimary"] = true
+	body := map[string]any{
+		"collectionName": collection,
+		"schema": map[string]any{
+			"fields": []map[string]any{
+				id,
+				varchar("description", 65535),
+				varchar("kind", 64),
+				varchar("source", 256),
+				field("importance", "Double"),
+				field("creation_time", "Double"),
+				field("last_accessed_time", "Double"),
+				field("expires_at", "Double"),
+				field("archived", "Bool"),
+				field("norm", "Float"),
+				field("recurrences", "Int64"),
+				varchar("evidence", 65535),
+				field("strength", "Double"),
+				varchar("question", 65535),
+				field("depth", "Int64"),
+				{"fieldName": "embedding", "dataType": "FloatVector", "elementTypeParams": map[string]any{"dim": dimensions}},
+			},
+		},
+		"indexParams": []map[string]any{
+			{"fieldName": "embedding", "indexName": "embedding", "metricType": "COSINE"},
+		},
+	}
+	if err := s.do(context.Background(), "/v2/vectordb/collections/create", body, nil); err != nil {
+		return fmt.Errorf("failed to create collection: %w", err)
+	}
+	return nil
+}
+
+// milvusFields are the fields returned when loading memories.
+var milvusFields = []string{"id", "description", "kind", "source", "importance", "creation_time", "last_accessed_time", "expires_at", "archived", "norm", "recurrences", "evidence", "strength", "question", "depth", "embedding"}
+
+// Load returns the agent's memories in creation order. Milvus caps a query's
+// offset plus limit at its max_query_result_window, 16384 by default, which
+// must be raised for agents with more memories.
+func (s *MilvusStore) Load() ([]MemoryObject, error) {
+	return s.LoadContext(context.Background())
+}
+
+// LoadContext is Load under the given context.
+func (s *MilvusStore) LoadContext(ctx context.Context) ([]MemoryObject, error) {
+	if err := s.FlushContext(ctx); err != nil {
+		return nil, err
+	}
+	var memories []MemoryObject
+	for offset := 0; ; offset += milvusPageSize {
+		body := map[string]any{
+			"collectionName": s.Collection,
+			"partitionNames": []string{s.partitionName()},
+			"filter":         `id != ""`,
+			"outputFields":   milvusFields,
+			"limit":          milvusPageSize,
+			"offset":         offset,
+		}
+		var rows []map[string]any
+		if err := s.do(ctx, "/v2/vectordb/entities/query", body, &rows); err != nil {
+			return nil, fmt.Errorf("failed to query memories: %w", err)
+		}
+		for _, r := range rows {
+			memories = append(memories, fromMilvus(r))
+		}
+		if len(rows) < milvusPageSize {
+			break
+		}
+	}
+	sortByCreation(memories)
+	return memories, nil
+}
+
+// Put queues a memory for upserting, writing the queue once it is full.
+func (s *MilvusStore) Put(m MemoryObject) error {
+	return s.PutContext(context.Background(), m)
+}
+
+// PutContext is Put under the given context.
+func (s *MilvusStore) PutContext(ctx context.Context, m MemoryObject) error {
+	if len(m.Embedding) == 0 {
+		return errors.New("milvus cannot store a memory without an embedding")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// A later put of the same memory replaces the queued one.
+	for i := range s.pending {
+		if s.pending[i].ID == m.ID {
+			s.pending[i] = m
+			return nil
+		}
+	}
+	s.pending = append(s.pending, m)
+	if len(s.pending) < s.batchSize() {
+		return nil
+	}
+	return s.flush(ctx)
+}
+
+// Flush upserts any queued memories.
+func (s *MilvusStore) Flush() error {
+	return s.FlushContext(context.Background())
+}
+
+// FlushContext is Flush under the given context.
+func (s *MilvusStore) FlushContext(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flush(ctx)
+}
+
+// flush upserts the queued memories. The caller must hold s.mu.
+func (s *MilvusStore) flush(ctx context.Context) error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	if err := s.ensurePartition(ctx); err != nil {
+		return err
+	}
+	data := make([]map[string]any, len(s.pending))
+	for i, m := range s.pending {
+		data[i] = toMilvus(m)
+	}
+	body := map[string]any{
+		"collectionName": s.Collection,
+		"partitionName":  s.partitionName(),
+		"data":           data,
+	}
+	if err := s.do(ctx, "/v2/vectordb/entities/upsert", body, nil); err != nil {
+		return fmt.Errorf("failed to upsert memories: %w", err)
+	}
+	s.pending = s.pending[:0]
+	return nil
+}
+
+// Delete removes a memory.
+func (s *MilvusStore) Delete(id string) error {
+	return s.DeleteContext(context.Background(), id)
+}
+
+// DeleteContext is Delete under the given context.
+func (s *MilvusStore) DeleteContext(ctx context.Context, id string) error {
+	if err := s.FlushContext(ctx); err != nil {
+		return err
+	}
+	body := map[string]any{
+		"collectionName": s.Collection,
+		"partitionName":  s.partitionName(),
+		"filter":         "id in [" + strconv.Quote(id) + "]",
+	}
+	return s.do(ctx, "/v2/vectordb/entities/delete", body, nil)
+}
+
+// Search returns the k unarchived, unexpired memories most similar to the query.
+func (s *MilvusStore) Search(query []float32, k int, now time.Time) ([]Match, error) {
+	return s.SearchContext(context.Background(), query, k, now)
+}
+
+// SearchContext is Search under the given context.
+func (s *MilvusStore) SearchContext(ctx context.Context, query []float32, k int, now time.Time) ([]Match, error) {
+	if err := s.FlushContext(ctx); err != nil {
+		return nil, err
+	}
+	cutoff := strconv.FormatFloat(unixSeconds(now), 'f', -1, 64)
+	body := map[string]any{
+		"collectionName": s.Collection,
+		"partitionNames": []string{s.partitionName()},
+		"data":           [][]float32{query},
+		"annsField":      "embedding",
+		"filter":         "archived == false and (expires_at == 0 or expires_at > " + cutoff + ")",
+		"limit":          k,
+		"outputFields":   []string{"id"},
+	}
+	var hits []struct {
+		ID       string  `json:"id"`
+		Distance float32 `json:"distance"`
+	}
+	if err := s.do(ctx, "/v2/vectordb/entities/search", body, &hits); err != nil {
+		return nil, err
+	}
+	// With the cosine metric the distance is the similarity.
+	matches := make([]Match, len(hits))
+	for i, h := range hits {
+		matches[i] = Match{ID: h.ID, Similarity: h.Distance}
+	}
+	return matches, nil
+}
+
+// batchSize returns the upsert batch size, defaulting to 100.
+func (s *MilvusStore) batchSize() int {
+	if s.BatchSize > 0 {
+		return s.BatchSize
+	}
+	return defaultBatchSize
+}
+
+// partitionName returns the agent's partition. Milvus only allows letters,
+// digits and underscores, so the agent's name is hex encoded.
+func (s *MilvusStore) partitionName() string {
+	return "agent_" + hex.EncodeToString([]byte(s.Agent))
+}
+
+// ensurePartition creates the agent's partition if it does not exist. The
+// caller must hold s.mu.
+func (s *MilvusStore) ensurePartition(ctx context.Context) error {
+	if s.partition {
+		return nil
+	}
+	body := map[string]any{"collectionName": s.Collection, "partitionName": s.partitionName()}
+	var has struct {
+		Has bool `json:"has"`
+	}
+	if err := s.do(ctx, "/v2/vectordb/partitions/has", body, &has); err != nil {
+		return fmt.Errorf("failed to check partition: %w", err)
+	}
+	if !has.Has {
+		if err := s.do(ctx, "/v2/vectordb/partitions/create", body, nil); err != nil {
+			return fmt.Errorf("failed to create partition: %w", err)
+		}
+	}
+	s.partition = true
+	return nil
+}
+
+// do posts a request to Milvus and decodes the data of the JSON response into
+// out, if non-nil. Milvus reports most errors in the body with status 200.
+func (s *MilvusStore) do(ctx context.Context, path string, body, out any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(s.Host, "/")+path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+	client := s.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("milvus returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	var result struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("milvus returned code %d: %s", result.Code, result.Message)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(result.Data, out)
+}
+
+// toMilvus converts a memory into a row of the memories collection. Times are
+// stored as Unix seconds, with zero for unset times.
+func toMilvus(m MemoryObject) map[string]any {
+	return map[string]any{
+		"id":                 m.ID,
+		"description":        m.Description,
+		"kind":               m.Kind,
+		"source":             m.Source,
+		"importance":         m.Importance,
+		"creation_time":      unixSeconds(m.CreationTime),
+		"last_accessed_time": unixSeconds(m.LastAccessedTime),
+		"expires_at":         unixSeconds(m.ExpiresAt),
+		"archived":           m.Archived,
+		"norm":               m.Norm,
+		"recurrences":        m.Recurrences,
+		"evidence":           strings.Join(m.Evidence, ","),
+		"strength":           m.Strength,
+		"question":           m.Question,
+		"depth":              m.Depth,
+		"embedding":          m.Embedding,
+	}
+}
+
+// fromMilvus is the inverse of toMilvus.
+func fromMilvus(r map[string]any) MemoryObject {
+	str := func(k string) string { s, _ := r[k].(string); return s }
+	num := func(k string) float64 { n, _ := r[k].(float64); return n }
+	archived, _ := r["archived"].(bool)
+	var embedding []float32
+	if values, ok := r["embedding"].([]any); ok {
+		embedding = make([]float32, len(values))
+		for i, v := range values {
+			f, _ := v.(float64)
+			embedding[i] = float32(f)
+		}
+	}
+	return MemoryObject{
+		ID:               str("id"),
+		Description:      str("description"),
+		Kind:             str("kind"),
+		Source:           str("source"),
+		Importance:       num("importance"),
+		CreationTime:     fromUnixSeconds(num("creation_time")),
+		LastAccessedTime: fromUnixSeconds(num("last_accessed_time")),
+		ExpiresAt:        fromUnixSeconds(num("expires_at")),
+		Archived:         archived,
+		Embedding:        embedding,
+		Norm:             float32(num("norm")),
+		Recurrences:      int(num("recurrences")),
+		Evidence:         splitIDs(str("evidence")),
+		Strength:         num("strength"),
+		Question:         str("question"),
+		Depth:            int(num("depth")),
+	}
+}
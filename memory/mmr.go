@@ -0,0 +1,65 @@
+package memory
+
+// mmrPoolFactor bounds the candidates considered by MMR to this many times the
+// number of results selected.
+const mmrPoolFactor = 5
+
+// topK returns the number of top results that count, defaulting to 10.
+func (ms *MemoryStream) topK() int {
+	if ms.TopK > 0 {
+		return ms.TopK
+	}
+	return defaultTopK
+}
+
+// diversify reorders the top TopK results by Maximal Marginal Relevance: each
+// is chosen to balance its score against its similarity to those already
+// chosen, weighted by Diversity, so a handful of near-identical memories
+// about one event don't crowd out everything else. Later results keep their
+// order.
+func (ms *MemoryStream) diversify(r []RetrievedMemory) []RetrievedMemory {
+	k := ms.topK()
+	pool := min(len(r), k*mmrPoolFactor)
+	if pool < 2 {
+		return r
+	}
+	// Scores are rescaled to [0, 1] to be comparable with similarities.
+	lo, hi := r[pool-1].Score, r[0].Score
+	span := hi - lo
+	if span == 0 {
+		span = 1
+	}
+	dot := ms.kernel()
+	lambda := 1 - ms.Diversity
+
+	out := make([]RetrievedMemory, 0, len(r))
+	chosen := make([]bool, pool)
+	// maxSim[i] is candidate i's greatest similarity to any chosen memory.
+	maxSim := make([]float32, pool)
+	for len(out) < min(k, pool) {
+		best, bestScore := -1, float32(0)
+		for i := 0; i < pool; i++ {
+			if chosen[i] {
+				continue
+			}
+			score := lambda*(r[i].Score-lo)/span - (1-lambda)*maxSim[i]
+			if best < 0 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		chosen[best] = true
+		out = append(out, r[best])
+		for i := 0; i < pool; i++ {
+			a, b := r[i].Memory.Embedding, r[best].Memory.Embedding
+			if !chosen[i] && len(a) == len(b) && len(a) > 0 {
+				maxSim[i] = max(maxSim[i], dot(a, b))
+			}
+		}
+	}
+	for i := 0; i < pool; i++ {
+		if !chosen[i] {
+			out = append(out, r[i])
+		}
+	}
+	return append(out, r[pool:]...)
+}
@@ -0,0 +1,38 @@
+package memory
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDiversify(t *testing.T) {
+	x, y := []float32{1, 0}, []float32{0, 1}
+	retrieved := []RetrievedMemory{
+		{Memory: MemoryObject{ID: "party1", Embedding: x}, Score: 3},
+		{Memory: MemoryObject{ID: "party2", Embedding: x}, Score: 2.9},
+		{Memory: MemoryObject{ID: "party3", Embedding: x}, Score: 2.8},
+		{Memory: MemoryObject{ID: "thesis", Embedding: y}, Score: 2},
+		{Memory: MemoryObject{ID: "beyond", Embedding: y}, Score: 1},
+	}
+	tests := []struct {
+		name      string
+		diversity float32
+		topK      int
+		in        []RetrievedMemory
+		want      []string
+	}{
+		{"no diversity keeps order", 0, 3, retrieved, []string{"party1", "party2", "party3", "thesis", "beyond"}},
+		{"diversity surfaces the distinct memory", 0.7, 2, retrieved, []string{"party1", "thesis", "party2", "party3", "beyond"}},
+		{"single result unchanged", 0.7, 2, retrieved[:1], []string{"party1"}},
+	}
+	for _, tt := range tests {
+		ms := MemoryStream{Diversity: tt.diversity, TopK: tt.topK}
+		var got []string
+		for _, r := range ms.diversify(slices.Clone(tt.in)) {
+			got = append(got, r.Memory.ID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: diversify = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// mongoIndexName is the default name of the Atlas Vector Search index.
+const mongoIndexName = "memory_embedding"
+
+// MongoStore keeps agents' memories as documents in a MongoDB collection. It is
+// both a Store and a VectorIndex: searches use Atlas Vector Search, and the
+// stream re-ranks the matches by recency and importance as usual.
+type MongoStore struct {
+	coll  *mongo.Collection
+	agent string
+	// Index is the name of the vector search index; defaults to memory_embedding.
+	Index string
+}
+
+// MongoShared is a SharedStore keeping every agent's memories in one MongoDB
+// collection, namespaced by agent.
+type MongoShared struct {
+	coll *mongo.Collection
+}
+
+// NewMongoShared creates a shared store over the collection.
+func NewMongoShared(coll *mongo.Collection) *MongoShared {
+	return &MongoShared{coll: coll}
+}
+
+// Namespace returns the store for one agent's memories, which is also a VectorIndex.
+func (s *MongoShared) Namespace(agent string) Store {
+	return &MongoStore{coll: s.coll, agent: agent}
+}
+
+// NewMongoStore creates a store for the agent's memories in the collection.
+func NewMongoStore(coll *mongo.Collection, agent string) *MongoStore {
+	return &MongoStore{coll: coll, agent: agent}
+}
+
+// CreateMongoVectorIndex creates the Atlas Vector Search index used by
+// MongoStore for embeddings of the given length, filterable by agent and
+// archival. Atlas builds the index asynchronously.
+func CreateMongoVectorIndex(coll *mongo.Collection, dimensions int) error {
+	definition := bson.D{{Key: "fields", Value: bson.A{
+		bson.D{{Key: "type", Value: "vector"}, {Key: "path", Value: "embedding"}, {Key: "numDimensions", Value: dimensions}, {Key: "similarity", Value: "cosine"}},
+		bson.D{{Key: "type", Value: "filter"}, {Key: "path", Value: "agent"}},
+		bson.D{{Key: "type", Value: "filter"}, {Key: "path", Value: "archived"}},
+	}}}
+	_, err := coll.SearchIndexes().CreateOne(context.Background(), mongo.SearchIndexModel{
+		Definition: definition,
+		Options:    options.SearchIndexes().SetName(mongoIndexName).SetType("vectorSearch"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create vector search index: %w", err)
+	}
+	return nil
+}
+
+// mongoMemory is a memory as stored in MongoDB. The document ID combines the
+// agent and memory IDs, so agents sharing a collection cannot collide.
+type mongoMemory struct {
+	DocID            string     `bson:"_id"`
+	Agent            string     `bson:"agent"`
+	ID               string     `bson:"id"`
+	Description      string     `bson:"description"`
+	Kind             string     `bson:"kind,omitempty"`
+	Source           string     `bson:"source,omitempty"`
+	Importance       float64    `bson:"importance"`
+	CreationTime     time.Time  `bson:"creation_time"`
+	LastAccessedTime time.Time  `bson:"last_accessed_time"`
+	ExpiresAt        *time.Time `bson:"expires_at,omitempty"`
+	Archived         bool       `bson:"archived"`
+	Embedding        []float32  `bson:"embedding,omitempty"`
+	Norm             float32    `bson:"norm"`
+	Recurrences      int        `bson:"recurrences,omitempty"`
+	Evidence         []string   `bson:"evidence,omitempty"`
+	Strength         float64    `bson:"strength,omitempty"`
+	Question         string     `bson:"question,omitempty"`
+	Depth            int        `bson:"depth,omitempty"`
+}
+
+// Load returns the agent's memories in creation order.
+func (s *MongoStore) Load() ([]MemoryObject, error) {
+	ctx := context.Background()
+	cur, err := s.coll.Find(ctx, bson.D{{Key: "agent", Value: s.agent}},
+		options.Find().SetSort(bson.D{{Key: "creation_time", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query memories: %w", err)
+	}
+	var docs []mongoMemory
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to read memories: %w", err)
+	}
+	memories := make([]MemoryObject, len(docs))
+	for i, d := range docs {
+		memories[i] = d.memory()
+	}
+	return memories, nil
+}
+
+// Put inserts or replaces a memory.
+func (s *MongoStore) Put(m MemoryObject) error {
+	doc := s.toMongo(m)
+	_, err := s.coll.ReplaceOne(context.Background(), bson.D{{Key: "_id", Value: doc.DocID}}, doc,
+		options.Replace().SetUpsert(true))
+	return err
+}
+
+// Delete removes a memory.
+func (s *MongoStore) Delete(id string) error {
+	_, err := s.coll.DeleteOne(context.Background(), bson.D{{Key: "_id", Value: s.docID(id)}})
+	return err
+}
+
+// Search returns the k unarchived memories most similar to the query. Expired
+// memories are left for the stream to filter out.
+func (s *MongoStore) Search(query []float32, k int, now time.Time) ([]Match, error) {
+	index := s.Index
+	if index == "" {
+		index = mongoIndexName
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$vectorSearch", Value: bson.D{
+			{Key: "index", Value: index},
+			{Key: "path", Value: "embedding"},
+			{Key: "queryVector", Value: query},
+			{Key: "numCandidates", Value: k * 10},
+			{Key: "limit", Value: k},
+			{Key: "filter", Value: bson.D{{Key: "agent", Value: s.agent}, {Key: "archived", Value: false}}},
+		}}},
+		{{Key: "$project", Value: bson.D{
+			{Key: "_id", Value: 0},
+			{Key: "id", Value: 1},
+			{Key: "score", Value: bson.D{{Key: "$meta", Value: "vectorSearchScore"}}},
+		}}},
+	}
+	ctx := context.Background()
+	cur, err := s.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var hits []struct {
+		ID    string  `bson:"id"`
+		Score float64 `bson:"score"`
+	}
+	if err := cur.All(ctx, &hits); err != nil {
+		return nil, err
+	}
+	// Atlas scores cosine similarity as (1 + similarity) / 2.
+	matches := make([]Match, len(hits))
+	for i, h := range hits {
+		matches[i] = Match{ID: h.ID, Similarity: float32(2*h.Score - 1)}
+	}
+	return matches, nil
+}
+
+// docID returns the document ID of the agent's memory.
+func (s *MongoStore) docID(id string) string {
+	return s.agent + "/" + id
+}
+
+// toMongo converts a memory into a document.
+func (s *MongoStore) toMongo(m MemoryObject) mongoMemory {
+	d := mongoMemory{
+		DocID:            s.docID(m.ID),
+		Agent:            s.agent,
+		ID:               m.ID,
+		Description:      m.Description,
+		Kind:             m.Kind,
+		Source:           m.Source,
+		Importance:       m.Importance,
+		CreationTime:     m.CreationTime,
+		LastAccessedTime: m.LastAccessedTime,
+		Archived:         m.Archived,
+		Embedding:        m.Embedding,
+		Norm:             m.Norm,
+		Recurrences:      m.Recurrences,
+		Evidence:         m.Evidence,
+		Strength:         m.Strength,
+		Question:         m.Question,
+		Depth:            m.Depth,
+	}
+	if !m.ExpiresAt.IsZero() {
+		d.ExpiresAt = &m.ExpiresAt
+	}
+	return d
+}
+
+// memory is the inverse of toMongo.
+func (d mongoMemory) memory() MemoryObject {
+	m := MemoryObject{
+		ID:               d.ID,
+		Description:      d.Description,
+		Kind:             d.Kind,
+		Source:           d.Source,
+		Importance:       d.Importance,
+		CreationTime:     d.CreationTime,
+		LastAccessedTime: d.LastAccessedTime,
+		Archived:         d.Archived,
+		Embedding:        d.Embedding,
+		Norm:             d.Norm,
+		Recurrences:      d.Recurrences,
+		Evidence:         d.Evidence,
+		Strength:         d.Strength,
+		Question:         d.Question,
+		Depth:            d.Depth,
+	}
+	if d.ExpiresAt != nil {
+		m.ExpiresAt = *d.ExpiresAt
+	}
+	return m
+}
@@ -0,0 +1,299 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// pineconeAPIVersion is the Pinecone data plane API version used.
+const pineconeAPIVersion = "2024-07"
+
+// PineconeStore keeps one agent's memories in a Pinecone index, in a namespace
+// of their own. It is both a Store and a VectorIndex. Memories are stored with
+// their metadata, so searches can be filtered by importance and creation time
+// before recency and importance are combined with relevance in Go.
+type PineconeStore struct {
+	Host      string // Index host, e.g. https://agents-abc123.svc.us-east-1-aws.pinecone.io.
+	APIKey    string
+	Namespace string
+	// MinImportance and CreatedAfter, if set, restrict searches to memories at
+	// least this important or created after this time.
+	MinImportance float64
+	CreatedAfter  time.Time
+	HTTPClient    *http.Client // Defaults to http.DefaultClient.
+}
+
+// PineconeShared is a SharedStore keeping every agent's memories in one
+// Pinecone index, each in its own namespace.
+type PineconeShared struct {
+	Host       string
+	APIKey     string
+	HTTPClient *http.Client // Defaults to http.DefaultClient.
+}
+
+// Namespace returns the store for one agent's memories, which is also a VectorIndex.
+func (s *PineconeShared) Namespace(agent string) Store {
+	return &PineconeStore{Host: s.Host, APIKey: s.APIKey, Namespace: agent, HTTPClient: s.HTTPClient}
+}
+
+// NewPineconeStore creates a store for the agent's memories in the index at host,
+// using the agent's name as the namespace.
+func NewPineconeStore(host, apiKey, agent string) *PineconeStore {
+	return &PineconeStore{Host: host, APIKey: apiKey, Namespace: agent}
+}
+
+// pineconeVector is a vector as sent to and returned by Pinecone.
+type pineconeVector struct {
+	ID       string         `json:"id"`
+	Values   []float32      `json:"values,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// Load returns the agent's memories in creation order.
+func (s *PineconeStore) Load() ([]MemoryObject, error) {
+	return s.LoadContext(context.Background())
+}
+
+// LoadContext is Load under the given context.
+func (s *PineconeStore) LoadContext(ctx context.Context) ([]MemoryObject, error) {
+	var memories []MemoryObject
+	token := ""
+	for {
+		q := url.Values{"namespace": {s.Namespace}}
+		if token != "" {
+			q.Set("paginationToken", token)
+		}
+		var list struct {
+			Vectors []struct {
+				ID string `json:"id"`
+			} `json:"vectors"`
+			Pagination struct {
+				Next string `json:"next"`
+			} `json:"pagination"`
+		}
+		if err := s.do(ctx, http.MethodGet, "/vectors/list?"+q.Encode(), nil, &list); err != nil {
+			return nil, fmt.Errorf("failed to list vectors: %w", err)
+		}
+		if len(list.Vectors) > 0 {
+			fq := url.Values{"namespace": {s.Namespace}}
+			for _, v := range list.Vectors {
+				fq.Add("ids", v.ID)
+			}
+			var fetched struct {
+				Vectors map[string]pineconeVector `json:"vectors"`
+			}
+			if err := s.do(ctx, http.MethodGet, "/vectors/fetch?"+fq.Encode(), nil, &fetched); err != nil {
+				return nil, fmt.Errorf("failed to fetch vectors: %w", err)
+			}
+			for _, v := range list.Vectors {
+				if pv, ok := fetched.Vectors[v.ID]; ok {
+					memories = append(memories, fromPinecone(pv))
+				}
+			}
+		}
+		if list.Pagination.Next == "" {
+			break
+		}
+		token = list.Pagination.Next
+	}
+	sortByCreation(memories)
+	return memories, nil
+}
+
+// Put inserts or replaces a memory.
+func (s *PineconeStore) Put(m MemoryObject) error {
+	return s.PutContext(context.Background(), m)
+}
+
+// PutContext is Put under the given context.
+func (s *PineconeStore) PutContext(ctx context.Context, m MemoryObject) error {
+	if len(m.Embedding) == 0 {
+		return errors.New("pinecone cannot store a memory without an embedding")
+	}
+	body := map[string]any{
+		"namespace": s.Namespace,
+		"vectors":   []pineconeVector{toPinecone(m)},
+	}
+	return s.do(ctx, http.MethodPost, "/vectors/upsert", body, nil)
+}
+
+// Delete removes a memory.
+func (s *PineconeStore) Delete(id string) error {
+	return s.DeleteContext(context.Background(), id)
+}
+
+// DeleteContext is Delete under the given context.
+func (s *PineconeStore) DeleteContext(ctx context.Context, id string) error {
+	body := map[string]any{
+		"namespace": s.Namespace,
+		"ids":       []string{id},
+	}
+	return s.do(ctx, http.MethodPost, "/vectors/delete", body, nil)
+}
+
+// Search returns the k unarchived, unexpired memories most similar to the query
+// that pass the store's importance and creation time filters.
+func (s *PineconeStore) Search(query []float32, k int, now time.Time) ([]Match, error) {
+	return s.SearchContext(context.Background(), query, k, now)
+}
+
+// SearchContext is Search under the given context.
+func (s *PineconeStore) SearchContext(ctx context.Context, query []float32, k int, now time.Time) ([]Match, error) {
+	filter := []map[string]any{
+		{"archived": map[string]any{"$eq": false}},
+		{"$or": []map[string]any{
+			{"expires_at": map[string]any{"$eq": 0}},
+			{"expires_at": map[string]any{"$gt": unixSeconds(now)}},
+		}},
+	}
+	if s.MinImportance > 0 {
+		filter = append(filter, map[string]any{"importance": map[string]any{"$gte": s.MinImportance}})
+	}
+	if !s.CreatedAfter.IsZero() {
+		filter = append(filter, map[string]any{"creation_time": map[string]any{"$gt": unixSeconds(s.CreatedAfter)}})
+	}
+	body := map[string]any{
+		"namespace": s.Namespace,
+		"vector":    query,
+		"topK":      k,
+		"filter":    map[string]any{"$and": filter},
+	}
+	var resp struct {
+		Matches []struct {
+			ID    string  `json:"id"`
+			Score float32 `json:"score"`
+		} `json:"matches"`
+	}
+	if err := s.do(ctx, http.MethodPost, "/query", body, &resp); err != nil {
+		return nil, err
+	}
+	matches := make([]Match, len(resp.Matches))
+	for i, m := range resp.Matches {
+		matches[i] = Match{ID: m.ID, Similarity: m.Score}
+	}
+	return matches, nil
+}
+
+// do sends a request to the index and decodes the JSON response into out, if non-nil.
+func (s *PineconeStore) do(ctx context.Context, method, path string, body, out any) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.Host+path, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Api-Key", s.APIKey)
+	req.Header.Set("X-Pinecone-API-Version", pineconeAPIVersion)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	client := s.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("pinecone returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// toPinecone converts a memory into a vector with its fields as metadata.
+// Times are stored as Unix seconds so they can be filtered on, with zero for
+// unset times.
+func toPinecone(m MemoryObject) pineconeVector {
+	v := pineconeVector{
+		ID:     m.ID,
+		Values: m.Embedding,
+		Metadata: map[string]any{
+			"description":        m.Description,
+			"kind":               m.Kind,
+			"source":             m.Source,
+			"importance":         m.Importance,
+			"creation_time":      unixSeconds(m.CreationTime),
+			"last_accessed_time": unixSeconds(m.LastAccessedTime),
+			"expires_at":         unixSeconds(m.ExpiresAt),
+			"archived":           m.Archived,
+			"norm":               m.Norm,
+			"recurrences":        m.Recurrences,
+			"strength":           m.Strength,
+			"question":           m.Question,
+			"depth":              m.Depth,
+		},
+	}
+	// Pinecone rejects null metadata values.
+	if len(m.Evidence) > 0 {
+		v.Metadata["evidence"] = m.Evidence
+	}
+	return v
+}
+
+// fromPinecone is the inverse of toPinecone.
+func fromPinecone(v pineconeVector) MemoryObject {
+	str := func(k string) string { s, _ := v.Metadata[k].(string); return s }
+	num := func(k string) float64 { n, _ := v.Metadata[k].(float64); return n }
+	archived, _ := v.Metadata["archived"].(bool)
+	var evidence []string
+	if ids, ok := v.Metadata["evidence"].([]any); ok {
+		for _, id := range ids {
+			if s, ok := id.(string); ok {
+				evidence = append(evidence, s)
+			}
+		}
+	}
+	return MemoryObject{
+		ID:               v.ID,
+		Description:      str("description"),
+		Kind:             str("kind"),
+		Source:           str("source"),
+		Importance:       num("importance"),
+		CreationTime:     fromUnixSeconds(num("creation_time")),
+		LastAccessedTime: fromUnixSeconds(num("last_accessed_time")),
+		ExpiresAt:        fromUnixSeconds(num("expires_at")),
+		Archived:         archived,
+		Embedding:        v.Values,
+		Norm:             float32(num("norm")),
+		Recurrences:      int(num("recurrences")),
+		Evidence:         evidence,
+		Strength:         num("strength"),
+		Question:         str("question"),
+		Depth:            int(num("depth")),
+	}
+}
+
+// unixSeconds returns the time as fractional Unix seconds, or zero if unset.
+func unixSeconds(t time.Time) float64 {
+	if t.IsZero() {
+		return 0
+	}
+	return float64(t.UnixNano()) / 1e9
+}
+
+// fromUnixSeconds is the inverse of unixSeconds.
+func fromUnixSeconds(s float64) time.Time {
+	if s == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(s*1e9))
+}
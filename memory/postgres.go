@@ -0,0 +1,214 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PostgresStore keeps one agent's memories in Postgres and searches them with
+// the pgvector extension. It is both a Store and a VectorIndex, so setting it
+// as a stream's Store and Index pushes similarity search into the database.
+// The caller opens the database with a Postgres driver such as
+// github.com/jackc/pgx/v5/stdlib or github.com/lib/pq.
+type PostgresStore struct {
+	db    *sql.DB
+	agent string
+}
+
+// PostgresShared is a SharedStore keeping every agent's memories in one
+// Postgres database, namespaced by agent.
+type PostgresShared struct {
+	db *sql.DB
+}
+
+// NewPostgresShared creates a shared store, creating the schema as
+// NewPostgresStore does.
+func NewPostgresShared(db *sql.DB, dimensions int) (*PostgresShared, error) {
+	if err := createPostgresSchema(db, dimensions); err != nil {
+		return nil, err
+	}
+	return &PostgresShared{db: db}, nil
+}
+
+// Namespace returns the store for one agent's memories, which is also a VectorIndex.
+func (s *PostgresShared) Namespace(agent string) Store {
+	return &PostgresStore{db: s.db, agent: agent}
+}
+
+// NewPostgresStore creates a store for the agent's memories. It enables the
+// vector extension and creates the memories table and its cosine index if they
+// do not exist. Dimensions is the length of the stored embeddings.
+func NewPostgresStore(db *sql.DB, agent string, dimensions int) (*PostgresStore, error) {
+	if err := createPostgresSchema(db, dimensions); err != nil {
+		return nil, err
+	}
+	return &PostgresStore{db: db, agent: agent}, nil
+}
+
+// postgresColumns are the columns added to the memories table since it was
+// first released, which tables created before them are migrated to have.
+var postgresColumns = []column{
+	{"recurrences", "INTEGER NOT NULL DEFAULT 0"},
+	{"evidence", "TEXT[] NOT NULL DEFAULT '{}'"},
+	{"strength", "DOUBLE PRECISION NOT NULL DEFAULT 0"},
+	{"question", "TEXT NOT NULL DEFAULT ''"},
+	{"depth", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// createPostgresSchema creates the vector extension, memories table and index,
+// adding any columns an existing table lacks.
+func createPostgresSchema(db *sql.DB, dimensions int) error {
+	stmts := []string{
+		`CREATE EXTENSION IF NOT EXISTS vector`,
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS memories (
+	id TEXT NOT NULL,
+	agent TEXT NOT NULL,
+	description TEXT NOT NULL,
+	kind TEXT NOT NULL DEFAULT '',
+	source TEXT NOT NULL DEFAULT '',
+	importance DOUBLE PRECISION NOT NULL,
+	creation_time TIMESTAMPTZ,
+	last_accessed_time TIMESTAMPTZ,
+	expires_at TIMESTAMPTZ,
+	archived BOOLEAN NOT NULL DEFAULT FALSE,
+	embedding vector(%d),
+	norm REAL NOT NULL DEFAULT 0,
+	recurrences INTEGER NOT NULL DEFAULT 0,
+	evidence TEXT[] NOT NULL DEFAULT '{}',
+	strength DOUBLE PRECISION NOT NULL DEFAULT 0,
+	question TEXT NOT NULL DEFAULT '',
+	depth INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (agent, id)
+)`, dimensions),
+		`CREATE INDEX IF NOT EXISTS memories_embedding_idx ON memories USING hnsw (embedding vector_cosine_ops)`,
+	}
+	for _, c := range postgresColumns {
+		stmts = append(stmts, fmt.Sprintf(`ALTER TABLE memories ADD COLUMN IF NOT EXISTS %s %s`, c.name, c.definition))
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to create memories schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// Load returns the agent's memories in creation order.
+func (s *PostgresStore) Load() ([]MemoryObject, error) {
+	rows, err := s.db.Query(`SELECT id, description, kind, source, importance, creation_time, last_accessed_time, expires_at, archived, embedding::text, norm, recurrences, array_to_string(evidence, ','), strength, question, depth
+FROM memories WHERE agent = $1 ORDER BY creation_time`, s.agent)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var memories []MemoryObject
+	for rows.Next() {
+		var m MemoryObject
+		var created, accessed, expires sql.NullTime
+		var embedding sql.NullString
+		var evidence string
+		if err := rows.Scan(&m.ID, &m.Description, &m.Kind, &m.Source, &m.Importance, &created, &accessed, &expires, &m.Archived, &embedding, &m.Norm, &m.Recurrences, &evidence, &m.Strength, &m.Question, &m.Depth); err != nil {
+			return nil, err
+		}
+		m.CreationTime = created.Time
+		m.LastAccessedTime = accessed.Time
+		m.ExpiresAt = expires.Time
+		if m.Embedding, err = parseVector(embedding.String); err != nil {
+			return nil, fmt.Errorf("memory %s: %w", m.ID, err)
+		}
+		m.Evidence = splitIDs(evidence)
+		memories = append(memories, m)
+	}
+	return memories, rows.Err()
+}
+
+// Put inserts or replaces a memory.
+func (s *PostgresStore) Put(m MemoryObject) error {
+	_, err := s.db.Exec(`INSERT INTO memories (id, agent, description, kind, source, importance, creation_time, last_accessed_time, expires_at, archived, embedding, norm, recurrences, evidence, strength, question, depth)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11::vector, $12, $13, string_to_array(NULLIF($14, ''), ','), $15, $16, $17)
+ON CONFLICT (agent, id) DO UPDATE SET
+	description = EXCLUDED.description,
+	kind = EXCLUDED.kind,
+	source = EXCLUDED.source,
+	importance = EXCLUDED.importance,
+	creation_time = EXCLUDED.creation_time,
+	last_accessed_time = EXCLUDED.last_accessed_time,
+	expires_at = EXCLUDED.expires_at,
+	archived = EXCLUDED.archived,
+	embedding = EXCLUDED.embedding,
+	norm = EXCLUDED.norm,
+	recurrences = EXCLUDED.recurrences,
+	evidence = EXCLUDED.evidence,
+	strength = EXCLUDED.strength,
+	question = EXCLUDED.question,
+	depth = EXCLUDED.depth`,
+		m.ID, s.agent, m.Description, m.Kind, m.Source, m.Importance,
+		nullTime(m.CreationTime), nullTime(m.LastAccessedTime), nullTime(m.ExpiresAt),
+		m.Archived, formatVector(m.Embedding), m.Norm, m.Recurrences, strings.Join(m.Evidence, ","), m.Strength, m.Question, m.Depth)
+	return err
+}
+
+// Delete removes a memory.
+func (s *PostgresStore) Delete(id string) error {
+	_, err := s.db.Exec(`DELETE FROM memories WHERE agent = $1 AND id = $2`, s.agent, id)
+	return err
+}
+
+// Search returns the k unarchived, unexpired memories nearest to the query by
+// cosine distance.
+func (s *PostgresStore) Search(query []float32, k int, now time.Time) ([]Match, error) {
+	rows, err := s.db.Query(`SELECT id, 1 - (embedding <=> $1::vector) FROM memories
+WHERE agent = $2 AND NOT archived AND (expires_at IS NULL OR expires_at > $3) AND embedding IS NOT NULL
+ORDER BY embedding <=> $1::vector LIMIT $4`, formatVector(query), s.agent, now, k)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var matches []Match
+	for rows.Next() {
+		var m Match
+		if err := rows.Scan(&m.ID, &m.Similarity); err != nil {
+			return nil, err
+		}
+		matches = append(matches, m)
+	}
+	return matches, rows.Err()
+}
+
+// nullTime stores a zero time as NULL.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// formatVector renders an embedding in pgvector's text format, or NULL if empty.
+func formatVector(v []float32) sql.NullString {
+	if len(v) == 0 {
+		return sql.NullString{}
+	}
+	parts := make([]string, len(v))
+	for i, x := range v {
+		parts[i] = strconv.FormatFloat(float64(x), 'g', -1, 32)
+	}
+	return sql.NullString{String: "[" + strings.Join(parts, ",") + "]", Valid: true}
+}
+
+// parseVector is the inverse of formatVector.
+func parseVector(s string) ([]float32, error) {
+	s = strings.Trim(s, "[] ")
+	if s == "" {
+		return nil, nil
+	}
+	parts := strings.Split(s, ",")
+	v := make([]float32, len(parts))
+	for i, p := range parts {
+		x, err := strconv.ParseFloat(strings.TrimSpace(p), 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vector: %w", err)
+		}
+		v[i] = float32(x)
+	}
+	return v, nil
+}
@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/lordtatty/a25/llm"
+	"github.com/sashabaranov/go-openai"
+)
+
+// Redactor masks sensitive content in a memory before it is embedded or stored.
+type Redactor interface {
+	Redact(text string) (string, error)
+}
+
+// ContextRedactor is a Redactor whose requests can be cancelled with a context.
+type ContextRedactor interface {
+	Redactor
+	RedactContext(ctx context.Context, text string) (string, error)
+}
+
+// redactContext redacts the text with r, under ctx if r supports it.
+func redactContext(ctx context.Context, r Redactor, text string) (string, error) {
+	if cr, ok := r.(ContextRedactor); ok {
+		return cr.RedactContext(ctx, text)
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return r.Redact(text)
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	phonePattern = regexp.MustCompile(`\+?\d[\d\s().\-]{7,}\d`)
+)
+
+// PatternRedactor masks email addresses, phone numbers and blocklisted names
+// using regular expressions.
+type PatternRedactor struct {
+	Names []string // Names to mask, matched case-insensitively as whole words.
+	Mask  string   // Replacement text; defaults to "[REDACTED]".
+}
+
+// Redact masks the matching content in text.
+func (r *PatternRedactor) Redact(text string) (string, error) {
+	mask := r.Mask
+	if mask == "" {
+		mask = "[REDACTED]"
+	}
+	text = emailPattern.ReplaceAllLiteralString(text, mask)
+	text = phonePattern.ReplaceAllLiteralString(text, mask)
+	for _, n := range r.Names {
+		re, err := regexp.Compile(`(?i)\b` + regexp.QuoteMeta(n) + `\b`)
+		if err != nil {
+			return "", err
+		}
+		text = re.ReplaceAllLiteralString(text, mask)
+	}
+	return text, nil
+}
+
+// LLMRedactor asks the language model to mask personal information that
+// patterns can't catch, such as addresses or names not on a blocklist.
+type LLMRedactor struct {
+	Client      OpenAIClient
+	Model       string   // Chat model; defaults to GPT-4o mini.
+	Temperature *float32 // Sampling temperature, set with llm.Temp; defaults to 1.
+}
+
+// model returns the chat model, defaulting to GPT-4o mini.
+func (r *LLMRedactor) model() string {
+	return llm.Model(r.Model)
+}
+
+// temperature returns the sampling temperature, defaulting to 1.
+func (r *LLMRedactor) temperature() float32 {
+	return llm.Temperature(r.Temperature)
+}
+
+// Redact returns text with personal information replaced by "[REDACTED]".
+func (r *LLMRedactor) Redact(text string) (string, error) {
+	return r.RedactContext(context.Background(), text)
+}
+
+// RedactContext is Redact under the given context.
+func (r *LLMRedactor) RedactContext(ctx context.Context, text string) (string, error) {
+	sysPrompt := "Replace any personal information in the user's text (email addresses, phone numbers, street addresses, identification numbers and the full names of real people) with [REDACTED].  Output the redacted text only, otherwise unchanged."
+	resp, err := r.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: r.model(),
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: sysPrompt},
+			{Role: "user", Content: text},
+		},
+		Temperature: r.temperature(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
+
+// Redactors applies several redactors in order, e.g. cheap patterns first and
+// the LLM last.
+type Redactors []Redactor
+
+// Redact runs text through every redactor in turn.
+func (rs Redactors) Redact(text string) (string, error) {
+	return rs.RedactContext(context.Background(), text)
+}
+
+// RedactContext is Redact under the given context.
+func (rs Redactors) RedactContext(ctx context.Context, text string) (string, error) {
+	for _, r := range rs {
+		var err error
+		if text, err = redactContext(ctx, r, text); err != nil {
+			return "", err
+		}
+	}
+	return text, nil
+}
@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lordtatty/a25/llm"
+	"github.com/sashabaranov/go-openai"
+)
+
+// echoClient answers chat completions with the user's message, recording the
+// request.
+type echoClient struct {
+	OpenAIClient
+	req openai.ChatCompletionRequest
+}
+
+func (c *echoClient) CreateChatCompletion(_ context.Context, req openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error) {
+	c.req = req
+	return &openai.ChatCompletionResponse{Choices: []openai.ChatCompletionChoice{{Message: req.Messages[len(req.Messages)-1]}}}, nil
+}
+
+func TestLLMRedactorModel(t *testing.T) {
+	tests := []struct {
+		name        string
+		r           LLMRedactor
+		model       string
+		temperature float32
+	}{
+		{"defaults", LLMRedactor{}, openai.GPT4oMini, 1},
+		{"configured", LLMRedactor{Model: "small-model", Temperature: llm.Temp(0.2)}, "small-model", 0.2},
+	}
+	for _, tt := range tests {
+		client := &echoClient{}
+		tt.r.Client = client
+		if _, err := tt.r.Redact("Call me on 555 0100"); err != nil {
+			t.Fatal(err)
+		}
+		if client.req.Model != tt.model || client.req.Temperature != tt.temperature {
+			t.Errorf("%s: requested %q at %v, want %q at %v", tt.name, client.req.Model, client.req.Temperature, tt.model, tt.temperature)
+		}
+	}
+}
+
+func TestPatternRedactor(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Email klaus@example.com today", "Email [REDACTED] today"},
+		{"Call +1 (555) 010-0199 now", "Call [REDACTED] now"},
+		{"klaus met Maria", "[REDACTED] met Maria"},
+		{"Klausner is not Klaus", "Klausner is not [REDACTED]"},
+	}
+	r := &PatternRedactor{Names: []string{"Klaus"}}
+	for _, tt := range tests {
+		if got, _ := r.Redact(tt.text); got != tt.want {
+			t.Errorf("Redact(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/lordtatty/a25/llm"
+	"github.com/sashabaranov/go-openai"
+)
+
+// defaultRerankCandidates is the number of top candidates re-ranked by default.
+const defaultRerankCandidates = 30
+
+// Reranker orders retrieval candidates by their true relevance to the query,
+// returning their indexes from most to least relevant. Candidates left out
+// are judged irrelevant and dropped from the results.
+type Reranker interface {
+	Rerank(query string, candidates []RetrievedMemory) ([]int, error)
+}
+
+// ContextReranker is a Reranker whose requests can be cancelled with a context.
+type ContextReranker interface {
+	Reranker
+	RerankContext(ctx context.Context, query string, candidates []RetrievedMemory) ([]int, error)
+}
+
+// rerankContext re-ranks the candidates with r, under ctx if r supports it.
+func rerankContext(ctx context.Context, r Reranker, query string, candidates []RetrievedMemory) ([]int, error) {
+	if cr, ok := r.(ContextReranker); ok {
+		return cr.RerankContext(ctx, query, candidates)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.Rerank(query, candidates)
+}
+
+// reranker returns the stream's Reranker, defaulting to the language model.
+func (ms *MemoryStream) reranker() Reranker {
+	if ms.Reranker != nil {
+		return ms.Reranker
+	}
+	return &LLMReranker{Client: ms.Client, Model: ms.Model, Temperature: ms.Temperature}
+}
+
+// RetrieveReranked retrieves memories by score, then re-ranks the top
+// candidates with the Reranker, or the language model if none is set. It
+// costs an extra call, so it is best kept for high-stakes prompts such as
+// interviews.
+func (ms *MemoryStream) RetrieveReranked(query string) ([]RetrievedMemory, error) {
+	return ms.RetrieveRerankedContext(context.Background(), query)
+}
+
+// RetrieveRerankedContext is RetrieveReranked under the given context.
+func (ms *MemoryStream) RetrieveRerankedContext(ctx context.Context, query string) ([]RetrievedMemory, error) {
+	retrieved, err := ms.retrieveMemories(ctx, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	reranked, err := ms.rerankTop(ctx, ms.reranker(), query, retrieved)
+	if err != nil {
+		return nil, err
+	}
+	if err := ms.touch(ctx, reranked); err != nil {
+		return nil, err
+	}
+	ms.retrieved(query, reranked)
+	return reranked, nil
+}
+
+// rerankTop re-ranks the top RerankCandidates of the retrieved memories,
+// dropping those the reranker leaves out. Memories beyond the candidates were
+// not judged and follow the ranked ones.
+func (ms *MemoryStream) rerankTop(ctx context.Context, reranker Reranker, query string, retrieved []RetrievedMemory) ([]RetrievedMemory, error) {
+	n := ms.RerankCandidates
+	if n <= 0 {
+		n = defaultRerankCandidates
+	}
+	n = min(n, len(retrieved))
+	if n < 2 {
+		return retrieved, nil
+	}
+	order, err := rerankContext(ctx, reranker, query, retrieved[:n])
+	if err != nil {
+		return nil, fmt.Errorf("failed to rerank memories: %w", err)
+	}
+
+	reranked := make([]RetrievedMemory, 0, len(retrieved))
+	used := make([]bool, n)
+	for _, i := range order {
+		if i >= 0 && i < n && !used[i] {
+			used[i] = true
+			reranked = append(reranked, retrieved[i])
+		}
+	}
+	return append(reranked, retrieved[n:]...), nil
+}
+
+// LLMReranker re-ranks candidates by asking a chat model to order them.
+type LLMReranker struct {
+	Client      OpenAIClient
+	Model       string   // Chat model; defaults to GPT-4o mini.
+	Temperature *float32 // Sampling temperature, set with llm.Temp; defaults to 1.
+}
+
+// model returns the chat model, defaulting to GPT-4o mini.
+func (r *LLMReranker) model() string {
+	return llm.Model(r.Model)
+}
+
+// temperature returns the sampling temperature, defaulting to 1.
+func (r *LLMReranker) temperature() float32 {
+	return llm.Temperature(r.Temperature)
+}
+
+// Rerank asks the model to order the candidates by relevance.
+func (r *LLMReranker) Rerank(query string, candidates []RetrievedMemory) ([]int, error) {
+	return r.RerankContext(context.Background(), query, candidates)
+}
+
+// RerankContext is Rerank under the given context.
+func (r *LLMReranker) RerankContext(ctx context.Context, query string, candidates []RetrievedMemory) ([]int, error) {
+	var lines []string
+	for i, c := range candidates {
+		lines = append(lines, fmt.Sprintf("%d. %s", i+1, c.Memory.Description))
+	}
+	sysPrompt := "Rank the numbered statements by how relevant they are to the query, most relevant first.  Output the statement numbers only, separated by commas, e.g., 3, 1, 2.  Leave out statements that are not relevant."
+	usrPrompt := fmt.Sprintf("Query: %s\nStatements:\n%s", query, strings.Join(lines, "\n"))
+
+	resp, err := r.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: r.model(),
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: sysPrompt},
+			{Role: "user", Content: usrPrompt},
+		},
+		Temperature: r.temperature(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseRanking(resp.Choices[0].Message.Content, len(candidates)), nil
+}
+
+// parseRanking extracts zero-based indexes from a comma separated ranking,
+// ignoring anything out of range.
+func parseRanking(response string, n int) []int {
+	var order []int
+	for _, f := range strings.FieldsFunc(response, func(r rune) bool { return r == ',' || r == ' ' || r == '\n' }) {
+		i, err := strconv.Atoi(strings.Trim(f, ".()[]"))
+		if err != nil || i < 1 || i > n {
+			continue
+		}
+		order = append(order, i-1)
+	}
+	return order
+}
+
+// CrossEncoderReranker re-ranks candidates with a cross-encoder served by a
+// rerank API in Cohere's format, as offered by Cohere, Jina and Voyage and by
+// self-hosted servers. It is cheaper and faster than LLMReranker.
+type CrossEncoderReranker struct {
+	URL    string // e.g. https://api.cohere.com/v2/rerank.
+	APIKey string
+	Model  string // e.g. rerank-english-v3.0.
+	// MinScore, if set, drops candidates scoring below it from the results.
+	MinScore   float64
+	HTTPClient *http.Client // Defaults to http.DefaultClient.
+}
+
+// Rerank scores every candidate against the query.
+func (r *CrossEncoderReranker) Rerank(query string, candidates []RetrievedMemory) ([]int, error) {
+	return r.RerankContext(context.Background(), query, candidates)
+}
+
+// RerankContext is Rerank under the given context.
+func (r *CrossEncoderReranker) RerankContext(ctx context.Context, query string, candidates []RetrievedMemory) ([]int, error) {
+	documents := make([]string, len(candidates))
+	for i, c := range candidates {
+		documents[i] = c.Memory.Description
+	}
+	body, err := json.Marshal(map[string]any{"model": r.Model, "query": query, "documents": documents})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.APIKey)
+	}
+	client := r.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("rerank API returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	var result struct {
+		Results []struct {
+			Index          int     `json:"index"`
+			RelevanceScore float64 `json:"relevance_score"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	// Results come most relevant first.
+	var order []int
+	for _, res := range result.Results {
+		if res.RelevanceScore >= r.MinScore {
+			order = append(order, res.Index)
+		}
+	}
+	return order, nil
+}
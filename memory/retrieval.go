@@ -1,68 +1,257 @@
 package memory
 
 import (
-	"math"
+	"context"
+	"slices"
 	"sort"
-	"time"
+	"sync"
 )
 
-// RetrievedMemory pairs a memory with its retrieval score.
+// RetrievedMemory pairs a memory with its retrieval score. Score is the
+// weighted sum of the unweighted Relevance, Recency and Importance components.
 type RetrievedMemory struct {
-	Memory MemoryObject
-	Score  float32
+	Memory     MemoryObject
+	Score      float32
+	Relevance  float32 // Cosine similarity to the query.
+	Recency    float32 // Exponential decay since last access, from 1 down to 0.
+	Importance float32 // Importance scaled to [0, 1].
+}
+
+// Weights scale the components of a memory's retrieval score.
+type Weights struct {
+	Relevance  float32
+	Recency    float32
+	Importance float32
+}
+
+// weights returns the stream's retrieval weights, weighing every component
+// equally when none are set.
+func (ms *MemoryStream) weights() Weights {
+	if ms.Weights == (Weights{}) {
+		return Weights{Relevance: 1, Recency: 1, Importance: 1}
+	}
+	return ms.Weights
 }
 
 // RetrieveMemories retrieves relevant memories based on a query.
 func (ms *MemoryStream) RetrieveMemories(query string) ([]RetrievedMemory, error) {
+	return ms.RetrieveMemoriesContext(context.Background(), query)
+}
+
+// RetrieveMemoriesContext is RetrieveMemories under the given context, which
+// bounds the requests made to embed and expand the query.
+func (ms *MemoryStream) RetrieveMemoriesContext(ctx context.Context, query string) ([]RetrievedMemory, error) {
+	return ms.retrieveWhere(ctx, query, nil)
+}
+
+// retrieveWhere implements RetrieveMemoriesContext, considering only the
+// memories keep accepts if it is set, so that others are neither ranked nor
+// touched.
+func (ms *MemoryStream) retrieveWhere(ctx context.Context, query string, keep func(MemoryObject) bool) ([]RetrievedMemory, error) {
+	r, err := ms.retrieveMemories(ctx, query, keep)
+	if err != nil {
+		return nil, err
+	}
+	if ms.Diversity > 0 {
+		r = ms.diversify(r)
+	}
+	if ms.Reranker != nil {
+		if r, err = ms.rerankTop(ctx, ms.Reranker, query, r); err != nil {
+			return nil, err
+		}
+	}
+	if err := ms.touch(ctx, r); err != nil {
+		return nil, err
+	}
+	ms.retrieved(query, r)
+	return r, nil
+}
+
+// AccessPolicy decides which memories count as accessed by a retrieval, which
+// resets their recency.
+type AccessPolicy int
+
+const (
+	// TouchTopK marks the top TopK retrieved memories as accessed.
+	TouchTopK AccessPolicy = iota
+	// TouchAll marks every memory in the stream as accessed.
+	TouchAll
+	// TouchNone leaves access times alone.
+	TouchNone
+)
+
+// defaultTopK is the number of top results counted as accessed by default.
+const defaultTopK = 10
+
+// touch updates the last access time of the memories the retrieval accessed,
+// writing them to the Store.
+func (ms *MemoryStream) touch(ctx context.Context, r []RetrievedMemory) error {
+	if ms.Access == TouchNone {
+		return nil
+	}
+	k := ms.topK()
+	ids := make(map[string]bool, k)
+	for _, m := range r[:min(k, len(r))] {
+		ids[m.Memory.ID] = true
+	}
+	now := ms.now()
+	timeScale := ms.timeScale()
+	var touched []MemoryObject
+	ms.mu.Lock()
+	for i := range ms.memories {
+		if ms.Access == TouchAll || ids[ms.memories[i].ID] {
+			if ms.SpacedRepetition {
+				ms.memories[i].strengthen(now, timeScale)
+			}
+			ms.memories[i].LastAccessedTime = now
+			touched = append(touched, ms.memories[i])
+		}
+	}
+	if len(touched) > 0 {
+		ms.accesses++
+	}
+	ms.mu.Unlock()
+	for _, m := range touched {
+		if err := ms.persist(ctx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retrieveMemories implements RetrieveMemories for the memories keep accepts,
+// or all of them if it is nil, serving unfiltered retrievals from the cache
+// when it can.
+func (ms *MemoryStream) retrieveMemories(ctx context.Context, query string, keep func(MemoryObject) bool) ([]RetrievedMemory, error) {
+	if keep == nil {
+		ms.mu.Lock()
+		r, ok := ms.cached(query)
+		ms.mu.Unlock()
+		if ok {
+			return r, nil
+		}
+	}
+	if ms.ExpandQueries > 0 {
+		return ms.retrieveExpanded(ctx, query, ms.ExpandQueries, keep)
+	}
+	if ms.RewriteQueries {
+		return ms.retrieveExpanded(ctx, query, 1, keep)
+	}
 	// Compute the embedding for the query.
-	queryEmbedding, err := getEmbedding(query, ms.Client)
+	queryEmbedding, err := ms.embed(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	queryEmbedding, _ = normalize(queryEmbedding)
+	if err := ms.backfillFor(ctx, queryEmbedding); err != nil {
+		return nil, err
+	}
+	matches, err := ms.search(ctx, queryEmbedding, keep)
 	if err != nil {
 		return nil, err
 	}
 
-	var retrieved []RetrievedMemory
-	for i, memory := range ms.Memories {
-		// Compute the embedding for the memory.
-		memoryEmbedding, err := getEmbedding(memory.Description, ms.Client)
-		if err != nil {
-			return nil, err
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	r := ms.retrieve(queryEmbedding, matches, keep)
+	if keep == nil {
+		ms.store(query, r)
+	}
+	return r, nil
+}
+
+// backfillFor embeds the stored memories without an embedding, and mismatched
+// ones if ReembedOnMismatch is set, ahead of scoring them against the query
+// embedding. With an Index, candidates come from it instead. It must be called
+// without the lock held.
+func (ms *MemoryStream) backfillFor(ctx context.Context, queryEmbedding []float32) error {
+	if ms.Index != nil {
+		return nil
+	}
+	dim := 0
+	if ms.ReembedOnMismatch {
+		dim = len(queryEmbedding)
+	}
+	_, err := ms.backfill(ctx, dim, 0)
+	return err
+}
+
+// retrieve scores every unexpired, unarchived memory that keep accepts, if it
+// is set, against a unit-length query embedding, or only the matches found by
+// searching the Index if there is one. It must be called with the lock held.
+func (ms *MemoryStream) retrieve(queryEmbedding []float32, matches []Match, keep func(MemoryObject) bool) []RetrievedMemory {
+	idx, indexed := ms.candidates(matches, keep)
+
+	// Without an index, stored embeddings are scanned from a contiguous slab of
+	// unit vectors, after backfillFor has embedded any memories without one.
+	if indexed == nil {
+		if ms.slab.stale(ms) {
+			ms.slab = buildSlab(ms)
 		}
-		// Compute relevance as cosine similarity.
-		relevance := cosineSimilarity(queryEmbedding, memoryEmbedding)
-		// Compute recency score.
-		hoursSinceAccess := time.Since(memory.LastAccessedTime).Hours()
-		recencyScore := float32(math.Exp(-hoursSinceAccess / 24.0)) // Decay over one day.
-		// Normalize importance to [0,1].
-		importanceScore := memory.Importance / 10.0 // Assuming importance is between 0 and 10.
-		// Total score.
-		totalScore := relevance + recencyScore + float32(importanceScore)
-
-		retrieved = append(retrieved, RetrievedMemory{
-			Memory: memory,
-			Score:  totalScore,
-		})
-		// Update last accessed time.
-		ms.Memories[i].LastAccessedTime = time.Now()
 	}
 
+	dot := ms.kernel()
+	w := ms.weights()
+	timeScale := ms.timeScale()
+	now := ms.now()
+	retrieved := make([]RetrievedMemory, len(idx))
+	ms.parallel(len(idx), func(lo, hi int) {
+		for j := lo; j < hi; j++ {
+			i := idx[j]
+			memory := ms.memories[i]
+			// Compute relevance as cosine similarity, which for unit vectors is the dot product.
+			var relevance float32
+			if indexed != nil {
+				relevance = indexed[i]
+			} else {
+				if row := ms.slab.row(i); len(row) == len(queryEmbedding) {
+					relevance = dot(queryEmbedding, row)
+				}
+			}
+			recencyScore := float32(memory.retention(now, timeScale))
+			// Normalize importance to [0,1].
+			importanceScore := memory.Importance / 10.0 // Assuming importance is between 0 and 10.
+			// Total score.
+			totalScore := w.Relevance*relevance + w.Recency*recencyScore + w.Importance*float32(importanceScore)
+
+			retrieved[j] = RetrievedMemory{
+				Memory:     memory,
+				Score:      totalScore,
+				Relevance:  relevance,
+				Recency:    recencyScore,
+				Importance: float32(importanceScore),
+			}
+		}
+	})
+
+	retrieved = slices.DeleteFunc(retrieved, func(r RetrievedMemory) bool {
+		return r.Memory.Archived || r.Memory.Expired(now)
+	})
+
 	// Sort retrieved memories by score in descending order.
 	sort.Slice(retrieved, func(i, j int) bool {
 		return retrieved[i].Score > retrieved[j].Score
 	})
 
-	return retrieved, nil
+	return retrieved
 }
 
-// cosineSimilarity computes the cosine similarity between two vectors.
-func cosineSimilarity(a, b []float32) float32 {
-	var dotProduct, normA, normB float32
-	for i := range a {
-		dotProduct += a[i] * b[i]
-		normA += a[i] * a[i]
-		normB += b[i] * b[i]
+// parallel splits the range [0, n) into contiguous chunks and runs fn over them
+// on up to Parallelism goroutines, returning once every chunk is done.
+func (ms *MemoryStream) parallel(n int, fn func(lo, hi int)) {
+	workers := min(max(ms.Parallelism, 1), n)
+	if workers <= 1 {
+		fn(0, n)
+		return
 	}
-	if normA == 0 || normB == 0 {
-		return 0.0
+	chunk := (n + workers - 1) / workers
+	var wg sync.WaitGroup
+	for lo := 0; lo < n; lo += chunk {
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			fn(lo, hi)
+		}(lo, min(lo+chunk, n))
 	}
-	return dotProduct / float32(math.Sqrt(float64(normA))*math.Sqrt(float64(normB)))
+	wg.Wait()
 }
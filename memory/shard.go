@@ -0,0 +1,279 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lordtatty/a25/clock"
+)
+
+// memoriesKind identifies saved memory lists in the save format.
+const memoriesKind = "memories"
+
+// ShardedStream partitions an agent's memories by time epoch. Each shard is a
+// MemoryStream with its own index. Only the most recently used shards are held
+// in RAM; the rest are written to disk and loaded lazily when a query needs them.
+type ShardedStream struct {
+	Client OpenAIClient
+	Dir    string        // Directory holding cold shards.
+	Epoch  time.Duration // Span of time covered by each shard.
+	MaxHot int           // Maximum number of shards held in RAM.
+	// Embedder embeds queries and is given to each shard, so all share one
+	// embedding space; OpenAI's default embedding model when nil.
+	Embedder Embedder
+	// Clock supplies the current time for placing memories in shards and is
+	// given to each shard; defaults to the system clock.
+	Clock clock.Clock
+
+	shards map[int64]*shard
+	uses   uint64 // Counts shard loads, to find the least recently used.
+}
+
+// shard is a single epoch of memories, which may be hot or cold.
+type shard struct {
+	stream   *MemoryStream
+	lastUsed uint64
+}
+
+// now returns the current time on the sharded stream's clock.
+func (s *ShardedStream) now() time.Time {
+	if s.Clock != nil {
+		return s.Clock.Now()
+	}
+	return time.Now()
+}
+
+// NewShardedStream creates a sharded stream, registering any shards previously
+// written to dir as cold.
+func NewShardedStream(client OpenAIClient, dir string, epoch time.Duration, maxHot int) (*ShardedStream, error) {
+	if epoch <= 0 {
+		return nil, errors.New("shard epoch must be positive")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create shard directory: %w", err)
+	}
+	s := &ShardedStream{
+		Client: client,
+		Dir:    dir,
+		Epoch:  epoch,
+		MaxHot: max(maxHot, 1),
+		shards: make(map[int64]*shard),
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shard directory: %w", err)
+	}
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok {
+			continue
+		}
+		if id, err := strconv.ParseInt(name, 10, 64); err == nil {
+			s.shards[id] = &shard{}
+		}
+	}
+	return s, nil
+}
+
+// AddMemory adds a new memory to the shard for the current epoch.
+func (s *ShardedStream) AddMemory(description string) error {
+	sh, err := s.load(s.epochOf(s.now()))
+	if err != nil {
+		return err
+	}
+	if err := sh.stream.AddMemory(description); err != nil {
+		return err
+	}
+	return s.evict()
+}
+
+// RetrieveMemories retrieves relevant memories from every shard, loading cold
+// shards as needed.
+func (s *ShardedStream) RetrieveMemories(query string) ([]RetrievedMemory, error) {
+	return s.RetrieveMemoriesSince(query, time.Time{})
+}
+
+// RetrieveMemoriesSince retrieves relevant memories from shards overlapping the
+// period since the given time, leaving older cold shards on disk.
+func (s *ShardedStream) RetrieveMemoriesSince(query string, since time.Time) ([]RetrievedMemory, error) {
+	return s.RetrieveMemoriesSinceContext(context.Background(), query, since)
+}
+
+// RetrieveMemoriesSinceContext is RetrieveMemoriesSince under the given context.
+func (s *ShardedStream) RetrieveMemoriesSinceContext(ctx context.Context, query string, since time.Time) ([]RetrievedMemory, error) {
+	embeddings, err := embedContext(ctx, s.embedder(), []string{query})
+	if err != nil {
+		return nil, err
+	}
+	queryEmbedding, _ := normalize(embeddings[0])
+
+	var retrieved []RetrievedMemory
+	for _, id := range s.ids() {
+		if !since.IsZero() && id < s.epochOf(since) {
+			continue
+		}
+		sh, err := s.load(id)
+		if err != nil {
+			return nil, err
+		}
+		if err := sh.stream.backfillFor(ctx, queryEmbedding); err != nil {
+			return nil, err
+		}
+		matches, err := sh.stream.search(ctx, queryEmbedding, nil)
+		if err != nil {
+			return nil, err
+		}
+		sh.stream.mu.Lock()
+		r := sh.stream.retrieve(queryEmbedding, matches, nil)
+		sh.stream.mu.Unlock()
+		// Access times are updated per shard, before the shard can be evicted.
+		if err := sh.stream.touch(ctx, r); err != nil {
+			return nil, err
+		}
+		retrieved = append(retrieved, r...)
+		// Evict as we go so a full scan never holds every shard at once.
+		if err := s.evict(); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(retrieved, func(i, j int) bool {
+		return retrieved[i].Score > retrieved[j].Score
+	})
+	return retrieved, nil
+}
+
+// Flush writes every hot shard to disk.
+func (s *ShardedStream) Flush() error {
+	for id, sh := range s.shards {
+		if sh.stream == nil {
+			continue
+		}
+		if err := s.write(id, sh.stream); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// embedder returns the Embedder, defaulting to OpenAI's embedding model.
+func (s *ShardedStream) embedder() Embedder {
+	if s.Embedder != nil {
+		return s.Embedder
+	}
+	return &OpenAIEmbedder{Client: s.Client}
+}
+
+// epochOf returns the id of the shard covering the given time.
+func (s *ShardedStream) epochOf(t time.Time) int64 {
+	return t.UnixNano() / int64(s.Epoch)
+}
+
+// ids returns the ids of all shards in chronological order.
+func (s *ShardedStream) ids() []int64 {
+	ids := make([]int64, 0, len(s.shards))
+	for id := range s.shards {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// load returns the shard with the given id, reading it from disk if it is cold
+// or creating it if it doesn't exist yet.
+func (s *ShardedStream) load(id int64) (*shard, error) {
+	sh, ok := s.shards[id]
+	if !ok {
+		sh = &shard{stream: NewStream(s.Client)}
+		s.shards[id] = sh
+	}
+	if sh.stream == nil {
+		f, err := os.Open(s.path(id))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open shard: %w", err)
+		}
+		defer f.Close()
+		stream, err := LoadStream(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load shard %d: %w", id, err)
+		}
+		stream.Client = s.Client
+		sh.stream = stream
+	}
+	sh.stream.Clock = s.Clock
+	sh.stream.Embedder = s.Embedder
+	s.uses++
+	sh.lastUsed = s.uses
+	return sh, nil
+}
+
+// evict writes the least recently used shards to disk until no more than MaxHot
+// shards remain in RAM.
+func (s *ShardedStream) evict() error {
+	for {
+		var hot []int64
+		for id, sh := range s.shards {
+			if sh.stream != nil {
+				hot = append(hot, id)
+			}
+		}
+		if len(hot) <= s.MaxHot {
+			return nil
+		}
+		sort.Slice(hot, func(i, j int) bool {
+			return s.shards[hot[i]].lastUsed < s.shards[hot[j]].lastUsed
+		})
+		id := hot[0]
+		if err := s.write(id, s.shards[id].stream); err != nil {
+			return err
+		}
+		s.shards[id].stream = nil
+	}
+}
+
+// write saves a shard's memories to disk.
+func (s *ShardedStream) write(id int64, stream *MemoryStream) error {
+	f, err := os.Create(s.path(id))
+	if err != nil {
+		return fmt.Errorf("failed to create shard file: %w", err)
+	}
+	if err := stream.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// path returns the file holding the shard with the given id.
+func (s *ShardedStream) path(id int64) string {
+	return filepath.Join(s.Dir, strconv.FormatInt(id, 10)+".json")
+}
+
+// Iterate returns an iterator over every memory, oldest shard first. Shards are
+// loaded one at a time and evicted as needed, so the walk never holds more
+// than MaxHot shards in RAM.
+func (s *ShardedStream) Iterate() *MemoryIterator {
+	ids := s.ids()
+	return &MemoryIterator{fetch: func(page int) ([]MemoryObject, error) {
+		if page >= len(ids) {
+			return nil, nil
+		}
+		sh, err := s.load(ids[page])
+		if err != nil {
+			return nil, err
+		}
+		memories := sh.stream.Memories()
+		if err := s.evict(); err != nil {
+			return nil, err
+		}
+		// An empty shard yields an empty page rather than nil, which would end the walk.
+		return append([]MemoryObject{}, memories...), nil
+	}}
+}
@@ -0,0 +1,36 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+)
+
+// Embed returns unit-length embeddings of the texts, fetched in one call to
+// the stream's embedder. They can be compared with Similarity, and a memory
+// added with its embedding already set is not embedded again, so callers can
+// compare texts cheaply before deciding to store them.
+func (ms *MemoryStream) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	embeddings, err := embedContext(ctx, ms.embedder(), texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get embeddings: %w", err)
+	}
+	if len(embeddings) != len(texts) {
+		return nil, fmt.Errorf("got %d embeddings for %d texts", len(embeddings), len(texts))
+	}
+	for i, e := range embeddings {
+		embeddings[i], _ = normalize(e)
+	}
+	return embeddings, nil
+}
+
+// Similarity returns the cosine similarity of two unit-length embeddings, or
+// zero if their lengths differ.
+func Similarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	return float64(dot(a, b))
+}
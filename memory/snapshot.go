@@ -0,0 +1,27 @@
+package memory
+
+import (
+	"io"
+
+	"github.com/lordtatty/a25/save"
+)
+
+// Save writes a snapshot of every memory, including embeddings, timestamps and
+// importance, to w in the save format.
+func (ms *MemoryStream) Save(w io.Writer) error {
+	ms.mu.Lock()
+	memories := make([]MemoryObject, len(ms.memories))
+	copy(memories, ms.memories)
+	ms.mu.Unlock()
+	return save.Write(w, memoriesKind, memories)
+}
+
+// LoadStream restores a memory stream saved with Save, migrating older saves
+// as needed. The caller sets the Client and any other options.
+func LoadStream(r io.Reader) (*MemoryStream, error) {
+	ms := NewStream(nil)
+	if err := save.Read(r, memoriesKind, &ms.memories); err != nil {
+		return nil, err
+	}
+	return ms, nil
+}
@@ -0,0 +1,221 @@
+package memory
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// SQLiteStore is a Store that keeps one agent's memories in a SQLite database.
+// Many agents can share a database. The caller opens the database with the
+// SQLite driver of their choice, e.g. modernc.org/sqlite or
+// github.com/mattn/go-sqlite3.
+type SQLiteStore struct {
+	db    *sql.DB
+	agent string
+}
+
+// SQLiteShared is a SharedStore keeping every agent's memories in one SQLite
+// database, namespaced by agent.
+type SQLiteShared struct {
+	db *sql.DB
+}
+
+// NewSQLiteShared creates a shared store, creating the memories table if it
+// does not exist.
+func NewSQLiteShared(db *sql.DB) (*SQLiteShared, error) {
+	if err := createSQLiteTable(db); err != nil {
+		return nil, err
+	}
+	return &SQLiteShared{db: db}, nil
+}
+
+// Namespace returns the store for one agent's memories.
+func (s *SQLiteShared) Namespace(agent string) Store {
+	return &SQLiteStore{db: s.db, agent: agent}
+}
+
+// NewSQLiteStore creates a store for the agent's memories, creating the
+// memories table if it does not exist.
+func NewSQLiteStore(db *sql.DB, agent string) (*SQLiteStore, error) {
+	if err := createSQLiteTable(db); err != nil {
+		return nil, err
+	}
+	return &SQLiteStore{db: db, agent: agent}, nil
+}
+
+// column is a column of a memories table and its SQL definition.
+type column struct {
+	name, definition string
+}
+
+// sqliteColumns are the columns added to the memories table since it was
+// first released, which tables created before them are migrated to have.
+var sqliteColumns = []column{
+	{"recurrences", "INTEGER NOT NULL DEFAULT 0"},
+	{"evidence", "TEXT NOT NULL DEFAULT ''"},
+	{"strength", "REAL NOT NULL DEFAULT 0"},
+	{"question", "TEXT NOT NULL DEFAULT ''"},
+	{"depth", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// createSQLiteTable creates the memories table if it does not exist, adding
+// any columns an existing table lacks.
+func createSQLiteTable(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS memories (
+	id TEXT NOT NULL,
+	agent TEXT NOT NULL,
+	description TEXT NOT NULL,
+	kind TEXT NOT NULL DEFAULT '',
+	source TEXT NOT NULL DEFAULT '',
+	importance REAL NOT NULL,
+	creation_time INTEGER,
+	last_accessed_time INTEGER,
+	expires_at INTEGER,
+	archived INTEGER NOT NULL DEFAULT 0,
+	embedding BLOB,
+	norm REAL NOT NULL DEFAULT 0,
+	recurrences INTEGER NOT NULL DEFAULT 0,
+	evidence TEXT NOT NULL DEFAULT '',
+	strength REAL NOT NULL DEFAULT 0,
+	question TEXT NOT NULL DEFAULT '',
+	depth INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (agent, id)
+)`)
+	if err != nil {
+		return fmt.Errorf("failed to create memories table: %w", err)
+	}
+	if err := migrateSQLiteTable(db); err != nil {
+		return fmt.Errorf("failed to migrate memories table: %w", err)
+	}
+	return nil
+}
+
+// migrateSQLiteTable adds the sqliteColumns the memories table lacks. SQLite
+// has no ADD COLUMN IF NOT EXISTS, so the table's columns are listed first.
+func migrateSQLiteTable(db *sql.DB) error {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info('memories')`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		existing[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+	for _, c := range sqliteColumns {
+		if existing[c.name] {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf(`ALTER TABLE memories ADD COLUMN %s %s`, c.name, c.definition)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load returns the agent's memories in creation order.
+func (s *SQLiteStore) Load() ([]MemoryObject, error) {
+	rows, err := s.db.Query(`SELECT id, description, kind, source, importance, creation_time, last_accessed_time, expires_at, archived, embedding, norm, recurrences, evidence, strength, question, depth
+FROM memories WHERE agent = ? ORDER BY creation_time, rowid`, s.agent)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var memories []MemoryObject
+	for rows.Next() {
+		var m MemoryObject
+		var created, accessed, expires sql.NullInt64
+		var embedding []byte
+		var norm float64
+		var evidence string
+		if err := rows.Scan(&m.ID, &m.Description, &m.Kind, &m.Source, &m.Importance, &created, &accessed, &expires, &m.Archived, &embedding, &norm, &m.Recurrences, &evidence, &m.Strength, &m.Question, &m.Depth); err != nil {
+			return nil, err
+		}
+		m.CreationTime = fromUnixNano(created)
+		m.LastAccessedTime = fromUnixNano(accessed)
+		m.ExpiresAt = fromUnixNano(expires)
+		if m.Embedding, err = decodeEmbedding(embedding); err != nil {
+			return nil, fmt.Errorf("memory %s: %w", m.ID, err)
+		}
+		m.Norm = float32(norm)
+		m.Evidence = splitIDs(evidence)
+		memories = append(memories, m)
+	}
+	return memories, rows.Err()
+}
+
+// Put inserts or replaces a memory.
+func (s *SQLiteStore) Put(m MemoryObject) error {
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO memories (id, agent, description, kind, source, importance, creation_time, last_accessed_time, expires_at, archived, embedding, norm, recurrences, evidence, strength, question, depth)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		m.ID, s.agent, m.Description, m.Kind, m.Source, m.Importance,
+		toUnixNano(m.CreationTime), toUnixNano(m.LastAccessedTime), toUnixNano(m.ExpiresAt),
+		m.Archived, encodeEmbedding(m.Embedding), float64(m.Norm), m.Recurrences, strings.Join(m.Evidence, ","), m.Strength, m.Question, m.Depth)
+	return err
+}
+
+// Delete removes a memory.
+func (s *SQLiteStore) Delete(id string) error {
+	_, err := s.db.Exec(`DELETE FROM memories WHERE agent = ? AND id = ?`, s.agent, id)
+	return err
+}
+
+// splitIDs parses a comma-separated list of memory IDs.
+func splitIDs(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// toUnixNano stores a time as nanoseconds since the epoch, or NULL if zero.
+func toUnixNano(t time.Time) sql.NullInt64 {
+	if t.IsZero() {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: t.UnixNano(), Valid: true}
+}
+
+// fromUnixNano is the inverse of toUnixNano.
+func fromUnixNano(n sql.NullInt64) time.Time {
+	if !n.Valid {
+		return time.Time{}
+	}
+	return time.Unix(0, n.Int64)
+}
+
+// encodeEmbedding serialises an embedding as little-endian float32s.
+func encodeEmbedding(e []float32) []byte {
+	b := make([]byte, 4*len(e))
+	for i, v := range e {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(v))
+	}
+	return b
+}
+
+// decodeEmbedding is the inverse of encodeEmbedding.
+func decodeEmbedding(b []byte) ([]float32, error) {
+	if len(b)%4 != 0 {
+		return nil, fmt.Errorf("invalid embedding length %d", len(b))
+	}
+	if len(b) == 0 {
+		return nil, nil
+	}
+	e := make([]float32, len(b)/4)
+	for i := range e {
+		e[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return e, nil
+}
@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDB is a database/sql driver that records the statements executed and
+// answers the SQLite table_info query with a fixed list of columns.
+type fakeDB struct {
+	mu      sync.Mutex
+	columns []string
+	execs   []string
+}
+
+func (d *fakeDB) Open(string) (driver.Conn, error) { return fakeConn{d}, nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.execs = append(c.db.execs, query)
+	return driver.RowsAffected(0), nil
+}
+
+func (c fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if !strings.Contains(query, "pragma_table_info") {
+		return nil, errors.New("unexpected query")
+	}
+	return &fakeRows{names: c.db.columns}, nil
+}
+
+type fakeRows struct {
+	names []string
+	i     int
+}
+
+func (r *fakeRows) Columns() []string { return []string{"name"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.i == len(r.names) {
+		return io.EOF
+	}
+	dest[0] = r.names[r.i]
+	r.i++
+	return nil
+}
+
+// openFake opens a database on a new fakeDB with the given columns.
+func openFake(t *testing.T, columns []string) (*sql.DB, *fakeDB) {
+	t.Helper()
+	fake := &fakeDB{columns: columns}
+	db := sql.OpenDB(fakeConnector{fake})
+	t.Cleanup(func() { db.Close() })
+	return db, fake
+}
+
+type fakeConnector struct{ db *fakeDB }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn{c.db}, nil }
+func (c fakeConnector) Driver() driver.Driver                        { return c.db }
+
+// alteredColumns returns the columns added by ALTER TABLE statements.
+func alteredColumns(execs []string) []string {
+	var added []string
+	for _, e := range execs {
+		if rest, ok := strings.CutPrefix(e, "ALTER TABLE memories ADD COLUMN "); ok {
+			rest = strings.TrimPrefix(rest, "IF NOT EXISTS ")
+			added = append(added, strings.Fields(rest)[0])
+		}
+	}
+	return added
+}
+
+func TestCreateSQLiteTableMigrates(t *testing.T) {
+	original := []string{"id", "agent", "description", "kind", "source", "importance", "creation_time", "last_accessed_time", "expires_at", "archived", "embedding", "norm"}
+	tests := []struct {
+		name    string
+		columns []string
+		want    []string
+	}{
+		{"original table", original, []string{"recurrences", "evidence", "strength", "question", "depth"}},
+		{"partly migrated", append(slices.Clone(original), "recurrences", "evidence"), []string{"strength", "question", "depth"}},
+		{"current table", append(slices.Clone(original), "recurrences", "evidence", "strength", "question", "depth"), nil},
+	}
+	for _, tt := range tests {
+		db, fake := openFake(t, tt.columns)
+		if err := createSQLiteTable(db); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := alteredColumns(fake.execs); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: added %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCreatePostgresSchemaMigrates(t *testing.T) {
+	db, fake := openFake(t, nil)
+	if err := createPostgresSchema(db, 3); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"recurrences", "evidence", "strength", "question", "depth"}
+	if got := alteredColumns(fake.execs); !slices.Equal(got, want) {
+		t.Errorf("added %q, want %q", got, want)
+	}
+	for _, e := range fake.execs {
+		if strings.HasPrefix(e, "ALTER TABLE") && !strings.Contains(e, "IF NOT EXISTS") {
+			t.Errorf("migration %q is not idempotent", e)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// Store persists a memory stream outside the process. When a stream has a
+// Store, new memories are written to it as they are added and changes such as
+// archiving are written through, so the stream survives restarts.
+type Store interface {
+	// Load returns every stored memory in creation order.
+	Load() ([]MemoryObject, error)
+	// Put inserts or replaces a memory.
+	Put(MemoryObject) error
+	// Delete removes a memory. Deleting a missing memory is not an error.
+	Delete(id string) error
+}
+
+// ContextStore is a Store whose requests can be cancelled with a context, such
+// as one reached over the network.
+type ContextStore interface {
+	Store
+	LoadContext(ctx context.Context) ([]MemoryObject, error)
+	PutContext(ctx context.Context, m MemoryObject) error
+	DeleteContext(ctx context.Context, id string) error
+}
+
+// loadContext loads the memories in s, under ctx if s supports it.
+func loadContext(ctx context.Context, s Store) ([]MemoryObject, error) {
+	if cs, ok := s.(ContextStore); ok {
+		return cs.LoadContext(ctx)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.Load()
+}
+
+// putContext writes the memory to s, under ctx if s supports it.
+func putContext(ctx context.Context, s Store, m MemoryObject) error {
+	if cs, ok := s.(ContextStore); ok {
+		return cs.PutContext(ctx, m)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.Put(m)
+}
+
+// deleteContext removes the memory from s, under ctx if s supports it.
+func deleteContext(ctx context.Context, s Store, id string) error {
+	if cs, ok := s.(ContextStore); ok {
+		return cs.DeleteContext(ctx, id)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.Delete(id)
+}
+
+// Flusher is a Store that buffers writes, such as MilvusStore. The stream
+// flushes it on Flush and Sync, so nothing buffered is lost on exit.
+type Flusher interface {
+	// Flush writes out any buffered writes.
+	Flush() error
+}
+
+// SharedStore hosts the memories of many agents, each in a namespace of its
+// own, so a whole simulation can share one database and connection pool.
+type SharedStore interface {
+	// Namespace returns the Store for one namespace, typically an agent's name.
+	Namespace(name string) Store
+}
+
+// Load replaces the stream's memories with those held in its Store, typically
+// once on startup.
+func (ms *MemoryStream) Load() error {
+	return ms.LoadContext(context.Background())
+}
+
+// LoadContext is Load under the given context.
+func (ms *MemoryStream) LoadContext(ctx context.Context) error {
+	if ms.Store == nil {
+		return fmt.Errorf("memory stream has no store")
+	}
+	memories, err := loadContext(ctx, ms.Store)
+	if err != nil {
+		return fmt.Errorf("failed to load memories: %w", err)
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.memories = memories
+	ms.version++
+	for _, m := range memories {
+		ms.indexed(m)
+	}
+	return nil
+}
+
+// Sync writes every memory to the Store, including changes that are not
+// written through, such as last access times updated by retrieval.
+func (ms *MemoryStream) Sync() error {
+	if ms.Store == nil {
+		return nil
+	}
+	ms.mu.Lock()
+	memories := make([]MemoryObject, len(ms.memories))
+	copy(memories, ms.memories)
+	ms.mu.Unlock()
+	for _, m := range memories {
+		if err := ms.Store.Put(m); err != nil {
+			return fmt.Errorf("failed to store memory %s: %w", m.ID, err)
+		}
+	}
+	return ms.Flush()
+}
+
+// Flush writes out any writes the Store has buffered. Call it before exiting
+// when the Store is a Flusher.
+func (ms *MemoryStream) Flush() error {
+	f, ok := ms.Store.(Flusher)
+	if !ok {
+		return nil
+	}
+	if err := f.Flush(); err != nil {
+		return fmt.Errorf("failed to flush store: %w", err)
+	}
+	return nil
+}
+
+// persist writes a memory to the Store, if any.
+func (ms *MemoryStream) persist(ctx context.Context, m MemoryObject) error {
+	if ms.Store == nil {
+		return nil
+	}
+	if err := putContext(ctx, ms.Store, m); err != nil {
+		return fmt.Errorf("failed to store memory: %w", err)
+	}
+	return nil
+}
+
+// sortByCreation orders memories by creation time, keeping the order of equal times.
+func sortByCreation(memories []MemoryObject) {
+	slices.SortStableFunc(memories, func(a, b MemoryObject) int {
+		return a.CreationTime.Compare(b.CreationTime)
+	})
+}
@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"math"
+	"time"
+
+	"github.com/lordtatty/a25/clock"
+)
+
+// timeScale returns the simulated hours per hour of the stream's clock,
+// defaulting to 1.
+func (ms *MemoryStream) timeScale() float64 {
+	if ms.TimeScale > 0 && !clock.Simulated(ms.Clock) {
+		return ms.TimeScale
+	}
+	return 1
+}
+
+// strength returns the memory's strength, counting zero as 1.
+func (m MemoryObject) strength() float64 {
+	if m.Strength > 0 {
+		return m.Strength
+	}
+	return 1
+}
+
+// retention is the forgetting curve: the fraction of the memory retained since
+// it was last accessed, decaying exponentially over a day of simulated time per
+// unit of strength.
+func (m MemoryObject) retention(now time.Time, timeScale float64) float64 {
+	hours := now.Sub(m.LastAccessedTime).Hours() * timeScale
+	return math.Exp(-hours / (24 * m.strength()))
+}
+
+// strengthen increases the memory's strength on access. As in spaced
+// repetition, recalling a nearly forgotten memory strengthens it by up to
+// double, while recalling one just accessed barely changes it.
+func (m *MemoryObject) strengthen(now time.Time, timeScale float64) {
+	m.Strength = m.strength() * (2 - m.retention(now, timeScale))
+}
@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"context"
+	"time"
+)
+
+// within reports whether the memory was created in [start, end). A zero start
+// or end leaves that side of the range open.
+func (m MemoryObject) within(start, end time.Time) bool {
+	return (start.IsZero() || !m.CreationTime.Before(start)) && (end.IsZero() || m.CreationTime.Before(end))
+}
+
+// RetrieveMemoriesBetween retrieves relevant memories created in [start, end),
+// such as yesterday afternoon for a daily retrospective. A zero start or end
+// leaves that side of the range open.
+func (ms *MemoryStream) RetrieveMemoriesBetween(query string, start, end time.Time) ([]RetrievedMemory, error) {
+	return ms.RetrieveMemoriesBetweenContext(context.Background(), query, start, end)
+}
+
+// RetrieveMemoriesBetweenContext is RetrieveMemoriesBetween under the given
+// context. Memories outside the range are neither ranked nor touched.
+func (ms *MemoryStream) RetrieveMemoriesBetweenContext(ctx context.Context, query string, start, end time.Time) ([]RetrievedMemory, error) {
+	return ms.retrieveWhere(ctx, query, func(m MemoryObject) bool {
+		return m.within(start, end)
+	})
+}
+
+// GetMemoriesBetween returns the unarchived memories created in [start, end),
+// in the order they were added.
+func (ms *MemoryStream) GetMemoriesBetween(start, end time.Time) []MemoryObject {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	var out []MemoryObject
+	for _, m := range ms.memories {
+		if !m.Archived && m.within(start, end) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// ImportanceSince returns the summed importance of the memories created after
+// t, excluding reflections, which measures how much has happened since then.
+func (ms *MemoryStream) ImportanceSince(t time.Time) float64 {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	var sum float64
+	// Memories are not always in creation order, as when imported, so all
+	// are checked.
+	for _, m := range ms.memories {
+		if m.CreationTime.After(t) && !m.Archived && m.Kind != Reflection {
+			sum += m.Importance
+		}
+	}
+	return sum
+}
@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetMemory returns the memory with the given ID.
+func (ms *MemoryStream) GetMemory(id string) (MemoryObject, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	i := ms.index(id)
+	if i < 0 {
+		return MemoryObject{}, ErrNotFound
+	}
+	return ms.memories[i], nil
+}
+
+// UpdateMemory replaces the stored memory with the same ID, for example to
+// correct an observation, redact its content or adjust its importance. A
+// changed description is redacted and re-embedded; other fields are kept as
+// given.
+func (ms *MemoryStream) UpdateMemory(m MemoryObject) error {
+	return ms.UpdateMemoryContext(context.Background(), m)
+}
+
+// UpdateMemoryContext is UpdateMemory under the given context.
+func (ms *MemoryStream) UpdateMemoryContext(ctx context.Context, m MemoryObject) error {
+	old, err := ms.GetMemory(m.ID)
+	if err != nil {
+		return err
+	}
+	if m.Description != old.Description {
+		// The embedding copied from GetMemory is of the old description.
+		m.Embedding = nil
+		if m, err = ms.prepare(ctx, m); err != nil {
+			return err
+		}
+		m.Keywords = ms.extractor().Extract(m.Description)
+	} else if len(m.Embedding) == 0 {
+		m.Embedding, m.Norm = old.Embedding, old.Norm
+	}
+	if err := ms.persist(ctx, m); err != nil {
+		return err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	i := ms.index(m.ID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	ms.memories[i] = m
+	ms.version++
+	ms.indexed(m)
+	return nil
+}
+
+// DeleteMemory removes the memory with the given ID from the stream. Unlike
+// Archive, nothing of the memory is kept.
+func (ms *MemoryStream) DeleteMemory(id string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.index(id) < 0 {
+		return ErrNotFound
+	}
+	if err := ms.remove(map[string]bool{id: true}); err != nil {
+		return fmt.Errorf("failed to delete memory %s: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package memory
+
+import "math"
+
+// norm returns the Euclidean length of a vector.
+func norm(v []float32) float32 {
+	var sum float32
+	for _, x := range v {
+		sum += x * x
+	}
+	return float32(math.Sqrt(float64(sum)))
+}
+
+// normalize returns a unit-length copy of the vector and its original norm.
+func normalize(v []float32) ([]float32, float32) {
+	n := norm(v)
+	out := make([]float32, len(v))
+	if n == 0 {
+		return out, 0
+	}
+	for i, x := range v {
+		out[i] = x / n
+	}
+	return out, n
+}
+
+// dot computes the dot product of two equal-length vectors. For unit vectors
+// this is their cosine similarity.
+func dot(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+// slab stores the unit-length embeddings of a memory stream contiguously, so
+// scoring scans memory in order rather than chasing a slice per memory.
+type slab struct {
+	dim     int
+	vectors []float32
+	valid   []bool // Whether each memory has an embedding of the slab dimension.
+	version uint64
+	first   *MemoryObject
+}
+
+// row returns the embedding of the i-th memory, or nil if it has none.
+func (s *slab) row(i int) []float32 {
+	if !s.valid[i] {
+		return nil
+	}
+	return s.vectors[i*s.dim : (i+1)*s.dim]
+}
+
+// stale reports whether the slab no longer reflects the memory stream.
+func (s *slab) stale(ms *MemoryStream) bool {
+	if s == nil || s.version != ms.version || len(s.valid) != len(ms.memories) {
+		return true
+	}
+	return len(ms.memories) > 0 && s.first != &ms.memories[0]
+}
+
+// buildSlab copies the stream's embeddings into a new slab, normalising any that
+// were stored before embeddings were normalised at insert time.
+func buildSlab(ms *MemoryStream) *slab {
+	s := &slab{
+		valid:   make([]bool, len(ms.memories)),
+		version: ms.version,
+	}
+	if len(ms.memories) > 0 {
+		s.first = &ms.memories[0]
+	}
+	for _, m := range ms.memories {
+		if len(m.Embedding) > 0 {
+			s.dim = len(m.Embedding)
+			break
+		}
+	}
+	s.vectors = make([]float32, len(ms.memories)*s.dim)
+	for i, m := range ms.memories {
+		if s.dim == 0 || len(m.Embedding) != s.dim {
+			continue
+		}
+		row := s.vectors[i*s.dim : (i+1)*s.dim]
+		copy(row, m.Embedding)
+		if n := norm(row); n != 0 && math.Abs(float64(n)-1) > 1e-3 {
+			for j := range row {
+				row[j] /= n
+			}
+		}
+		s.valid[i] = true
+	}
+	return s
+}
@@ -0,0 +1,175 @@
+package a25
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/lordtatty/a25/memory"
+)
+
+// defaultPerceptionSimilarity is the word overlap at which observations count
+// as the same by default.
+const defaultPerceptionSimilarity = 0.9
+
+// perception is an observation the agent has recently processed.
+type perception struct {
+	words map[string]bool
+	at    time.Time
+}
+
+// perceivedRecently reports whether the agent processed the same or a nearly
+// identical observation within the PerceptionCooldown before now.
+func (a *Agent) perceivedRecently(observation string, now time.Time) bool {
+	if a.PerceptionCooldown <= 0 {
+		return false
+	}
+	a.perceived = slices.DeleteFunc(a.perceived, func(p perception) bool {
+		return now.Sub(p.at) >= a.PerceptionCooldown
+	})
+	words := wordSet(observation)
+	return slices.ContainsFunc(a.perceived, func(p perception) bool {
+		return jaccard(words, p.words) >= a.perceptionSimilarity()
+	})
+}
+
+// samePerception reports whether two observations count as the same under
+// the PerceptionCooldown, as when both arrive in one tick.
+func (a *Agent) samePerception(o, other string) bool {
+	return a.PerceptionCooldown > 0 && jaccard(wordSet(o), wordSet(other)) >= a.perceptionSimilarity()
+}
+
+// recordPerceptions records that the agent processed the observations at now,
+// starting their cooldown.
+func (a *Agent) recordPerceptions(observations []string, now time.Time) {
+	if a.PerceptionCooldown <= 0 {
+		return
+	}
+	for _, o := range observations {
+		a.perceived = append(a.perceived, perception{words: wordSet(o), at: now})
+	}
+}
+
+// perceptionSimilarity returns the word overlap at which observations count
+// as the same.
+func (a *Agent) perceptionSimilarity() float64 {
+	if a.PerceptionSimilarity > 0 {
+		return a.PerceptionSimilarity
+	}
+	return defaultPerceptionSimilarity
+}
+
+// wordSet returns the distinct lower-case words of the text.
+func wordSet(text string) map[string]bool {
+	words := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		words[w] = true
+	}
+	return words
+}
+
+// jaccard returns the proportion of words shared by the two sets.
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	shared := 0
+	for w := range a {
+		if b[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// relevanceGoals is the number of the agent's most pressing goals observations
+// are compared against for relevance.
+const relevanceGoals = 3
+
+// relevant reports which of the observations are similar enough to the
+// agent's current task or most pressing unfinished goals, those due soonest,
+// to be worth reacting to. All are when RelevanceThreshold is unset or there
+// is nothing to compare them against. It also returns the observations'
+// embeddings, if it needed them, so they needn't be embedded again when
+// remembered.
+func (a *Agent) relevant(ctx context.Context, observations []string) ([]bool, [][]float32, error) {
+	out := make([]bool, len(observations))
+	for i := range out {
+		out[i] = true
+	}
+	if a.RelevanceThreshold <= 0 {
+		return out, nil, nil
+	}
+	var references []string
+	if a.Status.CurrentTask != "" {
+		references = append(references, a.Status.CurrentTask)
+	}
+	goals := slices.DeleteFunc(slices.Clone(a.Goals), func(g Goal) bool { return g.Progress >= 1 })
+	slices.SortStableFunc(goals, func(x, y Goal) int {
+		switch {
+		case x.Deadline.Equal(y.Deadline):
+			return 0
+		case y.Deadline.IsZero() || (!x.Deadline.IsZero() && x.Deadline.Before(y.Deadline)):
+			return -1
+		default:
+			return 1
+		}
+	})
+	for _, g := range goals[:min(relevanceGoals, len(goals))] {
+		references = append(references, g.Description)
+	}
+	if len(references) == 0 {
+		return out, nil, nil
+	}
+	refs, err := a.referenceEmbeddings(ctx, references)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to assess relevance: %w", err)
+	}
+	embeddings, err := a.Memory.Embed(ctx, observations)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to assess relevance: %w", err)
+	}
+	for i, e := range embeddings {
+		best := math.Inf(-1)
+		for _, r := range refs {
+			best = max(best, memory.Similarity(e, r))
+		}
+		out[i] = best >= a.RelevanceThreshold
+	}
+	return out, embeddings, nil
+}
+
+// referenceEmbeddings returns the embeddings of the task and goal references,
+// embedding only those not already cached. The cache keeps just the given
+// references, so it changes only when the task or goals do.
+func (a *Agent) referenceEmbeddings(ctx context.Context, references []string) ([][]float32, error) {
+	var missing []string
+	for _, r := range references {
+		if _, ok := a.references[r]; !ok && !slices.Contains(missing, r) {
+			missing = append(missing, r)
+		}
+	}
+	embeddings, err := a.Memory.Embed(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	cached := make(map[string][]float32, len(references))
+	for i, r := range missing {
+		cached[r] = embeddings[i]
+	}
+	out := make([][]float32, len(references))
+	for i, r := range references {
+		if _, ok := cached[r]; !ok {
+			cached[r] = a.references[r]
+		}
+		out[i] = cached[r]
+	}
+	a.references = cached
+	return out, nil
+}
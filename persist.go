@@ -0,0 +1,102 @@
+package a25
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/lordtatty/a25/memory"
+	"github.com/lordtatty/a25/plan"
+	"github.com/lordtatty/a25/save"
+)
+
+// agentKind identifies saved agents in the save format.
+const agentKind = "agent"
+
+func init() {
+	save.Register(agentKind, 1, func(data json.RawMessage) (json.RawMessage, error) {
+		var s map[string]json.RawMessage
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, err
+		}
+		memories, err := memory.AssignIDs(s["memories"])
+		if err != nil {
+			return nil, err
+		}
+		s["memories"] = memories
+		return json.Marshal(s)
+	})
+}
+
+// agentState is the persisted form of an Agent.
+type agentState struct {
+	ID             string                `json:"id"`
+	Name           string                `json:"name"`
+	Traits         string                `json:"traits"`
+	Description    string                `json:"description"`
+	Memories       []memory.MemoryObject `json:"memories"`
+	Plan           []plan.Action         `json:"plan"`
+	Goals          []Goal                `json:"goals"`
+	Skills         []Skill               `json:"skills"`
+	Status         AgentStatus           `json:"status"`
+	LastReflection time.Time             `json:"last_reflection"`
+	Retrospective  string                `json:"retrospective,omitempty"`
+}
+
+// Save writes the agent's state, including memories and plan, to w.
+func (a *Agent) Save(w io.Writer) error {
+	return save.Write(w, agentKind, agentState{
+		ID:             a.ID,
+		Name:           a.Name,
+		Traits:         a.Traits,
+		Description:    a.Description,
+		Memories:       a.Memory.Memories(),
+		Plan:           a.CurrentPlan.Actions(),
+		Goals:          a.Goals,
+		Skills:         a.Skills,
+		Status:         a.Status,
+		LastReflection: a.lastReflection,
+		Retrospective:  a.retrospective,
+	})
+}
+
+// LoadAgent restores an agent saved with Save, migrating older saves as needed.
+func LoadAgent(r io.Reader, client OpenAIClient) (*Agent, error) {
+	var s agentState
+	if err := save.Read(r, agentKind, &s); err != nil {
+		return nil, err
+	}
+	a := NewAgent(s.Name, s.Traits, s.Description, client)
+	if s.ID != "" {
+		a.ID = s.ID
+	}
+	a.Memory.SetMemories(s.Memories)
+	if err := a.CurrentPlan.SetActions(s.Plan); err != nil {
+		return nil, fmt.Errorf("failed to restore plan: %w", err)
+	}
+	a.Goals = s.Goals
+	a.Skills = s.Skills
+	a.Status = s.Status
+	a.lastReflection = s.LastReflection
+	a.retrospective = s.Retrospective
+	return a, nil
+}
+
+// UseStore attaches the agent's memory stream to its namespace in a shared
+// store and loads the memories held there. Stores that can search by vector
+// similarity also become the stream's Index.
+func (a *Agent) UseStore(shared memory.SharedStore) error {
+	store := shared.Namespace(a.Name)
+	a.Memory.Store = store
+	if idx, ok := store.(memory.VectorIndex); ok {
+		a.Memory.Index = idx
+	}
+	return a.Memory.Load()
+}
+
+// Close writes out any memories the agent's Store has buffered, such as a
+// MilvusStore's pending upserts. Call it when the agent is done with.
+func (a *Agent) Close() error {
+	return a.Memory.Flush()
+}
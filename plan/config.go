@@ -0,0 +1,96 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Inclusion decides whether a kind of activity appears in generated plans.
+type Inclusion int
+
+const (
+	// Optional leaves it to the model.
+	Optional Inclusion = iota
+	// Include has every plan include it.
+	Include
+	// Exclude keeps it out of plans.
+	Exclude
+)
+
+// PlannerConfig sets the granularity and scope of generated plans.
+type PlannerConfig struct {
+	// BlockSize, if set, is the length of time plans are made in, typically 15,
+	// 30 or 60 minutes. Actions are snapped to whole blocks.
+	BlockSize time.Duration
+	// MaxActions, if set, caps the actions the model plans for a day, not
+	// counting fixed appointments.
+	MaxActions int
+	Meals      Inclusion
+	Sleep      Inclusion
+}
+
+// prompt describes the plan format for the model, or is empty if nothing is set.
+func (c PlannerConfig) prompt() string {
+	var lines []string
+	if c.BlockSize > 0 {
+		lines = append(lines, "- "+c.blockRule())
+	}
+	if c.MaxActions > 0 {
+		lines = append(lines, fmt.Sprintf("- Plan no more than %d actions.", c.MaxActions))
+	}
+	switch c.Meals {
+	case Include:
+		lines = append(lines, "- Include breakfast, lunch and dinner as actions of their own.")
+	case Exclude:
+		lines = append(lines, "- Leave out meals.")
+	}
+	switch c.Sleep {
+	case Include:
+		lines = append(lines, "- End the plan with the agent's night's sleep as an action of its own.")
+	case Exclude:
+		lines = append(lines, "- Leave out sleep; end the plan when the agent goes to bed.")
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "\nFormat the plan as follows:\n" + strings.Join(lines, "\n")
+}
+
+// blockRule describes the block size for the model.
+func (c PlannerConfig) blockRule() string {
+	minutes := int(c.BlockSize / time.Minute)
+	return fmt.Sprintf("Plan in blocks of %d minutes: every action starts on the hour or a multiple of %d minutes past it, and lasts a multiple of %d minutes.", minutes, minutes, minutes)
+}
+
+// blockPrompt describes the block size alone, as when revising a plan, or is
+// empty if it is unset.
+func (c PlannerConfig) blockPrompt() string {
+	if c.BlockSize <= 0 {
+		return ""
+	}
+	return "\n" + c.blockRule()
+}
+
+// snap rounds the actions' start times to the nearest block of the day and
+// their durations to a whole number of blocks, at least one.
+func (c PlannerConfig) snap(actions []Action) {
+	b := c.BlockSize
+	if b <= 0 {
+		return
+	}
+	for i, a := range actions {
+		y, m, d := a.StartTime.Date()
+		midnight := time.Date(y, m, d, 0, 0, 0, 0, a.StartTime.Location())
+		actions[i].StartTime = midnight.Add(a.StartTime.Sub(midnight).Round(b))
+		actions[i].Duration = max(a.Duration.Round(b), b)
+	}
+}
+
+// limit returns at most MaxActions of the actions, keeping the earliest.
+func (c PlannerConfig) limit(actions []Action) []Action {
+	if c.MaxActions > 0 && len(actions) > c.MaxActions {
+		return actions[:c.MaxActions]
+	}
+	return actions
+}
@@ -0,0 +1,183 @@
+package plan
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Constraints are commitments a day's plan must respect. They are described
+// to the model and then enforced on what it returns, so nothing is ever
+// scheduled over them.
+type Constraints struct {
+	// Fixed are appointments kept exactly as given.
+	Fixed []Action
+	// Routines are recurring activities kept as fixed appointments on the days
+	// they occur, giving the agent the same structure from day to day.
+	Routines []Routine
+	// Blackouts are periods when nothing may be scheduled.
+	Blackouts []Window
+	// Wake and Sleep, if set, are the earliest and latest times of day that
+	// actions may take place, as offsets from midnight. A Sleep at or before
+	// Wake falls on the following day.
+	Wake, Sleep time.Duration
+}
+
+// Window is a period of time.
+type Window struct {
+	Start, End time.Time
+	Reason     string // Optional, e.g. "at the doctor's".
+}
+
+// prompt describes the constraints for the model, or is empty if there are none.
+func (c Constraints) prompt() string {
+	var lines []string
+	for _, a := range c.Fixed {
+		lines = append(lines, fmt.Sprintf("- Fixed appointment, keep exactly: %s - %s: %s", a.StartTime.Format("3:04 PM"), a.StartTime.Add(a.Duration).Format("3:04 PM"), a.Description))
+	}
+	for _, w := range c.Blackouts {
+		line := fmt.Sprintf("- Unavailable, schedule nothing: %s - %s", w.Start.Format("3:04 PM"), w.End.Format("3:04 PM"))
+		if w.Reason != "" {
+			line += " (" + w.Reason + ")"
+		}
+		lines = append(lines, line)
+	}
+	midnight := time.Time{}
+	if c.Wake > 0 {
+		lines = append(lines, "- Start the day no earlier than "+midnight.Add(c.Wake).Format("3:04 PM"))
+	}
+	if c.Sleep > 0 {
+		lines = append(lines, "- End the day no later than "+midnight.Add(c.Sleep).Format("3:04 PM"))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "\nThe plan must respect these constraints:\n" + strings.Join(lines, "\n")
+}
+
+// Enforce applies the constraints, including the day's routines, to actions
+// planned for the day of currentTime, such as a revised plan, returning them
+// in chronological order with the day's fixed appointments in place.
+func (c Constraints) Enforce(actions []Action, currentTime time.Time) []Action {
+	actions = c.forDay(currentTime).apply(actions, currentTime)
+	sort.SliceStable(actions, func(i, j int) bool {
+		return actions[i].StartTime.Before(actions[j].StartTime)
+	})
+	return actions
+}
+
+// Fit returns the action cut back so that it respects the constraints on its
+// day, such as an action added in reaction to something, reporting false if
+// no part of it does.
+func (c Constraints) Fit(a Action) (Action, bool) {
+	return a.fit(c.forDay(a.StartTime).blocked(a.StartTime))
+}
+
+// onDay returns the constraints with only the fixed appointments starting on
+// the day of t.
+func (c Constraints) onDay(t time.Time) Constraints {
+	y, m, d := t.Date()
+	var fixed []Action
+	for _, a := range c.Fixed {
+		if ay, am, ad := a.StartTime.In(t.Location()).Date(); ay == y && am == m && ad == d {
+			fixed = append(fixed, a)
+		}
+	}
+	c.Fixed = fixed
+	return c
+}
+
+// blocked returns the windows on the day of t when nothing else may be
+// scheduled: the fixed appointments, the blackouts and the hours before
+// waking or after sleeping.
+func (c Constraints) blocked(t time.Time) []Window {
+	var blocked []Window
+	for _, a := range c.onDay(t).Fixed {
+		blocked = append(blocked, Window{Start: a.StartTime, End: a.StartTime.Add(a.Duration)})
+	}
+	blocked = append(blocked, c.Blackouts...)
+	y, m, d := t.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	wake := midnight
+	if c.Wake > 0 {
+		wake = midnight.Add(c.Wake)
+		blocked = append(blocked, Window{End: wake})
+	}
+	if c.Sleep > 0 {
+		sleep := midnight.Add(c.Sleep)
+		if !sleep.After(wake) {
+			sleep = sleep.AddDate(0, 0, 1)
+		}
+		blocked = append(blocked, Window{Start: sleep, End: sleep.AddDate(1, 0, 0)})
+	}
+	return blocked
+}
+
+// apply enforces the constraints on actions planned for the day of
+// currentTime: actions are trimmed or dropped where they run into a blackout,
+// a fixed appointment or the hours before waking or after sleeping, and the
+// day's fixed appointments are added unless already planned.
+func (c Constraints) apply(actions []Action, currentTime time.Time) []Action {
+	c = c.onDay(currentTime)
+	blocked := c.blocked(currentTime)
+	var out []Action
+	present := make([]bool, len(c.Fixed))
+	for _, a := range actions {
+		i := slices.IndexFunc(c.Fixed, func(f Action) bool {
+			return f.Description == a.Description && f.StartTime.Equal(a.StartTime)
+		})
+		if i >= 0 && !present[i] {
+			present[i] = true
+			out = append(out, a)
+			continue
+		}
+		if a, ok := a.fit(blocked); ok {
+			out = append(out, a)
+		}
+	}
+	for i, a := range c.Fixed {
+		if present[i] {
+			continue
+		}
+		if a.ID == "" {
+			a.ID = uuid.NewString()
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// fit returns the action cut back so that it overlaps none of the windows,
+// reporting false if no part of it lies outside them.
+func (a Action) fit(blocked []Window) (Action, bool) {
+	ok := true
+	for _, w := range blocked {
+		if a, ok = a.outside(w); !ok {
+			break
+		}
+	}
+	return a, ok
+}
+
+// outside returns the action cut back so that it does not overlap the window,
+// keeping its part before the window if it has one. It reports false if no
+// part of the action lies outside the window.
+func (a Action) outside(w Window) (Action, bool) {
+	end := a.StartTime.Add(a.Duration)
+	if !a.StartTime.Before(w.End) || !end.After(w.Start) {
+		return a, true
+	}
+	if a.StartTime.Before(w.Start) {
+		a.Duration = w.Start.Sub(a.StartTime)
+		return a, true
+	}
+	if end.After(w.End) {
+		a.StartTime, a.Duration = w.End, end.Sub(w.End)
+		return a, true
+	}
+	return a, false
+}
@@ -0,0 +1,143 @@
+package plan
+
+import (
+	"testing"
+	"time"
+)
+
+// blocks describes actions by description, start and duration for comparison.
+func blocks(actions []Action) []string {
+	var out []string
+	for _, a := range actions {
+		out = append(out, a.Description+" "+a.StartTime.Format("Jan 2 15:04")+" "+a.Duration.String())
+	}
+	return out
+}
+
+func TestConstraintsEnforce(t *testing.T) {
+	dentist := Action{Description: "Dentist", StartTime: at(10, 0), Duration: time.Hour}
+	tomorrow := Action{Description: "Interview", StartTime: at(10, 0).AddDate(0, 0, 1), Duration: time.Hour}
+	run := Routine{Description: "Run", Start: 7 * time.Hour, Duration: time.Hour, Days: Weekdays}
+	church := Routine{Description: "Church", Start: 11 * time.Hour, Duration: time.Hour, Days: []time.Weekday{time.Sunday}}
+
+	tests := []struct {
+		name        string
+		constraints Constraints
+		actions     []Action
+		want        []string
+	}{
+		{
+			name:        "trims around a fixed appointment",
+			constraints: Constraints{Fixed: []Action{dentist}},
+			actions: []Action{
+				{Description: "Study", StartTime: at(9, 0), Duration: 2 * time.Hour},
+				{Description: "Read", StartTime: at(10, 30), Duration: time.Hour},
+			},
+			want: []string{"Study Feb 14 09:00 1h0m0s", "Dentist Feb 14 10:00 1h0m0s", "Read Feb 14 11:00 30m0s"},
+		},
+		{
+			name:        "drops actions inside a blackout",
+			constraints: Constraints{Blackouts: []Window{{Start: at(12, 0), End: at(14, 0)}}},
+			actions: []Action{
+				{Description: "Lunch", StartTime: at(12, 30), Duration: time.Hour},
+				{Description: "Work", StartTime: at(14, 0), Duration: time.Hour},
+			},
+			want: []string{"Work Feb 14 14:00 1h0m0s"},
+		},
+		{
+			name:        "keeps to waking hours",
+			constraints: Constraints{Wake: 7 * time.Hour, Sleep: 22 * time.Hour},
+			actions: []Action{
+				{Description: "Wake up", StartTime: at(6, 0), Duration: 2 * time.Hour},
+				{Description: "Party", StartTime: at(21, 0), Duration: 3 * time.Hour},
+			},
+			want: []string{"Wake up Feb 14 07:00 1h0m0s", "Party Feb 14 21:00 1h0m0s"},
+		},
+		{
+			name:        "leaves out appointments on other days",
+			constraints: Constraints{Fixed: []Action{dentist, tomorrow}},
+			want:        []string{"Dentist Feb 14 10:00 1h0m0s"},
+		},
+		{
+			name:        "keeps appointments already planned",
+			constraints: Constraints{Fixed: []Action{dentist}},
+			actions:     []Action{{ID: "kept", Description: "Dentist", StartTime: at(10, 0), Duration: time.Hour, Status: Completed}},
+			want:        []string{"Dentist Feb 14 10:00 1h0m0s"},
+		},
+		{
+			name:        "adds routines occurring that day",
+			constraints: Constraints{Routines: []Routine{run, church}},
+			actions:     []Action{{Description: "Breakfast", StartTime: at(7, 30), Duration: time.Hour}},
+			want:        []string{"Run Feb 14 07:00 1h0m0s", "Breakfast Feb 14 08:00 30m0s"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := blocks(tt.constraints.Enforce(tt.actions, at(6, 0)))
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("action %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestConstraintsEnforceKeepsStatus(t *testing.T) {
+	dentist := Action{Description: "Dentist", StartTime: at(10, 0), Duration: time.Hour}
+	kept := dentist
+	kept.ID, kept.Status = "kept", Completed
+	got := Constraints{Fixed: []Action{dentist}}.Enforce([]Action{kept}, at(12, 0))
+	if len(got) != 1 || got[0].ID != "kept" || got[0].Status != Completed {
+		t.Errorf("Enforce = %+v, want the planned appointment kept as it was", got)
+	}
+}
+
+func TestConstraintsFit(t *testing.T) {
+	c := Constraints{
+		Fixed:    []Action{{Description: "Dentist", StartTime: at(10, 0), Duration: time.Hour}},
+		Routines: []Routine{{Description: "Run", Start: 17 * time.Hour, Duration: time.Hour}},
+	}
+	tests := []struct {
+		name   string
+		action Action
+		want   string
+		ok     bool
+	}{
+		{"before an appointment", Action{Description: "Call", StartTime: at(9, 30), Duration: time.Hour}, "Call Feb 14 09:30 30m0s", true},
+		{"over an appointment", Action{Description: "Call", StartTime: at(10, 15), Duration: 30 * time.Minute}, "", false},
+		{"over a routine", Action{Description: "Nap", StartTime: at(16, 0), Duration: 2 * time.Hour}, "Nap Feb 14 16:00 1h0m0s", true},
+		{"on another day", Action{Description: "Call", StartTime: at(10, 0).AddDate(0, 0, 1), Duration: time.Hour}, "Call Feb 15 10:00 1h0m0s", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := c.Fit(tt.action)
+			if ok != tt.ok {
+				t.Fatalf("Fit reported %v, want %v", ok, tt.ok)
+			}
+			if ok && blocks([]Action{got})[0] != tt.want {
+				t.Errorf("Fit = %q, want %q", blocks([]Action{got})[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestRoutineOccursOn(t *testing.T) {
+	tests := []struct {
+		days []time.Weekday
+		want bool
+	}{
+		{nil, true},
+		{Weekdays, true},
+		{Weekends, false},
+		{[]time.Weekday{time.Wednesday}, true},
+	}
+	for _, tt := range tests {
+		if got := (Routine{Days: tt.days}).occursOn(at(9, 0)); got != tt.want {
+			t.Errorf("occursOn(%v) = %v, want %v", tt.days, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PlanDiff is how a plan changed: actions added, removed, or moved to another
+// time or location.
+type PlanDiff struct {
+	Added   []Action
+	Removed []Action
+	Moved   []Move
+}
+
+// Move is an action before and after it was moved.
+type Move struct {
+	Old, New Action
+}
+
+// Diff compares two plans. Actions are matched by ID, or failing that by
+// description, so an action re-created by a replan at another time counts as
+// moved rather than removed and added.
+func Diff(old, new []Action) PlanDiff {
+	var d PlanDiff
+	matched := make([]bool, len(new))
+	match := func(a Action) int {
+		for j, b := range new {
+			if !matched[j] && a.ID != "" && a.ID == b.ID {
+				return j
+			}
+		}
+		for j, b := range new {
+			if !matched[j] && strings.EqualFold(a.Description, b.Description) {
+				return j
+			}
+		}
+		return -1
+	}
+	for _, a := range old {
+		j := match(a)
+		if j < 0 {
+			d.Removed = append(d.Removed, a)
+			continue
+		}
+		matched[j] = true
+		b := new[j]
+		if !a.StartTime.Equal(b.StartTime) || a.Duration != b.Duration || a.Location != b.Location {
+			d.Moved = append(d.Moved, Move{Old: a, New: b})
+		}
+	}
+	for j, b := range new {
+		if !matched[j] {
+			d.Added = append(d.Added, b)
+		}
+	}
+	return d
+}
+
+// Empty reports whether nothing changed.
+func (d PlanDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Moved) == 0
+}
+
+// String summarises the changes, one per line, e.g. "moved Lunch from 12:00 PM
+// to 1:00 PM".
+func (d PlanDiff) String() string {
+	var lines []string
+	for _, a := range d.Added {
+		lines = append(lines, fmt.Sprintf("added %s at %s", a.Description, a.StartTime.Format("3:04 PM")))
+	}
+	for _, a := range d.Removed {
+		lines = append(lines, fmt.Sprintf("removed %s at %s", a.Description, a.StartTime.Format("3:04 PM")))
+	}
+	for _, m := range d.Moved {
+		line := fmt.Sprintf("moved %s from %s to %s", m.New.Description, m.Old.StartTime.Format("3:04 PM"), m.New.StartTime.Format("3:04 PM"))
+		if m.Old.Duration != m.New.Duration {
+			line += fmt.Sprintf(", now lasting %s", m.New.Duration)
+		}
+		if m.Old.Location != m.New.Location {
+			line += ", now at " + m.New.Location
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,36 @@
+package plan
+
+import (
+	"encoding/json"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Exemplar is an example day's plan for a persona, such as a shopkeeper who
+// opens at nine or a doctor on call, shown to the model as a worked example.
+type Exemplar struct {
+	Persona string // Who the plan is for, written like an agent summary.
+	Actions []Action
+}
+
+// AddExemplar registers an example plan for the persona.
+func (p *Planner) AddExemplar(persona string, actions []Action) {
+	p.Exemplars = append(p.Exemplars, Exemplar{Persona: persona, Actions: actions})
+}
+
+// exemplarMessages presents the exemplars as earlier turns of the
+// conversation, each persona followed by its plan in the requested JSON form.
+func (p *Planner) exemplarMessages() []openai.ChatCompletionMessage {
+	var messages []openai.ChatCompletionMessage
+	for _, e := range p.Exemplars {
+		plan, err := json.Marshal(toStructured(e.Actions))
+		if err != nil {
+			continue
+		}
+		messages = append(messages,
+			openai.ChatCompletionMessage{Role: "user", Content: "Agent Summary:\n" + e.Persona},
+			openai.ChatCompletionMessage{Role: "assistant", Content: string(plan)},
+		)
+	}
+	return messages
+}
@@ -0,0 +1,79 @@
+package plan
+
+import "strings"
+
+// Location is a place in the agent's environment, such as a building, which
+// may contain smaller areas, such as its rooms.
+type Location struct {
+	Name  string
+	Areas []Location
+}
+
+// locationSeparator joins the names in a location path, e.g.
+// "Oak Hill College: Library: Reading Room".
+const locationSeparator = ": "
+
+// paths lists the full path of the location and each of its areas.
+func (l Location) paths(prefix string) []string {
+	path := prefix + l.Name
+	out := []string{path}
+	for _, a := range l.Areas {
+		out = append(out, a.paths(path+locationSeparator)...)
+	}
+	return out
+}
+
+// locationPaths lists the paths of every known location.
+func (p *Planner) locationPaths() []string {
+	var paths []string
+	for _, l := range p.Locations {
+		paths = append(paths, l.paths("")...)
+	}
+	return paths
+}
+
+// locationPrompt instructs the model to place every action at a known
+// location, or is empty if there are none.
+func (p *Planner) locationPrompt() string {
+	paths := p.locationPaths()
+	if len(paths) == 0 {
+		return ""
+	}
+	return "\nEvery action's location must be exactly one of these known locations:\n- " + strings.Join(paths, "\n- ")
+}
+
+// resolveLocations matches each action's location against the known
+// locations, accepting a full path or the unique path ending in the name
+// given, ignoring case. Locations matching none are cleared rather than
+// sending the agent somewhere that does not exist.
+func (p *Planner) resolveLocations(actions []Action) {
+	paths := p.locationPaths()
+	if len(paths) == 0 {
+		return
+	}
+	for i := range actions {
+		actions[i].Location = matchLocation(paths, actions[i].Location)
+	}
+}
+
+// matchLocation returns the known path the location refers to, or "".
+func matchLocation(paths []string, location string) string {
+	location = strings.TrimSpace(location)
+	var match string
+	for _, path := range paths {
+		if strings.EqualFold(path, location) {
+			return path
+		}
+		name := path
+		if i := strings.LastIndex(path, locationSeparator); i >= 0 {
+			name = path[i+len(locationSeparator):]
+		}
+		if strings.EqualFold(name, location) {
+			if match != "" {
+				return "" // Ambiguous.
+			}
+			match = path
+		}
+	}
+	return match
+}
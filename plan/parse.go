@@ -0,0 +1,137 @@
+package plan
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// clockFormats are the accepted formats for a time of day, once normalised by
+// parseClock.
+var clockFormats = []string{"3:04 PM", "3 PM", "15:04", "15"}
+
+// parseClock parses a time of day in 12 or 24-hour form, such as "3:04 PM",
+// "3pm", "3:04 p.m." or "15:04".
+func parseClock(s string) (time.Time, error) {
+	s = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(s), ".", ""))
+	if m, ok := strings.CutSuffix(s, "AM"); ok {
+		s = strings.TrimSpace(m) + " AM"
+	} else if m, ok := strings.CutSuffix(s, "PM"); ok {
+		s = strings.TrimSpace(m) + " PM"
+	}
+	for _, f := range clockFormats {
+		if t, err := time.Parse(f, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
+var (
+	// timeRangePattern matches a line opening with a time range, e.g.
+	// "**8:00 AM - 9:00 AM: Morning Routine**", "- 14:00–15:30 — Meeting" or
+	// "1. 9am to 10am Breakfast", capturing the start, end and any title.
+	timeRangePattern = regexp.MustCompile(`(?i)^(?:[-*•+]\s+|\d+[.)]\s+)?[*_]*\s*(\d{1,2}(?::\d{2})?\s*(?:[ap]\.?m\.?)?)\s*(?:-|–|—|to)\s*(\d{1,2}(?::\d{2})?\s*(?:[ap]\.?m\.?)?)[*_]*\s*(?:[:|\-–—]\s*)?(.*)$`)
+	// meridiemPattern matches the AM or PM of a time.
+	meridiemPattern = regexp.MustCompile(`(?i)[ap]\.?m\.?$`)
+	// bulletPattern matches the bullet or number opening a list item.
+	bulletPattern = regexp.MustCompile(`^(?:[-*•+]|\d+[.)])\s+`)
+)
+
+// parseRange parses the start and end of a time range. A start without AM or
+// PM takes the end's, as in "8:00 - 9:30 AM", unless that would put it after
+// the end.
+func parseRange(start, end string) (time.Time, time.Time, error) {
+	e, err := parseClock(end)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if m := meridiemPattern.FindString(strings.TrimSpace(end)); m != "" && !meridiemPattern.MatchString(strings.TrimSpace(start)) {
+		if s, err := parseClock(start + " " + m); err == nil && !s.After(e) {
+			return s, e, nil
+		}
+	}
+	s, err := parseClock(start)
+	return s, e, err
+}
+
+// bareRange reports whether a time range gives bare hours, as in "9-10", with
+// neither minutes nor AM or PM, which could as well be a count.
+func bareRange(start, end string) bool {
+	return !strings.Contains(start+end, ":") && !meridiemPattern.MatchString(strings.TrimSpace(start)) && !meridiemPattern.MatchString(strings.TrimSpace(end))
+}
+
+// parsePlan converts the language model's output in markdown, rather than
+// JSON, into actions. Each action opens with a time range in 12 or 24-hour
+// form, in a heading, bullet or plain line; any lines that follow, such as
+// nested bullets, describe a block that has no title of its own. Within a
+// block, a range of bare hours such as "2-3 chapters" is taken as detail.
+func (p *Planner) parsePlan(planText string) ([]Action, error) {
+	var actions []Action
+	var details []string
+	flush := func() {
+		if len(actions) == 0 {
+			return
+		}
+		last := &actions[len(actions)-1]
+		if last.Description == "" {
+			last.Description = strings.Join(details, "; ")
+		}
+		details = nil
+	}
+
+	for _, line := range strings.Split(planText, "\n") {
+		line = strings.TrimSpace(line)
+		heading := strings.HasPrefix(line, "#")
+		line = strings.TrimSpace(strings.TrimLeft(line, "#"))
+		if line == "" {
+			continue
+		}
+		m := timeRangePattern.FindStringSubmatch(line)
+		if m != nil && len(actions) > 0 && bareRange(m[1], m[2]) {
+			m = nil
+		}
+		if m == nil {
+			// Lines before the first block, such as the title, and headings
+			// without a time range are ignored.
+			if len(actions) > 0 && !heading {
+				if d := strings.Trim(bulletPattern.ReplaceAllString(line, ""), "*_ "); d != "" {
+					details = append(details, d)
+				}
+			}
+			continue
+		}
+		start, end, err := parseRange(m[1], m[2])
+		if err != nil {
+			continue
+		}
+		duration, ok := span(start, end)
+		if !ok {
+			continue
+		}
+		flush()
+		actions = append(actions, Action{
+			ID:          uuid.NewString(),
+			Description: strings.Trim(m[3], "*_ "),
+			StartTime:   start,
+			Duration:    duration,
+		})
+	}
+	flush()
+
+	// Blocks with neither a title nor details are dropped.
+	var out []Action
+	for _, a := range actions {
+		if a.Description != "" {
+			out = append(out, a)
+		}
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no actions found in plan")
+	}
+	return out, nil
+}
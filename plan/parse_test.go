@@ -0,0 +1,80 @@
+package plan
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePlan(t *testing.T) {
+	type block struct {
+		description string
+		start       string // 15:04
+		duration    time.Duration
+	}
+	tests := []struct {
+		name string
+		text string
+		want []block
+	}{
+		{
+			name: "bold bullets",
+			text: "# Plan\n- **8:00 AM - 9:00 AM: Breakfast**\n- **9:00 AM - 12:00 PM: Study**",
+			want: []block{{"Breakfast", "08:00", time.Hour}, {"Study", "09:00", 3 * time.Hour}},
+		},
+		{
+			name: "headings",
+			text: "## Morning\n### 8:00 AM - 9:00 AM: Breakfast\n### 9:00 AM - 10:30 AM: Read",
+			want: []block{{"Breakfast", "08:00", time.Hour}, {"Read", "09:00", 90 * time.Minute}},
+		},
+		{
+			name: "24-hour times and dashes",
+			text: "1. 14:00–15:30 — Meeting\n2. 15:30 — 16:00 | Coffee",
+			want: []block{{"Meeting", "14:00", 90 * time.Minute}, {"Coffee", "15:30", 30 * time.Minute}},
+		},
+		{
+			name: "shared meridiem",
+			text: "- 8:00 - 9:30 AM: Gym",
+			want: []block{{"Gym", "08:00", 90 * time.Minute}},
+		},
+		{
+			name: "untitled block with details",
+			text: "**9am to 11am**\n  - Write 2-3 chapters of the thesis\n  - Email advisor\n**11am to 12pm** Lunch",
+			want: []block{{"Write 2-3 chapters of the thesis; Email advisor", "09:00", 2 * time.Hour}, {"Lunch", "11:00", time.Hour}},
+		},
+		{
+			name: "bare hours open the first block",
+			text: "9-10 Breakfast\n- 2-3 pages of notes\n10:00 - 11:00 Walk",
+			want: []block{{"Breakfast", "09:00", time.Hour}, {"Walk", "10:00", time.Hour}},
+		},
+		{
+			name: "past midnight",
+			text: "- 11:00 PM - 1:00 AM: Party",
+			want: []block{{"Party", "23:00", 2 * time.Hour}},
+		},
+	}
+	var p Planner
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.parsePlan(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d actions, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, w := range tt.want {
+				g := got[i]
+				if g.Description != w.description || g.StartTime.Format("15:04") != w.start || g.Duration != w.duration {
+					t.Errorf("action %d = %q at %s for %s, want %q at %s for %s", i, g.Description, g.StartTime.Format("15:04"), g.Duration, w.description, w.start, w.duration)
+				}
+			}
+		})
+	}
+}
+
+func TestParsePlanEmpty(t *testing.T) {
+	var p Planner
+	if _, err := p.parsePlan("# Plan\nNothing planned today."); err == nil {
+		t.Error("parsePlan succeeded on a plan without actions")
+	}
+}
@@ -2,14 +2,15 @@ package plan
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"slices"
 	"sort"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/google/uuid"
+	"github.com/lordtatty/a25/llm"
 	openai "github.com/sashabaranov/go-openai"
 )
 
@@ -19,6 +20,10 @@ type OpenAIClient interface {
 
 // Plan represents a high-level plan composed of actions.
 type Plan struct {
+	// Validation decides how overlapping actions and gaps are handled when
+	// actions are set or added. By default they are accepted.
+	Validation Validation
+
 	actions []Action
 }
 
@@ -29,6 +34,13 @@ type Action struct {
 	Location    string
 	StartTime   time.Time
 	Duration    time.Duration
+	Status      Status
+	Goal        string // The ID of the agent's goal the action works towards, if any.
+	// Priority ranks the action against others it conflicts with; higher is
+	// more urgent. Deadline, if set, is when the action must be finished by.
+	// Both guide Rebalance.
+	Priority int
+	Deadline time.Time
 }
 
 // Actions returns all actions in the plan.
@@ -36,27 +48,45 @@ func (p *Plan) Actions() []Action {
 	return p.actions
 }
 
-// NextAction returns the next action in the plan based on the current time.
+// NextAction returns the first action in the plan that is not yet done, or nil
+// if every action is.
 func (p *Plan) NextAction() *Action {
-	return &p.actions[0]
+	for i := range p.actions {
+		if !p.actions[i].Done() {
+			return &p.actions[i]
+		}
+	}
+	return nil
 }
 
-// AddAction adds an action to the plan in chronological order.
-func (p *Plan) AddAction(a Action) {
+// AddAction adds an action to the plan in chronological order, validating the
+// result; a rejected action is not added.
+func (p *Plan) AddAction(a Action) error {
 	a.ID = uuid.NewString()
 	// Insert the action in the correct position to maintain chronological order
 	i := sort.Search(len(p.actions), func(i int) bool {
 		return p.actions[i].StartTime.After(a.StartTime)
 	})
-	p.actions = append(p.actions[:i], append([]Action{a}, p.actions[i:]...)...)
+	actions := slices.Insert(slices.Clone(p.actions), i, a)
+	if err := p.validate(actions); err != nil {
+		return err
+	}
+	p.actions = actions
+	return nil
 }
 
-// SetActions sets the actions and ensures they are sorted in chronological order.
-func (p *Plan) SetActions(actions []Action) {
-	p.actions = actions
-	sort.Slice(p.actions, func(i, j int) bool {
-		return p.actions[i].StartTime.Before(p.actions[j].StartTime)
+// SetActions sets the actions, sorted in chronological order, and validates
+// them; rejected actions leave the plan unchanged.
+func (p *Plan) SetActions(actions []Action) error {
+	actions = slices.Clone(actions)
+	sort.SliceStable(actions, func(i, j int) bool {
+		return actions[i].StartTime.Before(actions[j].StartTime)
 	})
+	if err := p.validate(actions); err != nil {
+		return err
+	}
+	p.actions = actions
+	return nil
 }
 
 // RemoveAction removes an action from the plan based on its ID.
@@ -71,120 +101,149 @@ func (p *Plan) RemoveAction(id string) error {
 }
 
 type Planner struct {
-	Client OpenAIClient
+	Client      OpenAIClient
+	Model       string   // Chat model; defaults to GPT-4o mini.
+	Temperature *float32 // Sampling temperature, set with llm.Temp; defaults to 1.
+	// Locations, if set, are the places in the environment. Each planned action
+	// is placed at one of them, so the agent has somewhere to go.
+	Locations []Location
+	// Distances, if set, estimates travel times between locations, so travel
+	// actions are inserted between actions at different places.
+	Distances DistanceModel
+	// Exemplars are example plans shown to the model before it plans a day, so
+	// plans keep a consistent style for specialised agents.
+	Exemplars []Exemplar
+	// Config sets the granularity and scope of plans; by default both are left
+	// to the model.
+	Config PlannerConfig
 }
 
-// parsePlan converts the language model's output into a Plan struct.
-func (p *Planner) parsePlan(planText string) ([]Action, error) {
-	var actions []Action
-	lines := strings.Split(planText, "\n")
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
+// model returns the chat model, defaulting to GPT-4o mini.
+func (p *Planner) model() string {
+	return llm.Model(p.Model)
+}
 
-		// Skip the main title.
-		if strings.HasPrefix(line, "**High-Level Plan for the Day") {
-			continue
-		}
+// temperature returns the sampling temperature, defaulting to 1.
+func (p *Planner) temperature() float32 {
+	return llm.Temperature(p.Temperature)
+}
 
-		// Skip bullet points.
-		if strings.HasPrefix(line, "-") {
-			continue
-		}
+// PlanDay generates a high-level plan for the agent's day that respects the
+// given constraints.
+func (p *Planner) PlanDay(currentTime time.Time, agentSummary string, constraints Constraints) ([]Action, error) {
+	return p.PlanDayContext(context.Background(), currentTime, agentSummary, constraints)
+}
 
-		// Remove asterisks from headings.
-		line = strings.Trim(line, "*")
+// PlanDayContext is PlanDay under the given context.
+func (p *Planner) PlanDayContext(ctx context.Context, currentTime time.Time, agentSummary string, constraints Constraints) ([]Action, error) {
+	constraints = constraints.forDay(currentTime)
+	// System prompt with detailed instructions for the model to follow.
+	sysPrompt := `You are an expert planner. Your task is to generate a detailed, structured daily plan for the agent based on their summary.
+Respond with a JSON object of the form ` + planSchema + `.
+1. List the actions in chronological order, each with a start and end time of day such as '8:00 AM'.
+2. Give each action the location where it takes place and a description of the specific activities within it.
+3. Ensure consistency, clarity, and that the activities align with the agent's description and traits.
+4. Make progress on any goals in the summary, especially those with near deadlines, giving the ID of the goal each action works towards.` + p.locationPrompt() + p.Config.prompt() + constraints.prompt()
 
-		// Check if line contains a time range and description.
-		if !strings.Contains(line, ": ") || !strings.Contains(line, "-") {
-			continue
-		}
+	// User prompt with variable input.
+	usrPrompt := fmt.Sprintf("Agent Summary:\n%s\nCurrent Time: %s", agentSummary, currentTime.Format("January 2, 2006"))
 
-		// Example format: "8:00 AM - 9:00 AM: Morning Routine"
-		// Split line into time block and action description.
-		parts := strings.SplitN(line, ": ", 2)
-		if len(parts) != 2 {
-			continue
-		}
+	// Call the language model, showing it any exemplars first.
+	messages := []openai.ChatCompletionMessage{{Role: "system", Content: sysPrompt}}
+	messages = append(messages, p.exemplarMessages()...)
+	messages = append(messages, openai.ChatCompletionMessage{Role: "user", Content: usrPrompt})
+	resp, err := p.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model:          p.model(),
+		Messages:       messages,
+		Temperature:    p.temperature(),
+		ResponseFormat: &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject},
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		// Extract and parse the time range.
-		timeRange := strings.TrimSpace(parts[0])
-		timeParts := strings.Split(timeRange, " - ")
-		if len(timeParts) != 2 {
-			continue
+	// Parse the response to extract the plan, falling back to the markdown
+	// format should the model answer in it instead.
+	content := resp.Choices[0].Message.Content
+	actions, err := parseStructuredPlan(content)
+	if err != nil {
+		fallback, ferr := p.parsePlan(content)
+		if ferr != nil {
+			return nil, err
 		}
+		actions = fallback
+	}
 
-		// Parse the start time and end time.
-		startTime, err := time.Parse("3:04 PM", strings.TrimSpace(timeParts[0]))
-		if err != nil {
-			continue
-		}
+	anchor(actions, currentTime)
+	p.Config.snap(actions)
+	actions = p.Config.limit(actions)
+	p.resolveLocations(actions)
+	actions = constraints.apply(actions, currentTime)
+	sort.SliceStable(actions, func(i, j int) bool {
+		return actions[i].StartTime.Before(actions[j].StartTime)
+	})
+	return insertTravel(actions, p.Distances, constraints.Fixed), nil
+}
 
-		endTime, err := time.Parse("3:04 PM", strings.TrimSpace(timeParts[1]))
-		if err != nil {
-			continue
-		}
+// span returns the duration between two times of day, treating an end before
+// the start as falling after midnight. It reports false for empty blocks.
+func span(start, end time.Time) (time.Duration, bool) {
+	d := end.Sub(start)
+	if d < 0 {
+		d += 24 * time.Hour
+	}
+	return d, d > 0
+}
 
-		// Calculate the duration.
-		duration := endTime.Sub(startTime)
-		if duration <= 0 {
-			continue
+// anchor dates actions parsed as bare times of day on the day of currentTime,
+// in its location. Actions are taken to be in chronological order, so one
+// starting earlier in the day than the action before it is moved to the next
+// day, as when a plan runs past midnight.
+func anchor(actions []Action, currentTime time.Time) {
+	y, m, d := currentTime.Date()
+	var prev time.Time
+	for i, a := range actions {
+		hour, minute, sec := a.StartTime.Clock()
+		start := time.Date(y, m, d, hour, minute, sec, 0, currentTime.Location())
+		if !prev.IsZero() && start.Before(prev) {
+			start = start.AddDate(0, 0, 1)
 		}
+		actions[i].StartTime = start
+		prev = start
+	}
+}
 
-		// Extract the action description.
-		description := strings.TrimSpace(parts[1])
+// restKeywords identify actions that let an agent recover energy.
+var restKeywords = []string{"rest", "break", "nap", "sleep", "relax", "lunch", "dinner", "breakfast", "meal"}
 
-		// Create and add the action.
-		action := Action{
-			ID:          uuid.NewString(),
-			Description: description,
-			StartTime:   startTime,
-			Duration:    duration,
+// IsRest reports whether the action lets the agent rest: whether its
+// description has a rest keyword as a word, alone or with a plural or verb
+// ending such as "resting", but not within another word such as "interest".
+func (a Action) IsRest() bool {
+	words := strings.FieldsFunc(strings.ToLower(a.Description), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, w := range words {
+		for _, k := range restKeywords {
+			if suffix, ok := strings.CutPrefix(w, k); ok && slices.Contains(restSuffixes, suffix) {
+				return true
+			}
 		}
-		actions = append(actions, action)
 	}
-
-	if len(actions) == 0 {
-		return nil, errors.New("no actions found in plan")
-	}
-
-	return actions, nil
+	return false
 }
 
-// PlanDay generates a high-level plan for the agent's day.
-func (p *Planner) PlanDay(currentTime time.Time, agentSummary string) ([]Action, error) {
-	// System prompt with detailed instructions for the model to follow.
-	sysPrompt := `You are an expert planner. Your task is to generate a detailed, structured daily plan for the agent based on their summary. 
-The plan should adhere to the following format:
-1. The plan title should be formatted as: '**High-Level Plan for the Day: [Date]**'.
-2. Include clear time blocks (e.g., '**8:00 AM - 9:00 AM: Morning Routine**').
-3. Under each time block, provide a bullet list with specific activities. Each bullet should describe actions or goals within that time block.
-4. Ensure consistency, clarity, and that the activities align with the agent's description and traits.`
+// restSuffixes are the endings a rest keyword may take, e.g. "naps" or "relaxing".
+var restSuffixes = []string{"", "s", "es", "ed", "ing"}
 
-	// User prompt with variable input.
-	usrPrompt := fmt.Sprintf("Agent Summary:\n%s\nCurrent Time: %s", agentSummary, currentTime.Format("January 2, 2006"))
+// HasRest reports whether any of the actions lets the agent rest.
+func HasRest(actions []Action) bool {
+	return slices.ContainsFunc(actions, Action.IsRest)
+}
 
-	// Call the language model.
-	resp, err := p.Client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
-		Model: openai.GPT4oMini,
-		Messages: []openai.ChatCompletionMessage{
-			{Role: "system", Content: sysPrompt},
-			{Role: "user", Content: usrPrompt},
-		},
-		Temperature: 1,
+// Truncate removes all actions starting at or after the given time.
+func (p *Plan) Truncate(after time.Time) {
+	p.actions = slices.DeleteFunc(p.actions, func(a Action) bool {
+		return !a.StartTime.Before(after)
 	})
-	if err != nil {
-		return nil, err
-	}
-
-	// Parse the response to extract the plan.
-	actions, err := p.parsePlan(resp.Choices[0].Message.Content)
-	if err != nil {
-		return nil, err
-	}
-
-	return actions, nil
 }
@@ -0,0 +1,26 @@
+package plan
+
+import "testing"
+
+func TestIsRest(t *testing.T) {
+	tests := []struct {
+		description string
+		want        bool
+	}{
+		{"Rest for the remainder of the day", true},
+		{"Take a nap", true},
+		{"Relaxing at home", true},
+		{"Lunch at Hobbs Cafe", true},
+		{"Coffee break", true},
+		{"Read up on a new interest", false},
+		{"Walk in the forest", false},
+		{"Meet Maria at the restaurant", false},
+		{"Restless pacing", false},
+		{"Write the thesis", false},
+	}
+	for _, tt := range tests {
+		if got := (Action{Description: tt.description}).IsRest(); got != tt.want {
+			t.Errorf("IsRest(%q) = %t, want %t", tt.description, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package plan
+
+import (
+	"sort"
+	"time"
+)
+
+// end returns when the action finishes.
+func (a Action) end() time.Time {
+	return a.StartTime.Add(a.Duration)
+}
+
+// outranks reports whether the action should keep its time over b: it has a
+// higher priority or, at equal priority, an earlier deadline.
+func (a Action) outranks(b Action) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	return !a.Deadline.IsZero() && (b.Deadline.IsZero() || a.Deadline.Before(b.Deadline))
+}
+
+// Rebalance resolves overlapping actions, as when urgent work is inserted into
+// a full day. Of two overlapping actions, the one of lower priority yields: it
+// is cut short if it keeps at least half its length, or otherwise moved after
+// the other, with anything it then runs into moved along too. Actions moved
+// past their deadline are compressed to meet it where they can. Actions done
+// or in progress never move.
+func (p *Plan) Rebalance() {
+	acts := p.actions
+	sort.SliceStable(acts, func(i, j int) bool {
+		return acts[i].StartTime.Before(acts[j].StartTime)
+	})
+	for i := 1; i < len(acts); i++ {
+		prev, cur := &acts[i-1], &acts[i]
+		if !cur.StartTime.Before(prev.end()) {
+			continue
+		}
+		prevFixed, curFixed := prev.settled(), cur.settled()
+		switch {
+		case prevFixed && curFixed:
+			continue
+		case prevFixed || (!curFixed && !cur.outranks(*prev)):
+			cur.StartTime = prev.end()
+			cur.meetDeadline()
+		case cur.StartTime.Sub(prev.StartTime) >= prev.Duration/2:
+			prev.Duration = cur.StartTime.Sub(prev.StartTime)
+		default:
+			prev.StartTime = cur.end()
+			prev.meetDeadline()
+			acts[i-1], acts[i] = acts[i], acts[i-1]
+			// The action moved forward may now overlap the one before it.
+			i = max(i-2, 0)
+		}
+	}
+}
+
+// settled reports whether the action is done or in progress, and so keeps its
+// time.
+func (a Action) settled() bool {
+	return a.Done() || a.Status == InProgress
+}
+
+// meetDeadline shortens the action to end by its deadline if it would finish
+// after it but starts before it.
+func (a *Action) meetDeadline() {
+	if !a.Deadline.IsZero() && a.end().After(a.Deadline) && a.StartTime.Before(a.Deadline) {
+		a.Duration = a.Deadline.Sub(a.StartTime)
+	}
+}
@@ -0,0 +1,96 @@
+package plan
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRebalance(t *testing.T) {
+	tests := []struct {
+		name    string
+		actions []Action
+		want    []string
+	}{
+		{
+			name: "lower priority is cut short",
+			actions: []Action{
+				{Description: "Read", StartTime: at(9, 0), Duration: time.Hour},
+				{Description: "Urgent", StartTime: at(9, 30), Duration: time.Hour, Priority: 1},
+			},
+			want: []string{"Read Feb 14 09:00 30m0s", "Urgent Feb 14 09:30 1h0m0s"},
+		},
+		{
+			name: "lower priority is moved after",
+			actions: []Action{
+				{Description: "Read", StartTime: at(9, 0), Duration: time.Hour},
+				{Description: "Urgent", StartTime: at(9, 15), Duration: time.Hour, Priority: 1},
+				{Description: "Lunch", StartTime: at(10, 30), Duration: time.Hour},
+			},
+			want: []string{"Urgent Feb 14 09:15 1h0m0s", "Read Feb 14 10:15 1h0m0s", "Lunch Feb 14 11:15 1h0m0s"},
+		},
+		{
+			name: "equal priority moves the later",
+			actions: []Action{
+				{Description: "Read", StartTime: at(9, 0), Duration: time.Hour},
+				{Description: "Write", StartTime: at(9, 30), Duration: time.Hour},
+			},
+			want: []string{"Read Feb 14 09:00 1h0m0s", "Write Feb 14 10:00 1h0m0s"},
+		},
+		{
+			name: "in progress is not moved for higher priority",
+			actions: []Action{
+				{Description: "Read", StartTime: at(9, 0), Duration: time.Hour, Status: InProgress},
+				{Description: "Urgent", StartTime: at(9, 30), Duration: time.Hour, Priority: 1},
+			},
+			want: []string{"Read Feb 14 09:00 1h0m0s", "Urgent Feb 14 10:00 1h0m0s"},
+		},
+		{
+			name: "completed later action is not moved",
+			actions: []Action{
+				{Description: "Read", StartTime: at(9, 0), Duration: time.Hour, Priority: 1},
+				{Description: "Call", StartTime: at(9, 30), Duration: time.Hour, Status: Completed},
+			},
+			want: []string{"Read Feb 14 09:00 30m0s", "Call Feb 14 09:30 1h0m0s"},
+		},
+		{
+			name: "in progress later action moves the earlier after it",
+			actions: []Action{
+				{Description: "Read", StartTime: at(9, 0), Duration: time.Hour, Priority: 1},
+				{Description: "Call", StartTime: at(9, 10), Duration: time.Hour, Status: InProgress},
+			},
+			want: []string{"Call Feb 14 09:10 1h0m0s", "Read Feb 14 10:10 1h0m0s"},
+		},
+		{
+			name: "settled actions are left overlapping",
+			actions: []Action{
+				{Description: "Read", StartTime: at(9, 0), Duration: time.Hour, Status: Completed},
+				{Description: "Call", StartTime: at(9, 30), Duration: time.Hour, Status: InProgress},
+			},
+			want: []string{"Read Feb 14 09:00 1h0m0s", "Call Feb 14 09:30 1h0m0s"},
+		},
+		{
+			name: "moved action meets its deadline",
+			actions: []Action{
+				{Description: "Urgent", StartTime: at(9, 0), Duration: time.Hour, Priority: 1},
+				{Description: "Report", StartTime: at(9, 30), Duration: time.Hour, Deadline: at(10, 30)},
+			},
+			want: []string{"Urgent Feb 14 09:00 1h0m0s", "Report Feb 14 10:00 30m0s"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Plan
+			p.actions = tt.actions
+			p.Rebalance()
+			got := blocks(p.Actions())
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("action %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
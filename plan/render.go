@@ -0,0 +1,70 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RenderMarkdown renders the plan as a markdown timeline: a table of actions
+// for each day, with their times, locations and status.
+func (p *Plan) RenderMarkdown() string {
+	var sb strings.Builder
+	day := ""
+	for _, a := range p.actions {
+		if d := a.StartTime.Format("Monday, January 2, 2006"); d != day {
+			if day != "" {
+				sb.WriteString("\n")
+			}
+			day = d
+			fmt.Fprintf(&sb, "### %s\n\n| Time | Action | Location | Status |\n| --- | --- | --- | --- |\n", day)
+		}
+		fmt.Fprintf(&sb, "| %s - %s | %s | %s | %s |\n",
+			a.StartTime.Format("3:04 PM"), a.end().Format("3:04 PM"),
+			markdownCell(a.Description), markdownCell(a.Location), a.Status)
+	}
+	return sb.String()
+}
+
+// markdownCell escapes text for use in a markdown table cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "|", `\|`), "\n", " ")
+}
+
+// RenderMermaidGantt renders the plan as a Mermaid Gantt chart, with a section
+// for each day. Completed actions are shown as done, the action in progress
+// as active and prioritised actions as critical. The result is the chart's
+// source, ready to place in a mermaid code block.
+func (p *Plan) RenderMermaidGantt() string {
+	var sb strings.Builder
+	sb.WriteString("gantt\n    dateFormat YYYY-MM-DD HH:mm\n    axisFormat %H:%M\n")
+	day := ""
+	for i, a := range p.actions {
+		if d := a.StartTime.Format("Monday, January 2"); d != day {
+			day = d
+			fmt.Fprintf(&sb, "    section %s\n", day)
+		}
+		var tags []string
+		switch a.Status {
+		case Completed:
+			tags = append(tags, "done")
+		case InProgress:
+			tags = append(tags, "active")
+		}
+		if a.Priority > 0 {
+			tags = append(tags, "crit")
+		}
+		tags = append(tags, fmt.Sprintf("a%d", i+1), a.StartTime.Format("2006-01-02 15:04"), fmt.Sprintf("%dm", max(int(a.Duration.Minutes()), 1)))
+		name := a.Description
+		if a.Status == Skipped {
+			name = "(skipped) " + name
+		}
+		fmt.Fprintf(&sb, "    %s :%s\n", mermaidTask(name), strings.Join(tags, ", "))
+	}
+	return sb.String()
+}
+
+// mermaidTask makes text safe as a Gantt task name, which may not contain
+// colons, semicolons or line breaks.
+func mermaidTask(s string) string {
+	return strings.NewReplacer(":", " -", ";", ",", "#", "", "\n", " ").Replace(s)
+}
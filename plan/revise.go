@@ -0,0 +1,127 @@
+package plan
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Revise has the model replan the rest of the day in light of a reaction, as
+// agents do in the generative agents paper. Actions that are done, in
+// progress or already begun by currentTime are kept as they are; the rest of
+// the plan is replaced with the model's. Travel never moves the constraints'
+// fixed appointments. It returns the revised plan in chronological order.
+func (p *Planner) Revise(current []Action, reaction string, currentTime time.Time, constraints Constraints) ([]Action, error) {
+	return p.ReviseContext(context.Background(), current, reaction, currentTime, constraints)
+}
+
+// ReviseContext is Revise under the given context.
+func (p *Planner) ReviseContext(ctx context.Context, current []Action, reaction string, currentTime time.Time, constraints Constraints) ([]Action, error) {
+	return p.revise(ctx, current, reaction, nil, currentTime, constraints)
+}
+
+// ReviseWithAction is Revise for a reaction that calls for a particular
+// action, such as one proposed by the Reactor. The action starts at
+// currentTime, cutting short whatever the agent was doing, and the model
+// replans the rest of the day from when it ends.
+func (p *Planner) ReviseWithAction(ctx context.Context, current []Action, reaction string, action Action, currentTime time.Time, constraints Constraints) ([]Action, error) {
+	if action.ID == "" {
+		action.ID = uuid.NewString()
+	}
+	action.StartTime = currentTime
+	resolved := []Action{action}
+	p.resolveLocations(resolved)
+	action = resolved[0]
+	if b := p.Config.BlockSize; b > 0 {
+		action.Duration = max(action.Duration.Round(b), b)
+	}
+	return p.revise(ctx, current, reaction, &action, currentTime, constraints)
+}
+
+// revise replans the day from currentTime, or from the end of the given
+// action if there is one, which then comes first.
+func (p *Planner) revise(ctx context.Context, current []Action, reaction string, action *Action, currentTime time.Time, constraints Constraints) ([]Action, error) {
+	resume := currentTime
+	if action != nil {
+		resume = action.StartTime.Add(action.Duration)
+	}
+	var kept []Action
+	var lines []string
+	for _, a := range current {
+		keep := a.Done() || a.Status == InProgress || a.StartTime.Before(currentTime)
+		if keep {
+			if action != nil && !a.Done() && a.StartTime.Add(a.Duration).After(currentTime) {
+				a.Duration = currentTime.Sub(a.StartTime)
+			}
+			kept = append(kept, a)
+		}
+		end := a.StartTime.Add(a.Duration)
+		line := fmt.Sprintf("- %s - %s: %s", a.StartTime.Format("3:04 PM"), end.Format("3:04 PM"), a.Description)
+		if a.Location != "" {
+			line += " (at " + a.Location + ")"
+		}
+		if keep {
+			line += " [" + a.Status.String() + "]"
+		}
+		lines = append(lines, line)
+	}
+
+	sysPrompt := `You are an expert planner. An agent has reacted to something that happened and must revise the rest of their day.
+Respond with a JSON object of the form ` + planSchema + `.
+List only the actions from the current time onwards, in chronological order, each with a start and end time of day such as '8:00 AM', a location and a description.
+Keep any of the agent's remaining plans that still make sense, and fit the reaction in.` + p.locationPrompt() + p.Config.blockPrompt()
+	usrPrompt := fmt.Sprintf("Current plan:\n%s\nReaction: %s\nCurrent Time: %s", strings.Join(lines, "\n"), reaction, currentTime.Format("January 2, 2006 3:04 PM"))
+	if action != nil {
+		sysPrompt += "\nThe agent first does the given action; plan only from when it ends."
+		usrPrompt += fmt.Sprintf("\nAction: %s - %s: %s", action.StartTime.Format("3:04 PM"), resume.Format("3:04 PM"), action.Description)
+		if action.Location != "" {
+			usrPrompt += " (at " + action.Location + ")"
+		}
+	}
+
+	resp, err := p.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: p.model(),
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: sysPrompt},
+			{Role: "user", Content: usrPrompt},
+		},
+		Temperature:    p.temperature(),
+		ResponseFormat: &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject},
+	})
+	if err != nil {
+		return nil, err
+	}
+	revised, err := parseStructuredPlan(resp.Choices[0].Message.Content)
+	if err != nil {
+		return nil, err
+	}
+	anchor(revised, currentTime)
+	p.Config.snap(revised)
+	p.resolveLocations(revised)
+
+	actions := kept
+	if action != nil {
+		actions = append(actions, *action)
+	}
+	for _, a := range revised {
+		// Anything the model placed entirely before it could start is dropped,
+		// and anything overlapping the start cut short.
+		end := a.StartTime.Add(a.Duration)
+		if !end.After(resume) {
+			continue
+		}
+		if action != nil && a.StartTime.Before(resume) {
+			a.StartTime, a.Duration = resume, end.Sub(resume)
+		}
+		actions = append(actions, a)
+	}
+	var out Plan
+	if err := out.SetActions(actions); err != nil {
+		return nil, err
+	}
+	return insertTravel(out.Actions(), p.Distances, constraints.forDay(currentTime).Fixed), nil
+}
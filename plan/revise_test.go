@@ -0,0 +1,44 @@
+package plan
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// replyClient answers every chat completion with the same reply.
+type replyClient string
+
+func (c replyClient) CreateChatCompletion(context.Context, openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error) {
+	return &openai.ChatCompletionResponse{Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: string(c)}}}}, nil
+}
+
+func TestReviseKeepsFixedAppointments(t *testing.T) {
+	dentist := Action{Description: "Dentist", Location: "clinic", StartTime: at(9, 0), Duration: time.Hour}
+	p := &Planner{
+		Client:    replyClient(`{"actions": [{"start": "9:00 AM", "end": "10:00 AM", "location": "clinic", "description": "Dentist"}, {"start": "10:00 AM", "end": "11:00 AM", "location": "home", "description": "Lunch"}]}`),
+		Distances: TravelTimes{Default: 30 * time.Minute},
+	}
+	tests := []struct {
+		name        string
+		constraints Constraints
+		want        time.Duration
+	}{
+		{"fixed", Constraints{Fixed: []Action{dentist}}, time.Hour},
+		{"not fixed", Constraints{}, 30 * time.Minute},
+	}
+	for _, tt := range tests {
+		revised, err := p.ReviseContext(context.Background(), []Action{dentist}, "Maria called", at(8, 0), tt.constraints)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(revised) == 0 {
+			t.Fatalf("%s: empty revised plan", tt.name)
+		}
+		if revised[0].Description != "Dentist" || revised[0].Duration != tt.want {
+			t.Errorf("%s: revised plan starts %+v, want the dentist for %s", tt.name, revised[0], tt.want)
+		}
+	}
+}
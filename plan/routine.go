@@ -0,0 +1,50 @@
+package plan
+
+import (
+	"slices"
+	"time"
+)
+
+// Routine is a recurring activity, such as a morning run on weekdays from 7 to
+// 8am, that is placed in each day's plan before the model fills in the rest.
+type Routine struct {
+	Description string
+	Location    string
+	Start       time.Duration // Time of day as an offset from midnight.
+	Duration    time.Duration
+	Days        []time.Weekday // Days the routine occurs on; every day if empty.
+}
+
+// Weekdays are Monday to Friday, for routines such as work.
+var Weekdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+// Weekends are Saturday and Sunday.
+var Weekends = []time.Weekday{time.Saturday, time.Sunday}
+
+// occursOn reports whether the routine takes place on the day of t.
+func (r Routine) occursOn(t time.Time) bool {
+	return len(r.Days) == 0 || slices.Contains(r.Days, t.Weekday())
+}
+
+// action returns the routine as an action on the day of t.
+func (r Routine) action(t time.Time) Action {
+	y, m, d := t.Date()
+	return Action{
+		Description: r.Description,
+		Location:    r.Location,
+		StartTime:   time.Date(y, m, d, 0, 0, 0, 0, t.Location()).Add(r.Start),
+		Duration:    r.Duration,
+	}
+}
+
+// forDay returns the constraints with the routines occurring on the day of t
+// added to the fixed appointments.
+func (c Constraints) forDay(t time.Time) Constraints {
+	c.Fixed = slices.Clone(c.Fixed)
+	for _, r := range c.Routines {
+		if r.occursOn(t) {
+			c.Fixed = append(c.Fixed, r.action(t))
+		}
+	}
+	return c
+}
@@ -0,0 +1,73 @@
+package plan
+
+import "fmt"
+
+// Status is the progress of an action through the day.
+type Status int
+
+const (
+	// Pending actions have yet to start.
+	Pending Status = iota
+	// InProgress actions have started but not finished.
+	InProgress
+	// Completed actions were carried out.
+	Completed
+	// Skipped actions were passed over without being carried out.
+	Skipped
+)
+
+// String returns the status in lower case, e.g. "in progress".
+func (s Status) String() string {
+	switch s {
+	case Pending:
+		return "pending"
+	case InProgress:
+		return "in progress"
+	case Completed:
+		return "completed"
+	case Skipped:
+		return "skipped"
+	}
+	return fmt.Sprintf("status(%d)", int(s))
+}
+
+// Done reports whether the action is finished, whether completed or skipped.
+func (a Action) Done() bool {
+	return a.Status == Completed || a.Status == Skipped
+}
+
+// Current returns the action in progress, or nil if there is none.
+func (p *Plan) Current() *Action {
+	for i := range p.actions {
+		if p.actions[i].Status == InProgress {
+			return &p.actions[i]
+		}
+	}
+	return nil
+}
+
+// Start marks the action as in progress.
+func (p *Plan) Start(id string) error {
+	return p.setStatus(id, InProgress)
+}
+
+// Complete marks the action as completed.
+func (p *Plan) Complete(id string) error {
+	return p.setStatus(id, Completed)
+}
+
+// Skip marks the action as skipped.
+func (p *Plan) Skip(id string) error {
+	return p.setStatus(id, Skipped)
+}
+
+// setStatus sets the status of the action with the given ID.
+func (p *Plan) setStatus(id string, s Status) error {
+	for i := range p.actions {
+		if p.actions[i].ID == id {
+			p.actions[i].Status = s
+			return nil
+		}
+	}
+	return fmt.Errorf("action id not found")
+}
@@ -0,0 +1,78 @@
+package plan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// planSchema describes the JSON object the model is asked to produce.
+const planSchema = `{"actions": [{"start": "8:00 AM", "end": "9:00 AM", "location": "where the action takes place", "description": "what the agent does", "goal": "the ID of the goal the action works towards, or empty"}]}`
+
+// structuredPlan is a plan in the JSON form requested from the model.
+type structuredPlan struct {
+	Actions []structuredAction `json:"actions"`
+}
+
+// structuredAction is an action in the JSON form requested from the model.
+type structuredAction struct {
+	Start       string `json:"start"`
+	End         string `json:"end"`
+	Location    string `json:"location"`
+	Description string `json:"description"`
+	Goal        string `json:"goal"`
+}
+
+// toStructured is the inverse of parseStructuredPlan.
+func toStructured(actions []Action) structuredPlan {
+	sp := structuredPlan{Actions: make([]structuredAction, len(actions))}
+	for i, a := range actions {
+		sp.Actions[i] = structuredAction{
+			Start:       a.StartTime.Format("3:04 PM"),
+			End:         a.end().Format("3:04 PM"),
+			Location:    a.Location,
+			Description: a.Description,
+			Goal:        a.Goal,
+		}
+	}
+	return sp
+}
+
+// parseStructuredPlan converts a plan in JSON form into actions, skipping any
+// whose times cannot be read.
+func parseStructuredPlan(content string) ([]Action, error) {
+	var sp structuredPlan
+	if err := json.Unmarshal([]byte(content), &sp); err != nil {
+		return nil, fmt.Errorf("failed to parse plan: %w", err)
+	}
+	var actions []Action
+	for _, a := range sp.Actions {
+		start, err := parseClock(a.Start)
+		if err != nil {
+			continue
+		}
+		end, err := parseClock(a.End)
+		if err != nil {
+			continue
+		}
+		duration, ok := span(start, end)
+		if !ok {
+			continue
+		}
+		actions = append(actions, Action{
+			ID:          uuid.NewString(),
+			Description: strings.TrimSpace(a.Description),
+			Location:    strings.TrimSpace(a.Location),
+			Goal:        strings.TrimSpace(a.Goal),
+			StartTime:   start,
+			Duration:    duration,
+		})
+	}
+	if len(actions) == 0 {
+		return nil, errors.New("no actions found in plan")
+	}
+	return actions, nil
+}
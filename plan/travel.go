@@ -0,0 +1,110 @@
+package plan
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DistanceModel estimates how long it takes to travel between two locations.
+type DistanceModel interface {
+	TravelTime(from, to string) time.Duration
+}
+
+// TravelTimes is a DistanceModel that looks travel times up in a table of
+// location pairs, which hold in both directions. Pairs may name full location
+// paths or top-level locations; places sharing a top-level location default
+// to Within, and any others to Default.
+type TravelTimes struct {
+	Times   map[[2]string]time.Duration
+	Within  time.Duration
+	Default time.Duration
+}
+
+// TravelTime returns the travel time between the locations.
+func (t TravelTimes) TravelTime(from, to string) time.Duration {
+	if d, ok := t.lookup(from, to); ok {
+		return d
+	}
+	fromTop, toTop := topLocation(from), topLocation(to)
+	if fromTop == toTop {
+		return t.Within
+	}
+	if d, ok := t.lookup(fromTop, toTop); ok {
+		return d
+	}
+	return t.Default
+}
+
+// lookup finds the travel time between the locations in either direction.
+func (t TravelTimes) lookup(from, to string) (time.Duration, bool) {
+	if d, ok := t.Times[[2]string{from, to}]; ok {
+		return d, true
+	}
+	d, ok := t.Times[[2]string{to, from}]
+	return d, ok
+}
+
+// topLocation returns the first name in a location path.
+func topLocation(path string) string {
+	top, _, _ := strings.Cut(path, locationSeparator)
+	return top
+}
+
+// insertTravel adds a travel action between consecutive actions at different
+// locations, so the agent arrives as the next action starts. The action before
+// is cut short to make time; if that would leave nothing of it, or it is one
+// of the fixed appointments, the agent leaves when it ends and the next action
+// starts late instead, or is dropped if nothing of it would be left. Fixed
+// appointments never move, so if neither action can give way the travel fills
+// whatever gap there is between them. Actions without a location are left
+// alone.
+func insertTravel(actions []Action, m DistanceModel, fixed []Action) []Action {
+	if m == nil || len(actions) < 2 {
+		return actions
+	}
+	out := []Action{actions[0]}
+	for _, next := range actions[1:] {
+		prev := &out[len(out)-1]
+		if prev.Location != "" && next.Location != "" && prev.Location != next.Location {
+			if d := m.TravelTime(prev.Location, next.Location); d > 0 {
+				depart := next.StartTime.Add(-d)
+				if end := prev.end(); depart.Before(end) {
+					switch {
+					case depart.After(prev.StartTime) && !isFixed(*prev, fixed):
+						prev.Duration = depart.Sub(prev.StartTime)
+					case !isFixed(next, fixed):
+						depart = end
+						arrive := end.Add(d)
+						if !next.end().After(arrive) {
+							continue
+						}
+						next.Duration = next.end().Sub(arrive)
+						next.StartTime = arrive
+					default:
+						depart, d = end, next.StartTime.Sub(end)
+					}
+				}
+				if d > 0 {
+					out = append(out, Action{
+						ID:          uuid.NewString(),
+						Description: "Travel from " + prev.Location + " to " + next.Location,
+						StartTime:   depart,
+						Duration:    d,
+					})
+				}
+			}
+		}
+		out = append(out, next)
+	}
+	return out
+}
+
+// isFixed reports whether the action is one of the fixed appointments.
+func isFixed(a Action, fixed []Action) bool {
+	return slices.ContainsFunc(fixed, func(f Action) bool {
+		return f.Description == a.Description && f.StartTime.Equal(a.StartTime)
+	})
+}
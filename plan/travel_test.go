@@ -0,0 +1,126 @@
+package plan
+
+import (
+	"testing"
+	"time"
+)
+
+func at(hour, minute int) time.Time {
+	return time.Date(2024, 2, 14, hour, minute, 0, 0, time.UTC)
+}
+
+func TestInsertTravel(t *testing.T) {
+	times := TravelTimes{Default: 30 * time.Minute}
+	breakfast := Action{Description: "Breakfast", Location: "home", StartTime: at(8, 0), Duration: time.Hour}
+	coffee := Action{Description: "Coffee", Location: "cafe", StartTime: at(9, 0), Duration: time.Hour}
+	dentist := Action{Description: "Dentist", Location: "cafe", StartTime: at(9, 0), Duration: time.Hour}
+	short := Action{Description: "Breakfast", Location: "home", StartTime: at(8, 45), Duration: 15 * time.Minute}
+
+	tests := []struct {
+		name    string
+		actions []Action
+		fixed   []Action
+		want    []Action // Only descriptions, start times and durations are compared.
+	}{
+		{
+			name:    "trims the action before",
+			actions: []Action{breakfast, coffee},
+			want: []Action{
+				{Description: "Breakfast", StartTime: at(8, 0), Duration: 30 * time.Minute},
+				{Description: "Travel from home to cafe", StartTime: at(8, 30), Duration: 30 * time.Minute},
+				{Description: "Coffee", StartTime: at(9, 0), Duration: time.Hour},
+			},
+		},
+		{
+			name:    "delays the next action when the one before is too short",
+			actions: []Action{short, coffee},
+			want: []Action{
+				{Description: "Breakfast", StartTime: at(8, 45), Duration: 15 * time.Minute},
+				{Description: "Travel from home to cafe", StartTime: at(9, 0), Duration: 30 * time.Minute},
+				{Description: "Coffee", StartTime: at(9, 30), Duration: 30 * time.Minute},
+			},
+		},
+		{
+			name:    "delays the next action rather than trim a fixed one",
+			actions: []Action{breakfast, coffee},
+			fixed:   []Action{breakfast},
+			want: []Action{
+				{Description: "Breakfast", StartTime: at(8, 0), Duration: time.Hour},
+				{Description: "Travel from home to cafe", StartTime: at(9, 0), Duration: 30 * time.Minute},
+				{Description: "Coffee", StartTime: at(9, 30), Duration: 30 * time.Minute},
+			},
+		},
+		{
+			name:    "never moves fixed appointments",
+			actions: []Action{breakfast, dentist},
+			fixed:   []Action{breakfast, dentist},
+			want: []Action{
+				{Description: "Breakfast", StartTime: at(8, 0), Duration: time.Hour},
+				{Description: "Dentist", StartTime: at(9, 0), Duration: time.Hour},
+			},
+		},
+		{
+			name: "drops the next action when travel leaves nothing of it",
+			actions: []Action{
+				short,
+				{Description: "Coffee", Location: "cafe", StartTime: at(9, 0), Duration: 20 * time.Minute},
+				{Description: "Study", Location: "library", StartTime: at(10, 0), Duration: time.Hour},
+			},
+			want: []Action{
+				{Description: "Breakfast", StartTime: at(8, 45), Duration: 15 * time.Minute},
+				{Description: "Travel from home to library", StartTime: at(9, 30), Duration: 30 * time.Minute},
+				{Description: "Study", StartTime: at(10, 0), Duration: time.Hour},
+			},
+		},
+		{
+			name:    "leaves actions at the same place alone",
+			actions: []Action{breakfast, {Description: "Read", Location: "home", StartTime: at(9, 0), Duration: time.Hour}},
+			want: []Action{
+				{Description: "Breakfast", StartTime: at(8, 0), Duration: time.Hour},
+				{Description: "Read", StartTime: at(9, 0), Duration: time.Hour},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := insertTravel(tt.actions, times, tt.fixed)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d actions, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, w := range tt.want {
+				g := got[i]
+				if g.Description != w.Description || !g.StartTime.Equal(w.StartTime) || g.Duration != w.Duration {
+					t.Errorf("action %d = %q at %s for %s, want %q at %s for %s", i, g.Description, g.StartTime.Format("15:04"), g.Duration, w.Description, w.StartTime.Format("15:04"), w.Duration)
+				}
+			}
+		})
+	}
+}
+
+func TestInsertTravelProgresses(t *testing.T) {
+	actions := insertTravel([]Action{
+		{ID: "home", Description: "Breakfast", Location: "home", StartTime: at(8, 0), Duration: time.Hour},
+		{ID: "cafe", Description: "Coffee", Location: "cafe", StartTime: at(9, 0), Duration: time.Hour},
+		{ID: "library", Description: "Study", Location: "library", StartTime: at(10, 0), Duration: time.Hour},
+	}, TravelTimes{Default: 15 * time.Minute}, nil)
+	var p Plan
+	if err := p.SetActions(actions); err != nil {
+		t.Fatal(err)
+	}
+	var done []string
+	for next := p.NextAction(); next != nil; next = p.NextAction() {
+		if len(done) > len(actions) {
+			t.Fatalf("plan did not progress: %v", done)
+		}
+		if err := p.Start(next.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Complete(next.ID); err != nil {
+			t.Fatal(err)
+		}
+		done = append(done, next.Description)
+	}
+	if len(done) != len(actions) {
+		t.Errorf("completed %v, want %d actions", done, len(actions))
+	}
+}
@@ -0,0 +1,114 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ValidationMode decides what SetActions and AddAction do with a plan whose
+// actions overlap or leave long gaps.
+type ValidationMode int
+
+const (
+	// Ignore accepts plans as they are.
+	Ignore ValidationMode = iota
+	// Reject refuses plans with overlaps or gaps longer than MaxGap, returning
+	// a *ValidationError and leaving the plan unchanged.
+	Reject
+	// Shift resolves overlaps by delaying later actions until earlier ones end.
+	Shift
+	// Trim resolves overlaps by cutting earlier actions short when later ones
+	// start, shifting instead where an action would be cut to nothing.
+	Trim
+)
+
+// Validation configures how a plan checks its actions.
+type Validation struct {
+	Mode ValidationMode
+	// MaxGap, if positive, is the longest unplanned time between consecutive
+	// actions that Reject allows.
+	MaxGap time.Duration
+}
+
+// Overlap is a pair of actions whose time blocks overlap.
+type Overlap struct {
+	First, Second Action
+}
+
+// Gap is an unplanned period between consecutive actions.
+type Gap struct {
+	After, Before Action
+}
+
+// Duration returns the length of the gap.
+func (g Gap) Duration() time.Duration {
+	return g.Before.StartTime.Sub(g.After.StartTime.Add(g.After.Duration))
+}
+
+// ValidationError lists the problems found in a plan.
+type ValidationError struct {
+	Overlaps []Overlap
+	Gaps     []Gap
+}
+
+func (e *ValidationError) Error() string {
+	var problems []string
+	for _, o := range e.Overlaps {
+		problems = append(problems, fmt.Sprintf("%q overlaps %q", o.First.Description, o.Second.Description))
+	}
+	for _, g := range e.Gaps {
+		problems = append(problems, fmt.Sprintf("%s gap after %q", g.Duration(), g.After.Description))
+	}
+	return "invalid plan: " + strings.Join(problems, "; ")
+}
+
+// Validate checks chronologically ordered actions for overlapping time blocks
+// and, if maxGap is positive, gaps longer than it, returning a
+// *ValidationError if it finds any. Each action is checked against every
+// earlier one still under way, and gaps are measured from the latest end.
+func Validate(actions []Action, maxGap time.Duration) error {
+	var e ValidationError
+	latest := 0 // The earlier action ending last.
+	for i := 1; i < len(actions); i++ {
+		next := actions[i]
+		for _, prev := range actions[:i] {
+			if next.StartTime.Before(prev.end()) {
+				e.Overlaps = append(e.Overlaps, Overlap{First: prev, Second: next})
+			}
+		}
+		if end := actions[latest].end(); maxGap > 0 && next.StartTime.Sub(end) > maxGap {
+			e.Gaps = append(e.Gaps, Gap{After: actions[latest], Before: next})
+		}
+		if next.end().After(actions[latest].end()) {
+			latest = i
+		}
+	}
+	if len(e.Overlaps) == 0 && len(e.Gaps) == 0 {
+		return nil
+	}
+	return &e
+}
+
+// validate applies the plan's Validation to chronologically ordered actions,
+// resolving overlaps in place or returning an error as its mode requires.
+func (p *Plan) validate(actions []Action) error {
+	switch p.Validation.Mode {
+	case Reject:
+		return Validate(actions, p.Validation.MaxGap)
+	case Shift, Trim:
+		for i := 1; i < len(actions); i++ {
+			prev := &actions[i-1]
+			end := prev.StartTime.Add(prev.Duration)
+			if !actions[i].StartTime.Before(end) {
+				continue
+			}
+			if p.Validation.Mode == Trim && actions[i].StartTime.After(prev.StartTime) {
+				prev.Duration = actions[i].StartTime.Sub(prev.StartTime)
+			} else {
+				actions[i].StartTime = end
+			}
+		}
+	}
+	return nil
+}
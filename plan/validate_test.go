@@ -0,0 +1,58 @@
+package plan
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	a := Action{Description: "A", StartTime: at(9, 0), Duration: 3 * time.Hour}
+	b := Action{Description: "B", StartTime: at(10, 0), Duration: 30 * time.Minute}
+	c := Action{Description: "C", StartTime: at(11, 0), Duration: 30 * time.Minute}
+	d := Action{Description: "D", StartTime: at(14, 0), Duration: time.Hour}
+
+	tests := []struct {
+		name     string
+		actions  []Action
+		maxGap   time.Duration
+		overlaps [][2]string
+		gaps     []string // Descriptions of the actions gaps follow.
+	}{
+		{name: "empty"},
+		{name: "back to back", actions: []Action{b, {Description: "E", StartTime: at(10, 30), Duration: time.Hour}}, maxGap: time.Minute},
+		{name: "adjacent overlap", actions: []Action{a, d, {Description: "E", StartTime: at(14, 30), Duration: time.Hour}}, overlaps: [][2]string{{"D", "E"}}},
+		{name: "overlap with an earlier action", actions: []Action{a, b, c}, overlaps: [][2]string{{"A", "B"}, {"A", "C"}}},
+		{name: "no gap inside a long action", actions: []Action{a, b, c}, maxGap: 15 * time.Minute, overlaps: [][2]string{{"A", "B"}, {"A", "C"}}},
+		{name: "gap from the latest end", actions: []Action{a, b, d}, maxGap: time.Hour, overlaps: [][2]string{{"A", "B"}}, gaps: []string{"A"}},
+		{name: "gap allowed", actions: []Action{a, d}, maxGap: 2 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.actions, tt.maxGap)
+			var got ValidationError
+			var ve *ValidationError
+			if errors.As(err, &ve) {
+				got = *ve
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got.Overlaps) != len(tt.overlaps) {
+				t.Fatalf("got overlaps %v, want %v", err, tt.overlaps)
+			}
+			for i, o := range got.Overlaps {
+				if o.First.Description != tt.overlaps[i][0] || o.Second.Description != tt.overlaps[i][1] {
+					t.Errorf("overlap %d = %q/%q, want %q/%q", i, o.First.Description, o.Second.Description, tt.overlaps[i][0], tt.overlaps[i][1])
+				}
+			}
+			if len(got.Gaps) != len(tt.gaps) {
+				t.Fatalf("got gaps %v, want after %v", err, tt.gaps)
+			}
+			for i, g := range got.Gaps {
+				if g.After.Description != tt.gaps[i] {
+					t.Errorf("gap %d after %q, want %q", i, g.After.Description, tt.gaps[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// defaultCompletionTokens is assumed for completions that don't set MaxTokens.
+const defaultCompletionTokens = 256
+
+type OpenAIClient interface {
+	CreateChatCompletion(context.Context, openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error)
+	CreateEmbeddings(context.Context, openai.EmbeddingRequestConverter) (*openai.EmbeddingResponse, error)
+}
+
+// Client is an OpenAIClient that waits on a shared Limiter before every call.
+// Give each agent its own Client over the same Limiter for fair scheduling.
+type Client struct {
+	Client  OpenAIClient
+	Limiter *Limiter
+	Agent   string
+}
+
+// Wrap returns a rate limited client for the given agent.
+func (l *Limiter) Wrap(client OpenAIClient, agent string) *Client {
+	return &Client{Client: client, Limiter: l, Agent: agent}
+}
+
+// CreateChatCompletion waits for capacity, then creates a chat completion.
+func (c *Client) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error) {
+	estimate := estimateChatTokens(req)
+	if err := c.Limiter.Wait(ctx, c.Agent, estimate); err != nil {
+		return nil, err
+	}
+	resp, err := c.Client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	c.Limiter.Adjust(resp.Usage.TotalTokens - estimate)
+	return resp, nil
+}
+
+// CreateEmbeddings waits for capacity, then creates embeddings.
+func (c *Client) CreateEmbeddings(ctx context.Context, conv openai.EmbeddingRequestConverter) (*openai.EmbeddingResponse, error) {
+	estimate := EstimateTokens(fmt.Sprint(conv.Convert().Input))
+	if err := c.Limiter.Wait(ctx, c.Agent, estimate); err != nil {
+		return nil, err
+	}
+	resp, err := c.Client.CreateEmbeddings(ctx, conv)
+	if err != nil {
+		return nil, err
+	}
+	c.Limiter.Adjust(resp.Usage.TotalTokens - estimate)
+	return resp, nil
+}
+
+// estimateChatTokens estimates the prompt and completion tokens of a request.
+func estimateChatTokens(req openai.ChatCompletionRequest) int {
+	var n int
+	for _, m := range req.Messages {
+		n += EstimateTokens(m.Content)
+	}
+	if req.MaxTokens > 0 {
+		return n + req.MaxTokens
+	}
+	return n + defaultCompletionTokens
+}
+
+// EstimateTokens approximates the token count of text at four characters per token.
+func EstimateTokens(text string) int {
+	return len(text)/4 + 1
+}
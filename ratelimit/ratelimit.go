@@ -0,0 +1,161 @@
+package ratelimit
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket limiting requests and tokens per minute across every
+// LLM call made through it. Waiting callers are served round-robin by agent, so
+// one busy agent cannot starve the others. A zero limit is unlimited.
+type Limiter struct {
+	RequestsPerMinute int
+	TokensPerMinute   int
+
+	mu       sync.Mutex
+	started  bool
+	requests float64
+	tokens   float64
+	last     time.Time
+	agents   []string
+	queues   map[string][]*waiter
+	cursor   int
+	timer    *time.Timer
+}
+
+// waiter is a caller queued for capacity.
+type waiter struct {
+	tokens float64
+	ready  chan struct{}
+}
+
+// Wait blocks until the limiter has capacity for one request using the given
+// number of tokens on behalf of the agent, or the context is cancelled.
+func (l *Limiter) Wait(ctx context.Context, agent string, tokens int) error {
+	w := &waiter{tokens: float64(tokens), ready: make(chan struct{})}
+	l.mu.Lock()
+	if l.queues == nil {
+		l.queues = make(map[string][]*waiter)
+	}
+	if _, ok := l.queues[agent]; !ok {
+		l.agents = append(l.agents, agent)
+	}
+	l.queues[agent] = append(l.queues[agent], w)
+	l.dispatch()
+	l.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		select {
+		case <-w.ready:
+			// Granted while cancelling; the capacity is already spent.
+		default:
+			l.queues[agent] = slices.DeleteFunc(l.queues[agent], func(x *waiter) bool { return x == w })
+			l.dispatch()
+		}
+		return ctx.Err()
+	}
+}
+
+// Adjust corrects the token bucket once the actual token usage of a call is
+// known. A positive delta spends extra tokens, a negative delta refunds them.
+func (l *Limiter) Adjust(delta int) {
+	if l.TokensPerMinute <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	l.tokens = min(l.tokens-float64(delta), float64(l.TokensPerMinute))
+	l.dispatch()
+}
+
+// refill tops up both buckets for the time elapsed since the last refill.
+func (l *Limiter) refill() {
+	now := time.Now()
+	if !l.started {
+		l.started = true
+		l.requests = float64(l.RequestsPerMinute)
+		l.tokens = float64(l.TokensPerMinute)
+		l.last = now
+		return
+	}
+	minutes := now.Sub(l.last).Minutes()
+	l.last = now
+	l.requests = min(l.requests+minutes*float64(l.RequestsPerMinute), float64(l.RequestsPerMinute))
+	l.tokens = min(l.tokens+minutes*float64(l.TokensPerMinute), float64(l.TokensPerMinute))
+}
+
+// dispatch grants capacity to queued waiters in round-robin order by agent. If
+// the next waiter cannot be served yet, a timer retries once enough capacity
+// has been refilled. It must be called with the lock held.
+func (l *Limiter) dispatch() {
+	l.refill()
+	for {
+		agent, w := l.next()
+		if w == nil {
+			return
+		}
+		need := w.tokens
+		if l.TokensPerMinute > 0 {
+			need = min(need, float64(l.TokensPerMinute))
+		}
+		wait := l.shortfall(need)
+		if wait > 0 {
+			l.schedule(wait)
+			return
+		}
+		if l.RequestsPerMinute > 0 {
+			l.requests--
+		}
+		if l.TokensPerMinute > 0 {
+			l.tokens -= need
+		}
+		l.queues[agent] = l.queues[agent][1:]
+		l.cursor++
+		close(w.ready)
+	}
+}
+
+// next returns the head waiter of the next agent in round-robin order.
+func (l *Limiter) next() (string, *waiter) {
+	for i := range l.agents {
+		agent := l.agents[(l.cursor+i)%len(l.agents)]
+		if q := l.queues[agent]; len(q) > 0 {
+			l.cursor = (l.cursor + i) % len(l.agents)
+			return agent, q[0]
+		}
+	}
+	return "", nil
+}
+
+// shortfall returns how long until the buckets can serve one request of the
+// given number of tokens.
+func (l *Limiter) shortfall(tokens float64) time.Duration {
+	var wait time.Duration
+	if l.RequestsPerMinute > 0 && l.requests < 1 {
+		wait = max(wait, time.Duration((1-l.requests)/float64(l.RequestsPerMinute)*float64(time.Minute)))
+	}
+	if l.TokensPerMinute > 0 && l.tokens < tokens {
+		wait = max(wait, time.Duration((tokens-l.tokens)/float64(l.TokensPerMinute)*float64(time.Minute)))
+	}
+	return wait
+}
+
+// schedule arranges for dispatch to run again after the given delay.
+func (l *Limiter) schedule(d time.Duration) {
+	if l.timer != nil {
+		l.timer.Stop()
+	}
+	l.timer = time.AfterFunc(d, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.dispatch()
+	})
+}
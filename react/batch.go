@@ -0,0 +1,113 @@
+package react
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Decision is the Reactor's verdict on a single observation.
+type Decision struct {
+	Observation string
+	React       bool
+	Reason      string
+}
+
+// ScheduleEdit is a new action the Reactor proposes adding to the plan.
+type ScheduleEdit struct {
+	Description string
+	Location    string
+	StartTime   time.Time
+	Duration    time.Duration
+}
+
+// batchResponse is the JSON the model returns for ToObservations.
+type batchResponse struct {
+	Decisions []struct {
+		Index   int     `json:"index"`
+		React   bool    `json:"react"`
+		Reason  string  `json:"reason"`
+		Urgency float64 `json:"urgency"`
+	} `json:"decisions"`
+	ScheduleEdits []struct {
+		Description     string `json:"description"`
+		Location        string `json:"location"`
+		Start           string `json:"start"`
+		DurationMinutes int    `json:"duration_minutes"`
+	} `json:"schedule_edits"`
+}
+
+// ToObservations decides how to react to all of a tick's observations in a single
+// call, returning a decision per observation and any edits to the schedule.
+func (r *Reactor) ToObservations(observations []string, contextSummary, planExcerpt string, currentTime time.Time) ([]Decision, []ScheduleEdit, error) {
+	return r.ToObservationsContext(context.Background(), observations, contextSummary, planExcerpt, currentTime)
+}
+
+// ToObservationsContext is ToObservations under the given context.
+func (r *Reactor) ToObservationsContext(ctx context.Context, observations []string, contextSummary, planExcerpt string, currentTime time.Time) ([]Decision, []ScheduleEdit, error) {
+	sysPrompt := `Based on the agent's context, plan and observations, decide for each observation whether the agent should react, and propose any changes to the schedule.
+Respond in JSON with the following format:
+{"decisions": [{"index": 1, "react": true, "reason": "brief explanation", "urgency": 0.5}], "schedule_edits": [{"description": "new action", "location": "where", "start": "15:04", "duration_minutes": 30}]}
+Include one decision per observation, rating in "urgency", from 0 to 1, how urgently it calls for a reaction. Use 24-hour times for "start". Leave "schedule_edits" empty if the plan needs no changes.` + r.Reactivity.prompt()
+
+	var obsTexts []string
+	for i, o := range observations {
+		obsTexts = append(obsTexts, fmt.Sprintf("%d. %s", i+1, o))
+	}
+	usrPrompt := fmt.Sprintf(`Agent Context:
+%s
+Current Time: %s
+Upcoming Plan:
+%s
+Observations:
+%s`, contextSummary, currentTime.Format("3:04 PM"), planExcerpt, strings.Join(obsTexts, "\n"))
+
+	resp, err := r.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: r.model(),
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: sysPrompt},
+			{Role: "user", Content: usrPrompt},
+		},
+		ResponseFormat: &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject},
+		Temperature:    r.temperature(),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var out batchResponse
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &out); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse reactions: %w", err)
+	}
+
+	decisions := make([]Decision, len(observations))
+	for i, o := range observations {
+		decisions[i].Observation = o
+	}
+	for _, d := range out.Decisions {
+		if d.Index < 1 || d.Index > len(observations) {
+			continue
+		}
+		decisions[d.Index-1].React = d.React && r.Reactivity.reacts(d.Urgency)
+		decisions[d.Index-1].Reason = strings.TrimSpace(d.Reason)
+	}
+
+	var edits []ScheduleEdit
+	for _, e := range out.ScheduleEdits {
+		start, err := time.Parse("15:04", strings.TrimSpace(e.Start))
+		if err != nil {
+			continue
+		}
+		edits = append(edits, ScheduleEdit{
+			Description: e.Description,
+			Location:    e.Location,
+			StartTime:   time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), start.Hour(), start.Minute(), 0, 0, currentTime.Location()),
+			Duration:    time.Duration(e.DurationMinutes) * time.Minute,
+		})
+	}
+	return decisions, edits, nil
+}
@@ -0,0 +1,177 @@
+package react
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Outcome is the kind of reaction an agent has to an observation.
+type Outcome int
+
+const (
+	// Ignore leaves the agent carrying on as before.
+	Ignore Outcome = iota
+	// AdjustPlan has the agent change the rest of their day.
+	AdjustPlan
+	// StartConversation has the agent talk to someone.
+	StartConversation
+	// UpdateEmotion changes how the agent feels without changing what they do.
+	UpdateEmotion
+)
+
+// outcomeNames are the names the model uses for each outcome.
+var outcomeNames = map[Outcome]string{
+	Ignore:            "ignore",
+	AdjustPlan:        "adjust_plan",
+	StartConversation: "start_conversation",
+	UpdateEmotion:     "update_emotion",
+}
+
+// String returns the outcome's name, e.g. "adjust_plan".
+func (o Outcome) String() string {
+	if name, ok := outcomeNames[o]; ok {
+		return name
+	}
+	return fmt.Sprintf("outcome(%d)", int(o))
+}
+
+// parseOutcome returns the outcome with the given name, written in snake
+// case, camel case or as separate words, e.g. "adjust_plan", "AdjustPlan" or
+// "adjust plan". It reports false if the name is unknown.
+func parseOutcome(name string) (Outcome, bool) {
+	var b strings.Builder
+	prev := ' '
+	for _, r := range strings.TrimSpace(name) {
+		switch {
+		case r == ' ' || r == '-':
+			r = '_'
+		case unicode.IsUpper(r) && unicode.IsLower(prev):
+			b.WriteRune('_')
+		}
+		b.WriteRune(unicode.ToLower(r))
+		prev = r
+	}
+	for o, n := range outcomeNames {
+		if n == b.String() {
+			return o, true
+		}
+	}
+	return Ignore, false
+}
+
+// Reaction is the Reactor's typed decision on an observation.
+type Reaction struct {
+	Outcome Outcome
+	Reason  string
+	With    string // Whom to talk to, for StartConversation.
+	Emotion string // How the agent now feels, for UpdateEmotion.
+	// Intensity is how strongly the agent feels the emotion, from 0 to 1.
+	Intensity float64
+	// Urgency is how urgently the observation called for a reaction, from 0 to 1.
+	Urgency float64
+	// Action is what the agent does next, for AdjustPlan, if the model
+	// proposed one.
+	Action *ProposedAction
+}
+
+// ProposedAction is an action the Reactor proposes the agent take now.
+type ProposedAction struct {
+	Description string
+	Location    string
+	Duration    time.Duration
+}
+
+// defaultActionDuration is how long a proposed action lasts when the model
+// gives no estimate.
+const defaultActionDuration = 30 * time.Minute
+
+// Classify decides how the agent reacts to the observation: ignoring it,
+// adjusting their plan, starting a conversation or only changing how they feel.
+func (r *Reactor) Classify(observation, contextSummary string, currentTime time.Time) (Reaction, error) {
+	return r.ClassifyContext(context.Background(), observation, contextSummary, currentTime)
+}
+
+// ClassifyContext is Classify under the given context.
+func (r *Reactor) ClassifyContext(ctx context.Context, observation, contextSummary string, currentTime time.Time) (Reaction, error) {
+	sysPrompt := `Based on the agent's context and observation, decide how the agent reacts. Choose one outcome:
+- "ignore": the agent carries on as before.
+- "adjust_plan": the agent changes what they do for the rest of the day; describe what they do now in "action", with where they do it and how many minutes it takes.
+- "start_conversation": the agent talks to someone; name them in "with".
+- "update_emotion": the agent's feelings change but not what they do; name the feeling in "emotion" and rate how strongly it is felt in "intensity", from 0 to 1.
+Respond in JSON with the following format:
+{"outcome": "ignore", "reason": "brief explanation", "with": "", "emotion": "", "intensity": 0, "urgency": 0.5, "action": {"description": "", "location": "", "duration_minutes": 0}}
+Rate in "urgency", from 0 to 1, how urgently the observation calls for a reaction.` + r.Reactivity.prompt()
+
+	usrPrompt := fmt.Sprintf(`Agent Context:
+%s
+Current Time: %s
+Observation:
+%s`, contextSummary, currentTime.Format("3:04 PM"), observation)
+
+	resp, err := r.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: r.model(),
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: sysPrompt},
+			{Role: "user", Content: usrPrompt},
+		},
+		ResponseFormat: &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject},
+		Temperature:    r.temperature(),
+	})
+	if err != nil {
+		return Reaction{}, err
+	}
+
+	var out struct {
+		Outcome   string  `json:"outcome"`
+		Reason    string  `json:"reason"`
+		With      string  `json:"with"`
+		Emotion   string  `json:"emotion"`
+		Intensity float64 `json:"intensity"`
+		Urgency   float64 `json:"urgency"`
+		Action    struct {
+			Description string  `json:"description"`
+			Location    string  `json:"location"`
+			Minutes     float64 `json:"duration_minutes"`
+		} `json:"action"`
+	}
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &out); err != nil {
+		return Reaction{}, fmt.Errorf("failed to parse reaction: %w", err)
+	}
+	outcome, ok := parseOutcome(out.Outcome)
+	if !ok {
+		// An outcome the model made up still called for some reaction.
+		outcome = AdjustPlan
+	}
+	reaction := Reaction{
+		Outcome:   outcome,
+		Reason:    strings.TrimSpace(out.Reason),
+		With:      strings.TrimSpace(out.With),
+		Emotion:   strings.TrimSpace(out.Emotion),
+		Intensity: out.Intensity,
+		Urgency:   out.Urgency,
+	}
+	if !r.Reactivity.reacts(out.Urgency) {
+		reaction.Outcome = Ignore
+	}
+	if d := strings.TrimSpace(out.Action.Description); d != "" {
+		reaction.Action = &ProposedAction{
+			Description: d,
+			Location:    strings.TrimSpace(out.Action.Location),
+			Duration:    time.Duration(out.Action.Minutes * float64(time.Minute)),
+		}
+		if reaction.Action.Duration <= 0 {
+			reaction.Action.Duration = defaultActionDuration
+		}
+	}
+	// An outcome missing what it needs falls back to adjusting the plan.
+	if (reaction.Outcome == StartConversation && reaction.With == "") || (reaction.Outcome == UpdateEmotion && reaction.Emotion == "") {
+		reaction.Outcome = AdjustPlan
+	}
+	return reaction, nil
+}
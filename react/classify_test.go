@@ -0,0 +1,25 @@
+package react
+
+import "testing"
+
+func TestParseOutcome(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   Outcome
+		wantOK bool
+	}{
+		{"ignore", Ignore, true},
+		{"adjust_plan", AdjustPlan, true},
+		{"AdjustPlan", AdjustPlan, true},
+		{"adjust plan", AdjustPlan, true},
+		{" Start-Conversation ", StartConversation, true},
+		{"UPDATE_EMOTION", UpdateEmotion, true},
+		{"run_away", Ignore, false},
+		{"", Ignore, false},
+	}
+	for _, tt := range tests {
+		if got, ok := parseOutcome(tt.name); got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseOutcome(%q) = %v, %t; want %v, %t", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -2,10 +2,12 @@ package react
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/lordtatty/a25/llm"
 	openai "github.com/sashabaranov/go-openai"
 )
 
@@ -15,40 +17,65 @@ type OpenAIClient interface {
 
 // React encapsulates the perceive and reaction capabilities of an agent.
 type Reactor struct {
-	Client OpenAIClient
+	Client      OpenAIClient
+	Model       string   // Chat model; defaults to GPT-4o mini.
+	Temperature *float32 // Sampling temperature, set with llm.Temp; defaults to 1.
+	// Reactivity sets how readily the agent reacts, e.g. Stoic or Impulsive,
+	// so the same observation moves different personas differently.
+	Reactivity Reactivity
 }
 
-// DecideReaction determines if the agent should react to the observation.
+// model returns the chat model, defaulting to GPT-4o mini.
+func (r *Reactor) model() string {
+	return llm.Model(r.Model)
+}
+
+// temperature returns the sampling temperature, defaulting to 1.
+func (r *Reactor) temperature() float32 {
+	return llm.Temperature(r.Temperature)
+}
+
+// ToObservation decides whether the agent should react to the observation,
+// returning the reason if so.
 func (r *Reactor) ToObservation(observation, contextSummary string, currentTime time.Time) (bool, string, error) {
-	sysPrompt := `Based on the agent's context and observation, determine if the agent should react. 
-Respond with 'Yes' or 'No' and provide a brief explanation if 'Yes'.`
+	return r.ToObservationContext(context.Background(), observation, contextSummary, currentTime)
+}
+
+// ToObservationContext is ToObservation under the given context.
+func (r *Reactor) ToObservationContext(ctx context.Context, observation, contextSummary string, currentTime time.Time) (bool, string, error) {
+	sysPrompt := `Based on the agent's context and observation, determine if the agent should react.
+Respond in JSON with the following format:
+{"should_react": true, "reason": "brief explanation if the agent should react", "urgency": 0.5}
+Rate in "urgency", from 0 to 1, how urgently the observation calls for a reaction.` + r.Reactivity.prompt()
 
 	usrPrompt := fmt.Sprintf(`Agent Context:
 %s
 Observation:
 %s`, contextSummary, observation)
 
-	resp, err := r.Client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
-		Model: openai.GPT4oMini,
+	resp, err := r.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: r.model(),
 		Messages: []openai.ChatCompletionMessage{
 			{Role: "system", Content: sysPrompt},
 			{Role: "user", Content: usrPrompt},
 		},
-		Temperature: 1,
+		ResponseFormat: &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject},
+		Temperature:    r.temperature(),
 	})
 	if err != nil {
 		return false, "", err
 	}
 
-	response := resp.Choices[0].Message.Content
-	response = strings.TrimSpace(strings.ToLower(response))
-
-	if strings.HasPrefix(response, "yes") {
-		// Extract the reaction explanation.
-		reaction := strings.TrimPrefix(response, "yes")
-		reaction = strings.TrimSpace(reaction)
-		return true, reaction, nil
+	var out struct {
+		ShouldReact bool    `json:"should_react"`
+		Reason      string  `json:"reason"`
+		Urgency     float64 `json:"urgency"`
 	}
-
-	return false, "", nil
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &out); err != nil {
+		return false, "", fmt.Errorf("failed to parse reaction: %w", err)
+	}
+	if !out.ShouldReact || !r.Reactivity.reacts(out.Urgency) {
+		return false, "", nil
+	}
+	return true, strings.TrimSpace(out.Reason), nil
 }
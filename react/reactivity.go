@@ -0,0 +1,35 @@
+package react
+
+// Reactivity is how readily an agent reacts to what they observe, from 0 to
+// 1. It is described to the model, which rates how urgently each observation
+// calls for a reaction, and observations rated less urgent than 1 -
+// Reactivity are ignored. Zero leaves the decision to the model alone.
+type Reactivity float64
+
+// Reactivity presets.
+const (
+	Stoic     Reactivity = 0.2
+	Balanced  Reactivity = 0.5
+	Impulsive Reactivity = 0.8
+)
+
+// prompt describes the agent's temperament for the model, or is empty if
+// Reactivity is unset.
+func (r Reactivity) prompt() string {
+	switch {
+	case r <= 0:
+		return ""
+	case r < 0.35:
+		return "\nThe agent is stoic: they react only to things that matter a great deal to them."
+	case r < 0.65:
+		return "\nThe agent is even-tempered: they react to things that matter to them."
+	default:
+		return "\nThe agent is impulsive: they react readily, even to small things."
+	}
+}
+
+// reacts reports whether an observation of the given urgency, from 0 to 1,
+// is urgent enough for the agent to react to.
+func (r Reactivity) reacts(urgency float64) bool {
+	return r <= 0 || urgency >= 1-float64(r)
+}
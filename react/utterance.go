@@ -0,0 +1,68 @@
+package react
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Response is the Reactor's decision on an utterance addressed to the agent.
+type Response struct {
+	Respond bool   // Whether the agent says anything back.
+	Text    string // What the agent says, if they respond.
+	// End is whether the agent ends the conversation, which they always do
+	// if they don't respond.
+	End bool
+}
+
+// ToUtterance decides whether and how the agent responds to what the speaker
+// just said to them, given the conversation before it, and whether the agent
+// ends the conversation.
+func (r *Reactor) ToUtterance(speaker, utterance, transcript, contextSummary string, currentTime time.Time) (Response, error) {
+	return r.ToUtteranceContext(context.Background(), speaker, utterance, transcript, contextSummary, currentTime)
+}
+
+// ToUtteranceContext is ToUtterance under the given context.
+func (r *Reactor) ToUtteranceContext(ctx context.Context, speaker, utterance, transcript, contextSummary string, currentTime time.Time) (Response, error) {
+	sysPrompt := fmt.Sprintf(`Based on the agent's context and the conversation so far, decide whether the agent responds to what %s just said and, if so, what the agent says, in character, in one to three sentences.
+Respond in JSON with the following format:
+{"respond": true, "utterance": "what the agent says", "end": false}
+Set "respond" to false if the agent says nothing, such as when ignoring %s or walking away. Set "end" to true if the agent's response ends the conversation.`, speaker, speaker)
+
+	usrPrompt := fmt.Sprintf("Agent Context:\n%s\nCurrent Time: %s\n", contextSummary, currentTime.Format("3:04 PM"))
+	if transcript != "" {
+		usrPrompt += fmt.Sprintf("Conversation so far:\n%s\n", transcript)
+	}
+	usrPrompt += fmt.Sprintf("%s just said:\n%s", speaker, utterance)
+
+	resp, err := r.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: r.model(),
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: sysPrompt},
+			{Role: "user", Content: usrPrompt},
+		},
+		ResponseFormat: &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject},
+		Temperature:    r.temperature(),
+	})
+	if err != nil {
+		return Response{}, err
+	}
+
+	var out struct {
+		Respond   bool   `json:"respond"`
+		Utterance string `json:"utterance"`
+		End       bool   `json:"end"`
+	}
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &out); err != nil {
+		return Response{}, fmt.Errorf("failed to parse response: %w", err)
+	}
+	text := strings.TrimSpace(out.Utterance)
+	if !out.Respond || text == "" {
+		return Response{End: true}, nil
+	}
+	return Response{Respond: true, Text: text, End: out.End}, nil
+}
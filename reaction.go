@@ -0,0 +1,66 @@
+package a25
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lordtatty/a25/plan"
+	"github.com/lordtatty/a25/react"
+)
+
+// ReactionHandler carries out a reaction the agent has decided on.
+type ReactionHandler func(ctx context.Context, a *Agent, observation string, r react.Reaction, currentTime time.Time) error
+
+// defaultHandlers handle each outcome when the agent has no handler of its own.
+var defaultHandlers = map[react.Outcome]ReactionHandler{
+	react.AdjustPlan:        adjustPlan,
+	react.StartConversation: startConversation,
+	react.UpdateEmotion:     updateEmotion,
+}
+
+// handle dispatches the reaction to the agent's handler for its outcome, or
+// the default one. Ignored observations need no handling.
+func (a *Agent) handle(ctx context.Context, observation string, r react.Reaction, currentTime time.Time) error {
+	h, ok := a.ReactionHandlers[r.Outcome]
+	if !ok {
+		h = defaultHandlers[r.Outcome]
+	}
+	if h == nil {
+		return nil
+	}
+	return h(ctx, a, observation, r, currentTime)
+}
+
+// adjustPlan revises the rest of the agent's day around the reaction, starting
+// with the action the Reactor proposed, if any.
+func adjustPlan(ctx context.Context, a *Agent, _ string, r react.Reaction, currentTime time.Time) error {
+	var err error
+	if p := r.Action; p != nil {
+		err = a.UpdatePlanWithAction(ctx, r.Reason, plan.Action{
+			Description: p.Description,
+			Location:    p.Location,
+			Duration:    p.Duration,
+			Priority:    reactionPriority,
+		}, currentTime)
+	} else {
+		err = a.UpdatePlanContext(ctx, r.Reason, currentTime)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update plan: %w", err)
+	}
+	return nil
+}
+
+// startConversation opens a conversation with the other agent, which becomes
+// the agent's current one.
+func startConversation(ctx context.Context, a *Agent, _ string, r react.Reaction, _ time.Time) error {
+	_, err := a.StartConversation(ctx, r.With, r.Reason)
+	return err
+}
+
+// updateEmotion records how the agent now feels.
+func updateEmotion(ctx context.Context, a *Agent, _ string, r react.Reaction, _ time.Time) error {
+	a.Feel(r.Emotion, r.Intensity)
+	return a.Memory.AddMemoryContext(ctx, fmt.Sprintf("%s feels %s.", a.Name, r.Emotion))
+}
@@ -0,0 +1,72 @@
+package reflect
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lordtatty/a25/compress"
+	"github.com/lordtatty/a25/memory"
+)
+
+// maxHigherOrderInputs is the most reflections a higher-order reflection is
+// drawn from, taking the most recent.
+const maxHigherOrderInputs = 30
+
+// identityQuestion is asked of reflections to draw higher-level self-knowledge
+// from them.
+const identityQuestion = "What do these reflections reveal about who the agent is, what they value and where their life is heading?"
+
+// ReflectOnReflections synthesises higher-level self-knowledge from the
+// stream's reflections at the given depth, such as "Klaus is dedicating
+// himself to his research" from reflections on his reading and lectures. The
+// insights are stored one level deeper, citing the reflections they draw on.
+// Reflections already cited by a deeper one are not drawn on again, so each
+// pass only considers those added since, and nothing is done with fewer than
+// two to draw on. It returns the reflections added.
+func (r *Reflector) ReflectOnReflections(ctx context.Context, ms *memory.MemoryStream, depth int) ([]memory.MemoryObject, error) {
+	var since time.Time
+	if r.Window > 0 {
+		since = r.now().Add(-r.Window)
+	}
+	cited := make(map[string]bool)
+	for _, m := range ms.GetMemoriesBetween(time.Time{}, time.Time{}) {
+		if m.Kind == memory.Reflection && m.Depth == depth+1 {
+			for _, id := range m.Evidence {
+				cited[id] = true
+			}
+		}
+	}
+	var reflections []memory.RetrievedMemory
+	for _, m := range ms.GetMemoriesBetween(since, time.Time{}) {
+		if m.Kind == memory.Reflection && m.Depth == depth && !cited[m.ID] {
+			reflections = append(reflections, memory.RetrievedMemory{Memory: m})
+		}
+	}
+	if len(reflections) < 2 {
+		return nil, nil
+	}
+	reflections = reflections[max(0, len(reflections)-maxHigherOrderInputs):]
+
+	statements, err := compress.Fit(ctx, r.Compressor, formatStatements(reflections), identityQuestion, r.MaxSectionChars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress reflections: %w", err)
+	}
+	insights, err := r.generateInsights(ctx, identityQuestion, statements)
+	if err != nil {
+		return nil, err
+	}
+	var added []memory.MemoryObject
+	for _, insight := range insights {
+		m := memory.MemoryObject{Description: insight.Text, Kind: memory.Reflection, Question: identityQuestion, Depth: depth + 1}
+		for _, n := range insight.Citations {
+			if n >= 1 && n <= len(reflections) {
+				m.Evidence = append(m.Evidence, reflections[n-1].Memory.ID)
+			}
+		}
+		if added, err = store(ctx, ms, m, added); err != nil {
+			return added, err
+		}
+	}
+	return added, nil
+}
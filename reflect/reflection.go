@@ -2,9 +2,19 @@ package reflect
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/lordtatty/a25/clock"
+	"github.com/lordtatty/a25/compress"
+	"github.com/lordtatty/a25/llm"
 	"github.com/lordtatty/a25/memory"
 	openai "github.com/sashabaranov/go-openai"
 )
@@ -14,57 +24,187 @@ type OpenAIClient interface {
 }
 
 type Reflector struct {
-	Client OpenAIClient
+	Client      OpenAIClient
+	Model       string   // Chat model; defaults to GPT-4o mini.
+	Temperature *float32 // Sampling temperature, set with llm.Temp; defaults to 1.
+	// Compressor shrinks memory lists longer than MaxSectionChars before they
+	// are sent to the model. Compression is disabled when either is unset.
+	Compressor      compress.Compressor
+	MaxSectionChars int
+	// Window, if set, restricts reflection to memories created within this long
+	// before now, so the agent reflects only on the recent period.
+	Window time.Duration
+	// Clock supplies the current time for Window; defaults to the system clock.
+	Clock clock.Clock
+	// Depth, if above 1, has Reflect go on to reflect on its reflections, and on
+	// those, up to this depth, building the abstraction hierarchy of the
+	// generative agents paper.
+	Depth int
+	// Workers is the number of reflection questions answered concurrently;
+	// defaults to 3.
+	Workers int
 }
 
-// Reflect allows the agent to generate higher-level reflections.
-func (r *Reflector) Reflect(memories []memory.MemoryObject, ms *memory.MemoryStream) error {
+// model returns the chat model, defaulting to GPT-4o mini.
+func (r *Reflector) model() string {
+	return llm.Model(r.Model)
+}
+
+// now returns the current time on the reflector's clock.
+func (r *Reflector) now() time.Time {
+	if r.Clock != nil {
+		return r.Clock.Now()
+	}
+	return time.Now()
+}
+
+// temperature returns the sampling temperature, defaulting to 1.
+func (r *Reflector) temperature() float32 {
+	return llm.Temperature(r.Temperature)
+}
+
+// Reflect allows the agent to generate higher-level reflections. It returns
+// the reflections added to the stream, with their importance and the IDs of
+// the memories they cite as evidence.
+func (r *Reflector) Reflect(memories []memory.MemoryObject, ms *memory.MemoryStream) ([]memory.MemoryObject, error) {
+	return r.ReflectContext(context.Background(), memories, ms)
+}
+
+// ReflectContext is Reflect under the given context, which also bounds the
+// retrieval and storage of memories.
+func (r *Reflector) ReflectContext(ctx context.Context, memories []memory.MemoryObject, ms *memory.MemoryStream) ([]memory.MemoryObject, error) {
+	var since time.Time
+	if r.Window > 0 {
+		since = r.now().Add(-r.Window)
+	}
 	// Concatenate memory descriptions.
 	var memoryTexts []string
 	for _, mem := range memories {
+		if mem.CreationTime.Before(since) {
+			continue
+		}
 		memoryTexts = append(memoryTexts, mem.Description)
 	}
 
 	// Generate questions for reflection.
-	questions, err := generateReflectionQuestions(memoryTexts, r.Client)
+	recent, err := compress.Fit(ctx, r.Compressor, strings.Join(memoryTexts, "\n"), "", r.MaxSectionChars)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to compress memories: %w", err)
+	}
+	questions, err := r.generateReflectionQuestions(ctx, recent, "")
+	if err != nil {
+		return nil, err
+	}
+	added, err := r.answerAll(ctx, ms, questions, TimeRange{Start: since})
+	if err != nil {
+		return added, err
 	}
 
-	for _, question := range questions {
-		// Retrieve relevant memories for the question.
-		retrievedMemories, err := ms.RetrieveMemories(question)
+	for depth := 1; depth < r.Depth; depth++ {
+		higher, err := r.ReflectOnReflections(ctx, ms, depth)
+		added = append(added, higher...)
 		if err != nil {
-			return err
+			return added, err
 		}
+	}
+	return added, nil
+}
 
-		// Generate insights based on retrieved memories.
-		insights, err := generateInsights(question, retrievedMemories, r.Client)
-		if err != nil {
-			return err
-		}
+// defaultWorkers is the number of questions answered concurrently by default.
+const defaultWorkers = 3
+
+// answerAll answers the questions concurrently with up to Workers at a time,
+// returning the reflections added, in question order, and every error
+// encountered.
+func (r *Reflector) answerAll(ctx context.Context, ms *memory.MemoryStream, questions []string, window TimeRange) ([]memory.MemoryObject, error) {
+	workers := r.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	sem := make(chan struct{}, workers)
+	added := make([][]memory.MemoryObject, len(questions))
+	errs := make([]error, len(questions))
+	var wg sync.WaitGroup
+	for i, question := range questions {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			added[i], errs[i] = r.answer(ctx, ms, question, window)
+		}()
+	}
+	wg.Wait()
+	return slices.Concat(added...), errors.Join(errs...)
+}
 
-		for _, insight := range insights {
-			ms.AddMemory(insight) // Assign calculated importance.
+// answer draws insights on the question from the memories created in the
+// window and stores them as reflections, returning those added.
+func (r *Reflector) answer(ctx context.Context, ms *memory.MemoryStream, question string, window TimeRange) ([]memory.MemoryObject, error) {
+	// Retrieve relevant memories for the question.
+	retrievedMemories, err := ms.RetrieveMemoriesBetweenContext(ctx, question, window.Start, window.End)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate insights based on retrieved memories.
+	statements, err := compress.Fit(ctx, r.Compressor, formatStatements(retrievedMemories), question, r.MaxSectionChars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress statements: %w", err)
+	}
+	insights, err := r.generateInsights(ctx, question, statements)
+	if err != nil {
+		return nil, err
+	}
+
+	var added []memory.MemoryObject
+	for _, insight := range insights {
+		// An insight is one level of reflection above the deepest memory it cites.
+		m := memory.MemoryObject{Description: insight.Text, Kind: memory.Reflection, Question: question, Depth: 1}
+		for _, n := range insight.Citations {
+			if n >= 1 && n <= len(retrievedMemories) {
+				cited := retrievedMemories[n-1].Memory
+				m.Evidence = append(m.Evidence, cited.ID)
+				m.Depth = max(m.Depth, cited.Depth+1)
+			}
+		}
+		if added, err = store(ctx, ms, m, added); err != nil {
+			return added, err
 		}
 	}
+	return added, nil
+}
 
-	return nil
+// store adds the insight to the stream, appending it to added as stored, with
+// its ID and importance, unless it was merged into an existing memory.
+func store(ctx context.Context, ms *memory.MemoryStream, m memory.MemoryObject, added []memory.MemoryObject) ([]memory.MemoryObject, error) {
+	m.ID = uuid.NewString()
+	if err := ms.AddContext(ctx, m); err != nil {
+		return added, fmt.Errorf("failed to add insight: %w", err)
+	}
+	if stored, err := ms.GetMemory(m.ID); err == nil {
+		added = append(added, stored)
+	}
+	return added, nil
 }
 
-// generateReflectionQuestions generates questions for reflection.
-func generateReflectionQuestions(memories []string, client OpenAIClient) ([]string, error) {
+// generateReflectionQuestions generates questions for reflection, about the
+// topic if one is given.
+func (r *Reflector) generateReflectionQuestions(ctx context.Context, memories, topic string) ([]string, error) {
 	sysPrompt := "Given only the information provided below, what are 3 most salient high-level questions we can answer about the subjects in the statements?"
-	usrPrompt := strings.Join(memories, "\n")
+	if topic != "" {
+		sysPrompt = fmt.Sprintf("Given only the information provided below, what are 3 most salient high-level questions we can answer about %s from the statements?", topic)
+	}
+	usrPrompt := memories
 
 	// Call the language model.
-	resp, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
-		Model: openai.GPT4oMini,
+	resp, err := r.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: r.model(),
 		Messages: []openai.ChatCompletionMessage{
 			{Role: "system", Content: sysPrompt},
 			{Role: "user", Content: usrPrompt},
 		},
-		Temperature: 1,
+		Temperature: r.temperature(),
 	})
 	if err != nil {
 		return nil, err
@@ -93,25 +233,36 @@ func parseQuestions(output string) []string {
 	return questions
 }
 
-// generateInsights generates insights based on the question and retrieved memories.
-func generateInsights(question string, memories []memory.RetrievedMemory, client OpenAIClient) ([]string, error) {
-	// Prepare prompt.
+// formatStatements numbers retrieved memories for use as evidence in a prompt.
+func formatStatements(memories []memory.RetrievedMemory) string {
 	var memoryTexts []string
 	for idx, mem := range memories {
 		memoryTexts = append(memoryTexts, fmt.Sprintf("%d. %s", idx+1, mem.Memory.Description))
 	}
+	return strings.Join(memoryTexts, "\n")
+}
+
+// insight is a generated insight and the numbers of the statements it cites.
+type insight struct {
+	Text      string
+	Citations []int
+}
+
+// generateInsights generates insights based on the question and numbered statements.
+func (r *Reflector) generateInsights(ctx context.Context, question, statements string) ([]insight, error) {
+	// Prepare prompt.
 	sysPrompt := "What 5 high-level insights can you infer from the given statements? (example format: Insight (because of statements 1, 2, 3))"
 	usrPrompt := fmt.Sprintf(`Statements about the question "%s":
-%s`, question, strings.Join(memoryTexts, "\n"))
+%s`, question, statements)
 
 	// Call the language model.
-	resp, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
-		Model: openai.GPT4oMini,
+	resp, err := r.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: r.model(),
 		Messages: []openai.ChatCompletionMessage{
 			{Role: "system", Content: sysPrompt},
 			{Role: "user", Content: usrPrompt},
 		},
-		Temperature: 1,
+		Temperature: r.temperature(),
 	})
 	if err != nil {
 		return nil, err
@@ -122,9 +273,16 @@ func generateInsights(question string, memories []memory.RetrievedMemory, client
 	return insights, nil
 }
 
-// parseInsights extracts insights from the model's output.
-func parseInsights(output string) []string {
-	var insights []string
+// citationPattern matches the citation following an insight, e.g.
+// "(because of statements 1, 2, 3)".
+var (
+	citationPattern = regexp.MustCompile(`\(\s*because of[^)]*\)`)
+	digitsPattern   = regexp.MustCompile(`\d+`)
+)
+
+// parseInsights extracts insights and the statements they cite from the model's output.
+func parseInsights(output string) []insight {
+	var insights []insight
 	lines := strings.Split(output, "\n")
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
@@ -135,12 +293,19 @@ func parseInsights(output string) []string {
 		if len(line) > 2 && (line[1] == '.' || line[1] == ')') {
 			line = strings.TrimSpace(line[2:])
 		}
-		// Extract the insight before the '('.
+		// Extract the insight before the '(' and the statement numbers it cites.
+		var citations []int
+		if c := citationPattern.FindString(line); c != "" {
+			for _, n := range digitsPattern.FindAllString(c, -1) {
+				i, _ := strconv.Atoi(n)
+				citations = append(citations, i)
+			}
+		}
 		idx := strings.Index(line, "(")
 		if idx != -1 {
 			line = line[:idx]
 		}
-		insights = append(insights, strings.TrimSpace(line))
+		insights = append(insights, insight{Text: strings.TrimSpace(line), Citations: citations})
 	}
 	return insights
 }
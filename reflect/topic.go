@@ -0,0 +1,48 @@
+package reflect
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lordtatty/a25/compress"
+	"github.com/lordtatty/a25/memory"
+)
+
+// TimeRange is the period [Start, End). A zero Start or End leaves that side
+// of the range open.
+type TimeRange struct {
+	Start, End time.Time
+}
+
+// Past returns the range covering the duration up to now on the reflector's clock.
+func (r *Reflector) Past(d time.Duration) TimeRange {
+	return TimeRange{Start: r.now().Add(-d)}
+}
+
+// ReflectOn has the agent reflect on a topic using only the memories created
+// in the window, for focused reflections such as "your relationship with
+// Maria" over r.Past(7 * 24 * time.Hour). It returns the reflections added.
+func (r *Reflector) ReflectOn(topic string, window TimeRange, ms *memory.MemoryStream) ([]memory.MemoryObject, error) {
+	return r.ReflectOnContext(context.Background(), topic, window, ms)
+}
+
+// ReflectOnContext is ReflectOn under the given context.
+func (r *Reflector) ReflectOnContext(ctx context.Context, topic string, window TimeRange, ms *memory.MemoryStream) ([]memory.MemoryObject, error) {
+	retrieved, err := ms.RetrieveMemoriesBetweenContext(ctx, topic, window.Start, window.End)
+	if err != nil {
+		return nil, err
+	}
+	if len(retrieved) == 0 {
+		return nil, nil
+	}
+	relevant, err := compress.Fit(ctx, r.Compressor, formatStatements(retrieved), topic, r.MaxSectionChars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress memories: %w", err)
+	}
+	questions, err := r.generateReflectionQuestions(ctx, relevant, topic)
+	if err != nil {
+		return nil, err
+	}
+	return r.answerAll(ctx, ms, questions, window)
+}
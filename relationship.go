@@ -0,0 +1,88 @@
+package a25
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lordtatty/a25/memory"
+	"github.com/lordtatty/a25/relationship"
+)
+
+// maxRelationshipMemories is the most memories of another agent drawn on when
+// summarising the relationship with them, keeping the most recent.
+const maxRelationshipMemories = 50
+
+// SummarizeRelationship creates or updates the agent's standing summary of
+// their relationship with another agent from every memory mentioning them by
+// name or coming from them, for dialogue and for questions about them. An
+// existing summary is revised with the memories it has not yet drawn on, and
+// returned as it is if there are none. Without memories of the other agent
+// nothing is done.
+func (a *Agent) SummarizeRelationship(ctx context.Context, other string) (memory.MemoryObject, error) {
+	question := relationship.Question(a.Name, other)
+	previous, found := a.relationshipSummary(question)
+	mentioning := make(map[string]bool)
+	for _, m := range a.Memory.MentioningName(other) {
+		mentioning[m.ID] = true
+	}
+	// The summary's evidence is every memory it has drawn on, so only those
+	// formed since it was written, and not yet drawn on, are new.
+	drawn := make(map[string]bool, len(previous.Evidence))
+	for _, id := range previous.Evidence {
+		drawn[id] = true
+	}
+	var memories, ids []string
+	for _, m := range a.Memory.Memories() {
+		if m.Archived || drawn[m.ID] || (found && (m.ID == previous.ID || m.CreationTime.Before(previous.CreationTime))) {
+			continue
+		}
+		if mentioning[m.ID] || m.Source == other {
+			memories = append(memories, m.Description)
+			ids = append(ids, m.ID)
+		}
+	}
+	if len(memories) == 0 {
+		return previous, nil
+	}
+	memories = memories[max(len(memories)-maxRelationshipMemories, 0):]
+	ids = ids[max(len(ids)-maxRelationshipMemories, 0):]
+
+	summary, err := a.Modules.Relationships.Summarize(ctx, a.Name, other, previous.Description, memories)
+	if err != nil {
+		return previous, fmt.Errorf("failed to summarize relationship: %w", err)
+	}
+	if found {
+		previous.Description = summary
+		previous.Evidence = append(previous.Evidence, ids...)
+		if err := a.Memory.UpdateMemoryContext(ctx, previous); err != nil {
+			return previous, fmt.Errorf("failed to update relationship summary: %w", err)
+		}
+		return previous, nil
+	}
+	m := memory.MemoryObject{ID: uuid.NewString(), Description: summary, Kind: memory.Summary, Question: question, Evidence: ids}
+	if err := a.Memory.AddContext(ctx, m); err != nil {
+		return m, fmt.Errorf("failed to add relationship summary: %w", err)
+	}
+	if stored, err := a.Memory.GetMemory(m.ID); err == nil {
+		return stored, nil
+	}
+	return m, nil
+}
+
+// Relationship returns the agent's standing summary of their relationship with
+// another agent, or "" if there is none; see SummarizeRelationship.
+func (a *Agent) Relationship(other string) string {
+	m, _ := a.relationshipSummary(relationship.Question(a.Name, other))
+	return m.Description
+}
+
+// relationshipSummary finds the relationship summary answering the question.
+func (a *Agent) relationshipSummary(question string) (memory.MemoryObject, bool) {
+	for _, m := range a.Memory.Memories() {
+		if m.Kind == memory.Summary && m.Question == question && !m.Archived {
+			return m, true
+		}
+	}
+	return memory.MemoryObject{}, false
+}
@@ -0,0 +1,65 @@
+package relationship
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lordtatty/a25/llm"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+type OpenAIClient interface {
+	CreateChatCompletion(context.Context, openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error)
+}
+
+// Summarizer summarises an agent's relationship with another agent from their
+// memories involving them.
+type Summarizer struct {
+	Client      OpenAIClient
+	Model       string   // Chat model; defaults to GPT-4o mini.
+	Temperature *float32 // Sampling temperature, set with llm.Temp; defaults to 1.
+}
+
+// model returns the chat model, defaulting to GPT-4o mini.
+func (s *Summarizer) model() string {
+	return llm.Model(s.Model)
+}
+
+// temperature returns the sampling temperature, defaulting to 1.
+func (s *Summarizer) temperature() float32 {
+	return llm.Temperature(s.Temperature)
+}
+
+// Question is the question a relationship summary answers, as asked in the
+// generative agents paper's interviews.
+func Question(agent, other string) string {
+	return fmt.Sprintf("What is %s's relationship with %s?", agent, other)
+}
+
+// Summarize describes the agent's relationship with the other agent from the
+// agent's memories involving them. If there is a previous summary, it is
+// revised in light of the memories rather than written afresh.
+func (s *Summarizer) Summarize(ctx context.Context, agent, other, previous string, memories []string) (string, error) {
+	sysPrompt := fmt.Sprintf(`%s
+Answer in two or three sentences, written in the third person, covering who %s is to %s, how %s feels about them and what has happened between them recently.`, Question(agent, other), other, agent, agent)
+	var usrPrompt string
+	if previous != "" {
+		sysPrompt += " Keep what still holds of the previous summary and change what the memories have changed."
+		usrPrompt = fmt.Sprintf("Previous summary:\n%s\n", previous)
+	}
+	usrPrompt += fmt.Sprintf("What %s remembers involving %s:\n- %s", agent, other, strings.Join(memories, "\n- "))
+
+	resp, err := s.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: s.model(),
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: sysPrompt},
+			{Role: "user", Content: usrPrompt},
+		},
+		Temperature: s.temperature(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
@@ -0,0 +1,61 @@
+package a25
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lordtatty/a25/memory"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// EndOfDay closes the agent's day: it compares the day's plan against what was
+// done, has the model write a short retrospective, e.g. "Klaus finished his
+// reading but skipped the gym", and remembers it as a reflection. The
+// retrospective is included in the summary the next PlanDay plans from, and
+// returned.
+func (a *Agent) EndOfDay(currentTime time.Time) (string, error) {
+	return a.EndOfDayContext(context.Background(), currentTime)
+}
+
+// EndOfDayContext is EndOfDay under the given context.
+func (a *Agent) EndOfDayContext(ctx context.Context, currentTime time.Time) (string, error) {
+	currentTime = a.local(currentTime)
+	year, month, day := currentTime.Date()
+	var lines []string
+	for _, act := range a.CurrentPlan.Actions() {
+		if y, m, d := act.StartTime.In(currentTime.Location()).Date(); y != year || m != month || d != day {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("- %s: %s [%s]", act.StartTime.Format("3:04 PM"), act.Description, act.Status))
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+	sysPrompt := "Write a brief retrospective of the agent's day in two or three sentences, in the third person: what they finished, what they skipped or left unfinished, and what they should carry into tomorrow.  Include no other comment."
+	usrPrompt := fmt.Sprintf("Agent: %s\nDate: %s\nPlan and outcome:\n%s", a.Name, currentTime.Format("January 2, 2006"), strings.Join(lines, "\n"))
+
+	resp, err := a.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: a.model(),
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: sysPrompt},
+			{Role: "user", Content: usrPrompt},
+		},
+		Temperature: a.temperature(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to write retrospective: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", errors.New("failed to write retrospective: no response from model")
+	}
+	retrospective := strings.TrimSpace(resp.Choices[0].Message.Content)
+	err = a.Memory.AddContext(ctx, memory.MemoryObject{Description: retrospective, Kind: memory.Reflection, Source: a.Name, Depth: 1})
+	if err != nil {
+		return "", fmt.Errorf("failed to remember retrospective: %w", err)
+	}
+	a.retrospective = retrospective
+	return retrospective, nil
+}
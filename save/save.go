@@ -0,0 +1,97 @@
+package save
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Version is the current version of the save format. When a persisted struct
+// changes shape, bump Version and register a migration from the previous version.
+const Version = 2
+
+// Envelope wraps persisted state with the format version and kind of data it holds.
+type Envelope struct {
+	Version int             `json:"version"`
+	Kind    string          `json:"kind"`
+	SavedAt time.Time       `json:"saved_at"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Migration upgrades data of a single kind from one version to the next.
+type Migration func(data json.RawMessage) (json.RawMessage, error)
+
+var (
+	mu         sync.RWMutex
+	migrations = map[string]map[int]Migration{}
+)
+
+// Register adds a migration that upgrades data of the given kind from version
+// from to version from+1. Versions without a migration are carried forward as-is.
+func Register(kind string, from int, m Migration) {
+	mu.Lock()
+	defer mu.Unlock()
+	if migrations[kind] == nil {
+		migrations[kind] = map[int]Migration{}
+	}
+	migrations[kind][from] = m
+}
+
+// Write encodes v inside a versioned envelope of the given kind.
+func Write(w io.Writer, kind string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", kind, err)
+	}
+	return json.NewEncoder(w).Encode(Envelope{
+		Version: Version,
+		Kind:    kind,
+		SavedAt: time.Now(),
+		Data:    data,
+	})
+}
+
+// Read decodes an envelope of the given kind into v, migrating the data from
+// older versions of the save format first.
+func Read(r io.Reader, kind string, v any) error {
+	var env Envelope
+	if err := json.NewDecoder(r).Decode(&env); err != nil {
+		return fmt.Errorf("failed to decode envelope: %w", err)
+	}
+	data, err := Migrate(env, kind)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", kind, err)
+	}
+	return nil
+}
+
+// Migrate checks the envelope holds the given kind and returns its data
+// upgraded to the current Version.
+func Migrate(env Envelope, kind string) (json.RawMessage, error) {
+	if env.Kind != kind {
+		return nil, fmt.Errorf("expected %s save but found %s", kind, env.Kind)
+	}
+	if env.Version > Version {
+		return nil, fmt.Errorf("save version %d is newer than supported version %d", env.Version, Version)
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	data := env.Data
+	for v := env.Version; v < Version; v++ {
+		m, ok := migrations[kind][v]
+		if !ok {
+			continue
+		}
+		var err error
+		data, err = m(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate %s from version %d: %w", kind, v, err)
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,80 @@
+package save
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type note struct {
+	Text  string `json:"text"`
+	Words int    `json:"words"`
+}
+
+func TestWriteRead(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, "note", note{Text: "hello there", Words: 2}); err != nil {
+		t.Fatal(err)
+	}
+	var got note
+	if err := Read(&buf, "note", &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != (note{Text: "hello there", Words: 2}) {
+		t.Errorf("Read = %+v", got)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	// Version 1 notes had no word count.
+	Register("counted-note", 1, func(data json.RawMessage) (json.RawMessage, error) {
+		var n note
+		if err := json.Unmarshal(data, &n); err != nil {
+			return nil, err
+		}
+		n.Words = len(strings.Fields(n.Text))
+		return json.Marshal(n)
+	})
+	Register("failing-note", 1, func(json.RawMessage) (json.RawMessage, error) {
+		return nil, errors.New("corrupt")
+	})
+	data := json.RawMessage(`{"text":"one two three"}`)
+	tests := []struct {
+		name    string
+		env     Envelope
+		kind    string
+		want    string
+		wantErr bool
+	}{
+		{"current version", Envelope{Version: Version, Kind: "counted-note", Data: data}, "counted-note", `{"text":"one two three"}`, false},
+		{"migrated", Envelope{Version: 1, Kind: "counted-note", Data: data}, "counted-note", `{"text":"one two three","words":3}`, false},
+		{"no migration registered", Envelope{Version: 1, Kind: "plain-note", Data: data}, "plain-note", `{"text":"one two three"}`, false},
+		{"wrong kind", Envelope{Version: Version, Kind: "counted-note", Data: data}, "plain-note", "", true},
+		{"newer version", Envelope{Version: Version + 1, Kind: "counted-note", Data: data}, "counted-note", "", true},
+		{"failed migration", Envelope{Version: 1, Kind: "failing-note", Data: data}, "failing-note", "", true},
+	}
+	for _, tt := range tests {
+		got, err := Migrate(tt.env, tt.kind)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, want error %t", tt.name, err, tt.wantErr)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: Migrate = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadRejectsGarbage(t *testing.T) {
+	var n note
+	if err := Read(strings.NewReader("not json"), "note", &n); err == nil {
+		t.Error("Read accepted a corrupt envelope")
+	}
+	env, _ := json.Marshal(Envelope{Version: Version, Kind: "note", SavedAt: time.Now(), Data: json.RawMessage(`"text"`)})
+	if err := Read(bytes.NewReader(env), "note", &n); err == nil {
+		t.Error("Read accepted data of the wrong shape")
+	}
+}
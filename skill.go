@@ -0,0 +1,113 @@
+package a25
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lordtatty/a25/plan"
+)
+
+// Skill is a learned ability that affects how well and how quickly the agent
+// performs related actions. Skills improve with practice.
+type Skill struct {
+	Name     string
+	Level    float64  // Between 0 (novice) and 10 (master).
+	Keywords []string // Words identifying actions that use the skill.
+}
+
+// Rank describes the skill level in words.
+func (s Skill) Rank() string {
+	switch {
+	case s.Level >= 8:
+		return "expert"
+	case s.Level >= 5:
+		return "skilled"
+	case s.Level >= 2:
+		return "competent"
+	}
+	return "novice"
+}
+
+// DurationFactor returns how long a related action takes relative to an
+// average performer, from 1.5x for a novice down to 0.5x for a master.
+func (s Skill) DurationFactor() float64 {
+	return 1.5 - s.Level/10
+}
+
+// matches reports whether the action description uses the skill.
+func (s Skill) matches(description string) bool {
+	description = strings.ToLower(description)
+	if strings.Contains(description, strings.ToLower(s.Name)) {
+		return true
+	}
+	for _, k := range s.Keywords {
+		if strings.Contains(description, strings.ToLower(k)) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetSkill adds a skill to the agent or replaces an existing one of the same name.
+func (a *Agent) SetSkill(s Skill) {
+	for i := range a.Skills {
+		if a.Skills[i].Name == s.Name {
+			a.Skills[i] = s
+			return
+		}
+	}
+	a.Skills = append(a.Skills, s)
+}
+
+// skillFor returns the agent's highest level skill used by the action.
+func (a *Agent) skillFor(description string) *Skill {
+	var best *Skill
+	for i := range a.Skills {
+		s := &a.Skills[i]
+		if s.matches(description) && (best == nil || s.Level > best.Level) {
+			best = s
+		}
+	}
+	return best
+}
+
+// ApplySkills scales the duration of each action by the agent's related skill.
+func (a *Agent) ApplySkills(actions []plan.Action) {
+	for i := range actions {
+		if s := a.skillFor(actions[i].Description); s != nil {
+			d := time.Duration(float64(actions[i].Duration) * s.DurationFactor())
+			actions[i].Duration = d.Round(time.Minute)
+		}
+	}
+}
+
+// Practice improves the skill related to the action. Gains diminish as the
+// skill approaches mastery, and reaching a new rank is remembered.
+func (a *Agent) Practice(action plan.Action) error {
+	return a.PracticeContext(context.Background(), action)
+}
+
+// PracticeContext is Practice under the given context.
+func (a *Agent) PracticeContext(ctx context.Context, action plan.Action) error {
+	s := a.skillFor(action.Description)
+	if s == nil {
+		return nil
+	}
+	rank := s.Rank()
+	s.Level = min(s.Level+0.5*(10-s.Level)/10, 10)
+	if s.Rank() != rank {
+		return a.Memory.AddMemoryContext(ctx, fmt.Sprintf("%s has become %s at %s.", a.Name, s.Rank(), s.Name))
+	}
+	return nil
+}
+
+// skillSummary describes the agent's skills for use in prompts.
+func (a *Agent) skillSummary() string {
+	var parts []string
+	for _, s := range a.Skills {
+		parts = append(parts, fmt.Sprintf("%s (%s)", s.Name, s.Rank()))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,88 @@
+package a25
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+)
+
+// ObjectStore stores snapshots by key, such as a cloud.Bucket in S3 or GCS.
+type ObjectStore interface {
+	Put(key string, data []byte) error
+	Get(key string) ([]byte, error)
+}
+
+// Snapshotter saves agents to an ObjectStore under versioned keys of the form
+// prefix/agent/20060102T150405.000000000Z.json, so earlier states are kept for
+// disaster recovery or to share a simulation at a given moment.
+type Snapshotter struct {
+	Store    ObjectStore
+	Prefix   string
+	Interval time.Duration // Time between snapshots taken by Run.
+	// Lock, if set, is held while Run snapshots the agents. A simulation
+	// that holds it around each tick is only snapshotted between ticks.
+	Lock sync.Locker
+}
+
+// Snapshot saves the agent's state, including memories, plan and status, and
+// returns the key it was stored under. The agent must not change while it is
+// saved, so take snapshots between ticks.
+func (s *Snapshotter) Snapshot(a *Agent) (string, error) {
+	var buf bytes.Buffer
+	if err := a.Save(&buf); err != nil {
+		return "", err
+	}
+	key := path.Join(s.Prefix, a.Name, time.Now().UTC().Format("20060102T150405.000000000Z")+".json")
+	if err := s.Store.Put(key, buf.Bytes()); err != nil {
+		return "", fmt.Errorf("failed to store snapshot: %w", err)
+	}
+	return key, nil
+}
+
+// Run snapshots every agent each Interval until the context is done or a
+// snapshot fails. Agents are not safe to save while they run, so a simulation
+// running alongside must share Lock with Run.
+func (s *Snapshotter) Run(ctx context.Context, agents []*Agent) error {
+	if s.Interval <= 0 {
+		return errors.New("snapshot interval must be positive")
+	}
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := s.snapshotAll(agents); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// snapshotAll snapshots every agent, holding Lock if set.
+func (s *Snapshotter) snapshotAll(agents []*Agent) error {
+	if s.Lock != nil {
+		s.Lock.Lock()
+		defer s.Lock.Unlock()
+	}
+	for _, a := range agents {
+		if _, err := s.Snapshot(a); err != nil {
+			return fmt.Errorf("failed to snapshot %s: %w", a.Name, err)
+		}
+	}
+	return nil
+}
+
+// Restore loads the agent snapshot stored under key.
+func (s *Snapshotter) Restore(key string, client OpenAIClient) (*Agent, error) {
+	data, err := s.Store.Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch snapshot: %w", err)
+	}
+	return LoadAgent(bytes.NewReader(data), client)
+}
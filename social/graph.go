@@ -0,0 +1,237 @@
+package social
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of interaction between agents.
+const (
+	Talked   = "talked"
+	Observed = "observed"
+	Messaged = "messaged"
+	Invited  = "invited"
+)
+
+// Interaction is a single directed interaction from one agent to another.
+type Interaction struct {
+	From string
+	To   string
+	Kind string
+	Time time.Time
+}
+
+// Edge aggregates the interactions from one agent to another.
+type Edge struct {
+	From   string
+	To     string
+	Weight int
+	Kinds  map[string]int
+	First  time.Time
+	Last   time.Time
+}
+
+// Graph records who interacted with whom over time. It is safe for concurrent
+// use, so one graph can be shared by every agent in a simulation.
+type Graph struct {
+	mu           sync.Mutex
+	agents       []string
+	interactions []Interaction
+}
+
+// AddAgent registers an agent as a node of the graph, so it can be recognised
+// when mentioned in observations.
+func (g *Graph) AddAgent(name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !slices.Contains(g.agents, name) {
+		g.agents = append(g.agents, name)
+	}
+}
+
+// Record adds an interaction from one agent to another.
+func (g *Graph) Record(from, to, kind string, t time.Time) {
+	if from == to {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, n := range []string{from, to} {
+		if !slices.Contains(g.agents, n) {
+			g.agents = append(g.agents, n)
+		}
+	}
+	g.interactions = append(g.interactions, Interaction{From: from, To: to, Kind: kind, Time: t})
+}
+
+// Mentions returns the known agents named in the text.
+func (g *Graph) Mentions(text string) []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var names []string
+	for _, n := range g.agents {
+		if strings.Contains(text, n) {
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
+// Interactions returns every recorded interaction.
+func (g *Graph) Interactions() []Interaction {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return slices.Clone(g.interactions)
+}
+
+// Edges aggregates the interactions between start and end into weighted edges.
+// A zero start or end leaves that side of the window open.
+func (g *Graph) Edges(start, end time.Time) []Edge {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	byPair := make(map[[2]string]*Edge)
+	for _, in := range g.interactions {
+		if (!start.IsZero() && in.Time.Before(start)) || (!end.IsZero() && in.Time.After(end)) {
+			continue
+		}
+		key := [2]string{in.From, in.To}
+		e, ok := byPair[key]
+		if !ok {
+			e = &Edge{From: in.From, To: in.To, Kinds: make(map[string]int), First: in.Time, Last: in.Time}
+			byPair[key] = e
+		}
+		e.Weight++
+		e.Kinds[in.Kind]++
+		if in.Time.Before(e.First) {
+			e.First = in.Time
+		}
+		if in.Time.After(e.Last) {
+			e.Last = in.Time
+		}
+	}
+	edges := make([]Edge, 0, len(byPair))
+	for _, e := range byPair {
+		edges = append(edges, *e)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+	return edges
+}
+
+// nodes returns the registered agents in sorted order.
+func (g *Graph) nodes() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	nodes := slices.Clone(g.agents)
+	sort.Strings(nodes)
+	return nodes
+}
+
+// WriteDOT exports the interactions between start and end as a Graphviz DOT graph.
+func (g *Graph) WriteDOT(w io.Writer, start, end time.Time) error {
+	var b strings.Builder
+	b.WriteString("digraph interactions {\n")
+	for _, n := range g.nodes() {
+		fmt.Fprintf(&b, "  %q;\n", n)
+	}
+	for _, e := range g.Edges(start, end) {
+		fmt.Fprintf(&b, "  %q -> %q [weight=%d, label=%q];\n", e.From, e.To, e.Weight, kindsLabel(e.Kinds))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// kindsLabel summarises interaction kinds, e.g. "talked:3 invited:1".
+func kindsLabel(kinds map[string]int) string {
+	var parts []string
+	for k, n := range kinds {
+		parts = append(parts, fmt.Sprintf("%s:%d", k, n))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, " ")
+}
+
+// graphML is the document structure written by WriteGraphML.
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   struct {
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLNode `xml:"node"`
+		Edges       []graphMLEdge `xml:"edge"`
+	} `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLNode struct {
+	ID string `xml:"id,attr"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML exports the interactions between start and end as GraphML, with
+// each edge's weight, interaction kinds and first and last interaction times.
+func (g *Graph) WriteGraphML(w io.Writer, start, end time.Time) error {
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "weight", For: "edge", AttrName: "weight", AttrType: "int"},
+			{ID: "kinds", For: "edge", AttrName: "kinds", AttrType: "string"},
+			{ID: "first", For: "edge", AttrName: "first", AttrType: "string"},
+			{ID: "last", For: "edge", AttrName: "last", AttrType: "string"},
+		},
+	}
+	doc.Graph.EdgeDefault = "directed"
+	for _, n := range g.nodes() {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{ID: n})
+	}
+	for _, e := range g.Edges(start, end) {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: e.From,
+			Target: e.To,
+			Data: []graphMLData{
+				{Key: "weight", Value: fmt.Sprint(e.Weight)},
+				{Key: "kinds", Value: kindsLabel(e.Kinds)},
+				{Key: "first", Value: e.First.Format(time.RFC3339)},
+				{Key: "last", Value: e.Last.Format(time.RFC3339)},
+			},
+		})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,76 @@
+package a25
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TriggerFunc is a behaviour run by a trigger at the given time.
+type TriggerFunc func(now time.Time) error
+
+// trigger is a scheduled behaviour that recurs at a fixed interval, or daily
+// at a clock time.
+type trigger struct {
+	next  time.Time
+	every time.Duration // Interval for triggers set with Every.
+	at    time.Time     // Clock time of day for triggers set with At.
+	fn    TriggerFunc
+}
+
+// advance moves the trigger to its next run. Daily triggers move by calendar
+// day, so they keep their clock time across daylight saving changes.
+func (t *trigger) advance() {
+	if t.every > 0 {
+		t.next = t.next.Add(t.every)
+		return
+	}
+	d := t.next.AddDate(0, 0, 1)
+	t.next = time.Date(d.Year(), d.Month(), d.Day(), t.at.Hour(), t.at.Minute(), 0, 0, d.Location())
+}
+
+// At schedules fn to run every day at the given clock time, formatted as "15:04".
+func (a *Agent) At(clockTime string, fn TriggerFunc) error {
+	t, err := time.Parse("15:04", clockTime)
+	if err != nil {
+		return fmt.Errorf("invalid trigger time: %w", err)
+	}
+	now := a.Clock().Now()
+	next := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+	if next.Before(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	a.triggers = append(a.triggers, &trigger{next: next, at: t, fn: fn})
+	return nil
+}
+
+// Every schedules fn to run repeatedly at the given interval, starting one
+// interval from now.
+func (a *Agent) Every(d time.Duration, fn TriggerFunc) error {
+	if d <= 0 {
+		return errors.New("trigger interval must be positive")
+	}
+	a.triggers = append(a.triggers, &trigger{next: a.Clock().Now().Add(d), every: d, fn: fn})
+	return nil
+}
+
+// Tick runs every trigger that has come due on the agent's clock. A trigger that
+// was missed several times runs once and is rescheduled after the current time.
+// Cached retrievals from the previous tick are discarded.
+func (a *Agent) Tick() error {
+	a.Memory.ResetCache()
+	now := a.Clock().Now()
+	var errs []error
+	for _, t := range a.triggers {
+		if now.Before(t.next) {
+			continue
+		}
+		for !now.Before(t.next) {
+			t.advance()
+		}
+		if err := t.fn(now); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,49 @@
+package a25
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lordtatty/a25/clock"
+)
+
+func TestAtKeepsClockTimeAcrossDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	tests := []struct {
+		name  string
+		start time.Time
+		at    string
+	}{
+		{"spring forward", time.Date(2024, 3, 8, 12, 0, 0, 0, ny), "07:00"},
+		{"fall back", time.Date(2024, 11, 1, 12, 0, 0, 0, ny), "07:00"},
+	}
+	for _, tt := range tests {
+		c := clock.NewManual(tt.start)
+		a := NewAgent("Klaus", "", "", nil)
+		a.SetClock(c)
+		var runs []time.Time
+		if err := a.At(tt.at, func(now time.Time) error {
+			runs = append(runs, now)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 5*24*4; i++ {
+			c.Advance(15 * time.Minute)
+			if err := a.Tick(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if len(runs) != 5 {
+			t.Errorf("%s: ran %d times in five days, want 5", tt.name, len(runs))
+		}
+		for _, r := range runs[1:] {
+			if got := r.Format("15:04"); got != tt.at {
+				t.Errorf("%s: ran at %s on %s, want %s", tt.name, got, r.Format("Jan 2"), tt.at)
+			}
+		}
+	}
+}
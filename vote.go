@@ -0,0 +1,152 @@
+package a25
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Ballot is a single agent's vote in a group decision.
+type Ballot struct {
+	Agent  string
+	Choice string
+	Reason string
+}
+
+// VoteResult holds the tallied outcome of a group decision.
+type VoteResult struct {
+	Question string
+	Winner   string
+	Tally    map[string]int
+	Ballots  []Ballot
+}
+
+// Dissenters returns the ballots that were not cast for the winning option.
+func (r *VoteResult) Dissenters() []Ballot {
+	var out []Ballot
+	for _, b := range r.Ballots {
+		if b.Choice != r.Winner {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+// Vote polls each agent on the question, tallies the ballots and broadcasts the
+// outcome to every agent as an observation. Agents who voted for a losing option
+// also remember their dissent. Ties are broken by the order of the options.
+func Vote(agents []*Agent, question string, options []string) (*VoteResult, error) {
+	return VoteContext(context.Background(), agents, question, options)
+}
+
+// VoteContext is Vote under the given context.
+func VoteContext(ctx context.Context, agents []*Agent, question string, options []string) (*VoteResult, error) {
+	if len(options) == 0 {
+		return nil, errors.New("no options to vote on")
+	}
+	result := &VoteResult{
+		Question: question,
+		Tally:    make(map[string]int),
+	}
+	for _, a := range agents {
+		b, err := a.CastVoteContext(ctx, question, options)
+		if err != nil {
+			return nil, fmt.Errorf("%s failed to vote: %w", a.Name, err)
+		}
+		result.Ballots = append(result.Ballots, b)
+		result.Tally[b.Choice]++
+	}
+	for _, o := range options {
+		if result.Winner == "" || result.Tally[o] > result.Tally[result.Winner] {
+			result.Winner = o
+		}
+	}
+
+	// Broadcast the outcome and record any dissent.
+	outcome := fmt.Sprintf("The group voted on '%s' and chose %s (%d of %d votes).", question, result.Winner, result.Tally[result.Winner], len(result.Ballots))
+	for i, a := range agents {
+		if err := a.Memory.AddMemoryContext(ctx, outcome); err != nil {
+			return nil, fmt.Errorf("%s failed to remember the outcome: %w", a.Name, err)
+		}
+		b := result.Ballots[i]
+		if b.Choice == result.Winner {
+			continue
+		}
+		if err := a.Memory.AddMemoryContext(ctx, fmt.Sprintf("%s voted for %s on '%s', but the group chose %s.", a.Name, b.Choice, question, result.Winner)); err != nil {
+			return nil, fmt.Errorf("%s failed to remember the outcome: %w", a.Name, err)
+		}
+	}
+	return result, nil
+}
+
+// CastVote asks the agent to choose one of the options, grounded in its memories
+// relevant to the question. The vote is recorded in the agent's memory.
+func (a *Agent) CastVote(question string, options []string) (Ballot, error) {
+	return a.CastVoteContext(context.Background(), question, options)
+}
+
+// CastVoteContext is CastVote under the given context.
+func (a *Agent) CastVoteContext(ctx context.Context, question string, options []string) (Ballot, error) {
+	retrieved, err := a.Memory.RetrieveMemoriesContext(ctx, question)
+	if err != nil {
+		return Ballot{}, fmt.Errorf("failed to retrieve memories: %w", err)
+	}
+	sysPrompt := `You are voting in a group decision on behalf of the agent described below.
+Choose exactly one of the options based on the agent's traits and memories.
+Respond with the chosen option, exactly as written, on the first line and a brief reason on the second line.`
+	usrPrompt := fmt.Sprintf(`Agent: %s
+Traits: %s
+Description: %s
+Relevant Memories:
+%s
+Question: %s
+Options:
+- %s`, a.Name, a.Traits, a.Description, memoryList(retrieved), question, strings.Join(options, "\n- "))
+
+	resp, err := a.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: a.model(),
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: sysPrompt},
+			{Role: "user", Content: usrPrompt},
+		},
+		Temperature: a.temperature(),
+	})
+	if err != nil {
+		return Ballot{}, err
+	}
+
+	b, err := parseBallot(resp.Choices[0].Message.Content, options)
+	if err != nil {
+		return Ballot{}, err
+	}
+	b.Agent = a.Name
+	if err := a.Memory.AddMemoryContext(ctx, fmt.Sprintf("%s voted for %s on '%s' because: %s", a.Name, b.Choice, question, b.Reason)); err != nil {
+		return Ballot{}, fmt.Errorf("failed to remember vote: %w", err)
+	}
+	return b, nil
+}
+
+// parseBallot matches the model's response against the available options.
+func parseBallot(response string, options []string) (Ballot, error) {
+	lines := strings.SplitN(strings.TrimSpace(response), "\n", 2)
+	choice := strings.ToLower(strings.Trim(lines[0], " -*.\"'"))
+	var reason string
+	if len(lines) == 2 {
+		reason = strings.TrimSpace(lines[1])
+	}
+	// Prefer an exact match, then fall back to the first option mentioned.
+	for _, o := range options {
+		if strings.ToLower(o) == choice {
+			return Ballot{Choice: o, Reason: reason}, nil
+		}
+	}
+	for _, o := range options {
+		if strings.Contains(choice, strings.ToLower(o)) {
+			return Ballot{Choice: o, Reason: reason}, nil
+		}
+	}
+	return Ballot{}, fmt.Errorf("vote did not match any option: %q", lines[0])
+}